@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"filmoteka/configs"
+	"filmoteka/configs/logger"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/catalog"
+	"filmoteka/pkg/models"
+	"filmoteka/repository/psx"
+	core_audit "filmoteka/usecase/audit"
+	core_catalog "filmoteka/usecase/catalog"
+	core_versions "filmoteka/usecase/versions"
+	"flag"
+	"fmt"
+	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const usage = `usage: cli <command> [flags]
+
+commands:
+  import [-format csv|json|ndjson] [-dry-run] FILE   import films, actors and links
+  export [-format csv|json|ndjson] [-o FILE] [filters]   export films, actors and links,
+         filters: -title, -actor, -director, -genres, -release-date-from, -release-date-to,
+         -rating-from, -rating-to
+
+Database settings are read from the environment and .env, e.g.
+POSTGRES_HOST=localhost POSTGRES_PORT=5435 go run ./cmd/cli import films.csv
+`
+
+// commands maps every subcommand to its implementation, which receives the
+// arguments that follow the command name and returns the exit code.
+var commands = map[string]func(args []string, log *logrus.Logger) int{
+	"import": importCommand,
+	"export": exportCommand,
+}
+
+func main() {
+	log := logger.GetLogger()
+	log.SetOutput(os.Stderr)
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command, found := commands[os.Args[1]]
+	if !found {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	err := godotenv.Load()
+	if err != nil {
+		log.Warn("load .env error: ", err)
+	}
+
+	os.Exit(command(os.Args[2:], log))
+}
+
+func importCommand(args []string, log *logrus.Logger) int {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "", "file format, taken from the file extension when omitted")
+	dryRun := flags.Bool("dry-run", false, "report what would be imported without saving anything")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	path := flags.Arg(0)
+
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	if !slices.Contains(catalog.Formats, *format) {
+		log.Errorf("unknown format %q, use -format csv, json or ndjson", *format)
+		return 2
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Error("open file error: ", err)
+		return 1
+	}
+	defer file.Close()
+
+	records, err := catalog.Decode(*format, file)
+	if err != nil {
+		log.Error("read catalog error: ", err)
+		return 1
+	}
+
+	catalogCore, err := getCatalogCore(log)
+	if err != nil {
+		return 1
+	}
+
+	report, err := catalogCore.ImportCatalog(context.Background(), records, *dryRun)
+	if err != nil {
+		log.Error("import catalog error: ", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+
+	if len(report.Errors) > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+func exportCommand(args []string, log *logrus.Logger) int {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "", "file format, taken from the -o extension or json when omitted")
+	output := flags.String("o", "", "output file, standard output when omitted")
+	title := flags.String("title", "", "full-text search over title, description and cast names")
+	actor := flags.String("actor", "", "actor name")
+	director := flags.String("director", "", "director name")
+	genres := flags.String("genres", "", "comma separated genre ids")
+	releaseDateFrom := flags.String("release-date-from", "", "release date from, YYYY-MM-DD")
+	releaseDateTo := flags.String("release-date-to", "", "release date to, YYYY-MM-DD")
+	ratingFrom := flags.Float64("rating-from", 0, "minimum rating")
+	ratingTo := flags.Float64("rating-to", utils.VoteEnd, "maximum rating")
+	flags.Parse(args)
+
+	if *format == "" && *output != "" {
+		*format = strings.TrimPrefix(filepath.Ext(*output), ".")
+	}
+	if *format == "" {
+		*format = catalog.FormatJSON
+	}
+	if !slices.Contains(catalog.Formats, *format) {
+		log.Errorf("unknown format %q, use -format csv, json or ndjson", *format)
+		return 2
+	}
+
+	var request *models.FindFilmRequest
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "format" || f.Name == "o" || request != nil {
+			return
+		}
+
+		genreIds, err := utils.ParseIdList(*genres)
+		if err != nil {
+			log.Error("parse genres error: ", err)
+			os.Exit(2)
+		}
+
+		request = &models.FindFilmRequest{
+			Title:           *title,
+			Actor:           *actor,
+			Director:        *director,
+			Genres:          genreIds,
+			ReleaseDateFrom: *releaseDateFrom,
+			ReleaseDateTo:   *releaseDateTo,
+			RatingFrom:      float32(*ratingFrom),
+			RatingTo:        float32(*ratingTo),
+		}
+	})
+
+	catalogCore, err := getCatalogCore(log)
+	if err != nil {
+		return 1
+	}
+
+	file := os.Stdout
+	if *output != "" {
+		file, err = os.Create(*output)
+		if err != nil {
+			log.Error("create file error: ", err)
+			return 1
+		}
+		defer file.Close()
+	}
+
+	writer := bufio.NewWriter(file)
+	err = catalogCore.ExportCatalog(context.Background(), request, *format, writer)
+	if err != nil {
+		log.Error("export catalog error: ", err)
+		return 1
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		log.Error("write file error: ", err)
+		return 1
+	}
+
+	return 0
+}
+
+func getCatalogCore(log *logrus.Logger) (*core_catalog.Catalog, error) {
+	psxCfg, err := configs.GetPsxConfig()
+	if err != nil {
+		log.Error("Create psx config error: ", err)
+		return nil, err
+	}
+
+	repo, err := psx.GetFilmRepo(psxCfg, log)
+	if err != nil {
+		log.Error("Get GetFilmRepo error: ", err)
+		return nil, err
+	}
+
+	return core_catalog.NewCoreCatalog(repo, core_audit.NewCoreAudit(repo, log), core_versions.NewCoreVersions(repo, log), log), nil
+}
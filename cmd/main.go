@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"filmoteka/configs"
 	"filmoteka/configs/logger"
 	delivery "filmoteka/delivery/http"
+	"filmoteka/pkg/scheduler"
 	"filmoteka/usecase"
 	"github.com/joho/godotenv"
 	_ "github.com/swaggo/swag"
@@ -14,6 +16,10 @@ import (
 // @description API Server fot Application
 // @host 127.0.0.1:8081
 // @BasePath /
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Personal access token from /api/v1/me/tokens/add as "Bearer flm_...".
 func main() {
 	log := logger.GetLogger()
 	err := godotenv.Load()
@@ -34,13 +40,62 @@ func main() {
 		return
 	}
 
-	core, err := usecase.GetCore(psxCfg, redisCfg, log)
+	apiCfg, err := configs.GetApiConfig()
+	if err != nil {
+		log.Error("Create api config error: ", err)
+		return
+	}
+
+	trashCfg, err := configs.GetTrashConfig()
+	if err != nil {
+		log.Error("Create trash config error: ", err)
+		return
+	}
+
+	storageCfg, err := configs.GetStorageConfig()
+	if err != nil {
+		log.Error("Create storage config error: ", err)
+		return
+	}
+
+	cacheCfg, err := configs.GetCacheConfig()
+	if err != nil {
+		log.Error("Create cache config error: ", err)
+		return
+	}
+
+	recommendationsCfg, err := configs.GetRecommendationsConfig()
+	if err != nil {
+		log.Error("Create recommendations config error: ", err)
+		return
+	}
+
+	core, err := usecase.GetCore(psxCfg, redisCfg, trashCfg, storageCfg, cacheCfg, recommendationsCfg, log)
 	if err != nil {
 		log.Error("Create core error: ", err)
 		return
 	}
 
-	api := delivery.GetApi(core, log)
+	go scheduler.Every(context.Background(), trashCfg.PurgeInterval, "trash purge", func(ctx context.Context) error {
+		_, err := core.Trash.Purge(ctx)
+		return err
+	}, log)
+
+	refreshSimilarity := func(ctx context.Context) error {
+		_, err := core.Recommendations.RefreshSimilarity(ctx)
+		return err
+	}
+
+	// the model is built at start as well, the first tick is a whole interval away
+	go func() {
+		err := refreshSimilarity(context.Background())
+		if err != nil {
+			log.Error("film similarity job error: ", err)
+		}
+		scheduler.Every(context.Background(), recommendationsCfg.Interval, "film similarity", refreshSimilarity, log)
+	}()
+
+	api := delivery.GetApi(core, apiCfg, log)
 
 	log.Info("Server running")
 	err = api.ListenAndServe("8081")
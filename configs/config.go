@@ -1,7 +1,11 @@
 package configs
 
 import (
+	"fmt"
 	"github.com/spf13/viper"
+	"net/netip"
+	"strings"
+	"time"
 )
 
 type DbPsxConfig struct {
@@ -53,3 +57,145 @@ func GetRedisConfig() (*DbRedisCfg, error) {
 
 	return cfg, nil
 }
+
+type ApiCfg struct {
+	DefaultPageSize uint64         `yaml:"default_page_size"`
+	MaxPageSize     uint64         `yaml:"max_page_size"`
+	TrustedProxies  []netip.Prefix `yaml:"trusted_proxies"`
+}
+
+func GetApiConfig() (*ApiCfg, error) {
+	v := viper.GetViper()
+	v.AutomaticEnv()
+	v.SetDefault("API_DEFAULT_PAGE_SIZE", 8)
+	v.SetDefault("API_MAX_PAGE_SIZE", 100)
+
+	cfg := &ApiCfg{
+		DefaultPageSize: v.GetUint64("API_DEFAULT_PAGE_SIZE"),
+		MaxPageSize:     v.GetUint64("API_MAX_PAGE_SIZE"),
+	}
+
+	if cfg.DefaultPageSize == 0 || cfg.MaxPageSize < cfg.DefaultPageSize {
+		return nil, fmt.Errorf("page size config error: default %d, max %d", cfg.DefaultPageSize, cfg.MaxPageSize)
+	}
+
+	for _, proxy := range strings.Split(v.GetString("API_TRUSTED_PROXIES"), ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		prefix, err := parsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxies config error: %s", err.Error())
+		}
+		cfg.TrustedProxies = append(cfg.TrustedProxies, prefix)
+	}
+
+	return cfg, nil
+}
+
+// parsePrefix parses a network in CIDR notation or a single address.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix.Masked(), err
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+}
+
+type TrashCfg struct {
+	Retention     time.Duration `yaml:"retention"`
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+func GetTrashConfig() (*TrashCfg, error) {
+	v := viper.GetViper()
+	v.AutomaticEnv()
+	v.SetDefault("TRASH_RETENTION", "720h")
+	v.SetDefault("TRASH_PURGE_INTERVAL", "1h")
+
+	cfg := &TrashCfg{
+		Retention:     v.GetDuration("TRASH_RETENTION"),
+		PurgeInterval: v.GetDuration("TRASH_PURGE_INTERVAL"),
+	}
+
+	if cfg.Retention <= 0 || cfg.PurgeInterval <= 0 {
+		return nil, fmt.Errorf("trash config error: retention %s, purge interval %s", cfg.Retention, cfg.PurgeInterval)
+	}
+
+	return cfg, nil
+}
+
+type StorageCfg struct {
+	Dir string `yaml:"dir"`
+}
+
+func GetStorageConfig() (*StorageCfg, error) {
+	v := viper.GetViper()
+	v.AutomaticEnv()
+	v.SetDefault("IMAGES_DIR", "images")
+
+	cfg := &StorageCfg{
+		Dir: v.GetString("IMAGES_DIR"),
+	}
+
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("storage config error: empty images dir")
+	}
+
+	return cfg, nil
+}
+
+type CacheCfg struct {
+	SimilarFilmsTtl time.Duration `yaml:"similar_films_ttl"`
+}
+
+func GetCacheConfig() (*CacheCfg, error) {
+	v := viper.GetViper()
+	v.AutomaticEnv()
+	v.SetDefault("SIMILAR_FILMS_CACHE_TTL", "1h")
+
+	cfg := &CacheCfg{
+		SimilarFilmsTtl: v.GetDuration("SIMILAR_FILMS_CACHE_TTL"),
+	}
+
+	if cfg.SimilarFilmsTtl <= 0 {
+		return nil, fmt.Errorf("cache config error: similar films ttl %s", cfg.SimilarFilmsTtl)
+	}
+
+	return cfg, nil
+}
+
+type RecommendationsCfg struct {
+	Interval   time.Duration `yaml:"interval"`
+	Neighbours uint64        `yaml:"neighbours"`
+	MinRaters  uint64        `yaml:"min_raters"`
+}
+
+func GetRecommendationsConfig() (*RecommendationsCfg, error) {
+	v := viper.GetViper()
+	v.AutomaticEnv()
+	v.SetDefault("RECOMMENDATIONS_INTERVAL", "1h")
+	v.SetDefault("RECOMMENDATIONS_NEIGHBOURS", 50)
+	v.SetDefault("RECOMMENDATIONS_MIN_RATERS", 2)
+
+	cfg := &RecommendationsCfg{
+		Interval:   v.GetDuration("RECOMMENDATIONS_INTERVAL"),
+		Neighbours: v.GetUint64("RECOMMENDATIONS_NEIGHBOURS"),
+		MinRaters:  v.GetUint64("RECOMMENDATIONS_MIN_RATERS"),
+	}
+
+	if cfg.Interval <= 0 || cfg.Neighbours == 0 || cfg.MinRaters == 0 {
+		return nil, fmt.Errorf("recommendations config error: interval %s, neighbours %d, min raters %d",
+			cfg.Interval, cfg.Neighbours, cfg.MinRaters)
+	}
+
+	return cfg, nil
+}
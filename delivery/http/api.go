@@ -1,36 +1,53 @@
 package delivery
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"filmoteka/configs"
 	_ "filmoteka/docs"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/catalog"
+	"filmoteka/pkg/images"
 	"filmoteka/pkg/middleware"
 	"filmoteka/pkg/models"
 	httpResponse "filmoteka/pkg/response"
 	"filmoteka/usecase"
+	"fmt"
 	"github.com/sirupsen/logrus"
 	"io"
+	"math"
 	"net/http"
+	"net/url"
+	"path"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type Api struct {
-	log  *logrus.Logger
-	mx   *http.ServeMux
-	core *usecase.Core
+	log     *logrus.Logger
+	mx      *http.ServeMux
+	handler http.Handler
+	core    *usecase.Core
+	cfg     *configs.ApiCfg
 }
 
-func GetApi(core *usecase.Core, log *logrus.Logger) *Api {
+func GetApi(core *usecase.Core, cfg *configs.ApiCfg, log *logrus.Logger) *Api {
 	api := &Api{
 		core: core,
 		log:  log,
 		mx:   http.NewServeMux(),
+		cfg:  cfg,
 	}
 
 	md := middleware.Middleware{
-		Lg:       log,
-		Sessions: core.Sessions,
-		Profiles: core.Profiles,
+		Lg:             log,
+		Sessions:       core.Sessions,
+		Tokens:         core.Tokens,
+		TrustedProxies: cfg.TrustedProxies,
 	}
 
 	api.mx.HandleFunc("/signin", api.Signin)
@@ -39,21 +56,88 @@ func GetApi(core *usecase.Core, log *logrus.Logger) *Api {
 	api.mx.HandleFunc("/authcheck", api.AuthAccept)
 
 	api.mx.HandleFunc("/api/v1/actors", api.FindActors)
-	api.mx.Handle("/api/v1/actors/add", md.AuthCheck(md.CheckRole(http.HandlerFunc(api.AddActor))))
-	api.mx.Handle("/api/v1/actors/update", md.AuthCheck(md.CheckRole(http.HandlerFunc(api.UpdateActor))))
-	api.mx.Handle("/api/v1/actors/delete", md.AuthCheck(md.CheckRole(http.HandlerFunc(api.DeleteActor))))
+	api.mx.Handle("/api/v1/actors/", api.pathRouter("/api/v1/actors/", map[string]http.Handler{
+		"GET ":              http.HandlerFunc(api.GetActor),
+		"GET revisions":     http.HandlerFunc(api.FindActorRevisions),
+		"GET collaborators": http.HandlerFunc(api.FindCollaborators),
+	}))
+	api.mx.HandleFunc("/api/v1/actors/path", api.FindActorPath)
+	api.mx.Handle("/api/v1/actors/add", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.AddActor))), utils.TokenScopeWriteActors))
+	api.mx.Handle("/api/v1/actors/update", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.UpdateActor))), utils.TokenScopeWriteActors))
+	api.mx.Handle("/api/v1/actors/delete", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.DeleteActor))), utils.TokenScopeWriteActors))
+	api.mx.Handle("/api/v1/actors/trash", md.AuthCheck(md.CheckRole(http.HandlerFunc(api.FindDeletedActors))))
+	api.mx.Handle("/api/v1/actors/restore", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.RestoreActor))), utils.TokenScopeWriteActors))
+	api.mx.Handle("/api/v1/actors/rollback", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.RollbackActor))), utils.TokenScopeWriteActors))
+	api.mx.Handle("/api/v1/actors/photo", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.UploadActorPhoto))), utils.TokenScopeWriteActors))
 
-	api.mx.HandleFunc("/api/v1/films", api.FindFilms)
-	api.mx.HandleFunc("/api/v1/films/search", api.SearchFilms)
-	api.mx.Handle("/api/v1/films/add", md.AuthCheck(md.CheckRole(http.HandlerFunc(api.AddFilm))))
-	api.mx.Handle("/api/v1/films/update", md.AuthCheck(md.CheckRole(http.HandlerFunc(api.UpdateFilm))))
-	api.mx.Handle("/api/v1/films/delete", md.AuthCheck(md.CheckRole(http.HandlerFunc(api.DeleteFilm))))
+	api.mx.HandleFunc("/api/v1/genres", api.FindGenres)
+	api.mx.Handle("/api/v1/genres/add", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.AddGenre))), utils.TokenScopeWriteFilms))
+	api.mx.Handle("/api/v1/genres/update", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.UpdateGenre))), utils.TokenScopeWriteFilms))
+	api.mx.Handle("/api/v1/genres/delete", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.DeleteGenre))), utils.TokenScopeWriteFilms))
+
+	api.mx.Handle("/api/v1/films", md.AuthOptional(http.HandlerFunc(api.FindFilms)))
+	api.mx.Handle("/api/v1/films/search", md.AuthOptional(http.HandlerFunc(api.SearchFilms)))
+	api.mx.Handle("/api/v1/films/", api.pathRouter("/api/v1/films/", map[string]http.Handler{
+		"GET ":          http.HandlerFunc(api.GetFilm),
+		"GET rating":    md.AuthCheck(http.HandlerFunc(api.GetFilmRating)),
+		"POST rating":   md.Scope(md.AuthCheck(http.HandlerFunc(api.RateFilm)), utils.TokenScopeWriteFilms),
+		"DELETE rating": md.Scope(md.AuthCheck(http.HandlerFunc(api.DeleteFilmRating)), utils.TokenScopeWriteFilms),
+		"GET reviews":   http.HandlerFunc(api.FindReviews),
+		"POST reviews":  md.Scope(md.AuthCheck(http.HandlerFunc(api.AddReview)), utils.TokenScopeWriteFilms),
+		"GET revisions": http.HandlerFunc(api.FindFilmRevisions),
+		"GET similar":   http.HandlerFunc(api.FindSimilarFilms),
+	}))
+
+	api.mx.Handle("/api/v1/me/lists", md.AuthCheck(http.HandlerFunc(api.FindList)))
+	api.mx.Handle("/api/v1/me/recommendations", md.AuthCheck(http.HandlerFunc(api.FindRecommendations)))
+	api.mx.Handle("/api/v1/me/lists/add", md.Scope(md.AuthCheck(http.HandlerFunc(api.AddToList)), utils.TokenScopeWriteFilms))
+	api.mx.Handle("/api/v1/me/lists/delete", md.Scope(md.AuthCheck(http.HandlerFunc(api.DeleteFromList)), utils.TokenScopeWriteFilms))
+	api.mx.Handle("/api/v1/me/sessions", md.AuthCheck(http.HandlerFunc(api.FindMySessions)))
+	api.mx.Handle("/api/v1/me/sessions/delete", md.AuthCheck(http.HandlerFunc(api.DeleteMySession)))
+	api.mx.Handle("/api/v1/me/sessions/delete_others", md.AuthCheck(http.HandlerFunc(api.DeleteOtherSessions)))
+	api.mx.Handle("/api/v1/me/tokens", md.AuthCheck(http.HandlerFunc(api.FindTokens)))
+	api.mx.Handle("/api/v1/me/tokens/add", md.AuthCheck(http.HandlerFunc(api.AddToken)))
+	api.mx.Handle("/api/v1/me/tokens/delete", md.AuthCheck(http.HandlerFunc(api.DeleteToken)))
+
+	api.mx.Handle("/api/v1/reviews/update", md.Scope(md.AuthCheck(http.HandlerFunc(api.UpdateReview)), utils.TokenScopeWriteFilms))
+	api.mx.Handle("/api/v1/reviews/delete", md.Scope(md.AuthCheck(http.HandlerFunc(api.DeleteReview)), utils.TokenScopeWriteFilms))
+	api.mx.Handle("/api/v1/reviews/hide", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.HideReview))), utils.TokenScopeWriteFilms))
+	api.mx.Handle("/api/v1/films/add", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.AddFilm))), utils.TokenScopeWriteFilms))
+	api.mx.Handle("/api/v1/films/update", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.UpdateFilm))), utils.TokenScopeWriteFilms))
+	api.mx.Handle("/api/v1/films/delete", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.DeleteFilm))), utils.TokenScopeWriteFilms))
+	api.mx.Handle("/api/v1/films/trash", md.AuthCheck(md.CheckRole(http.HandlerFunc(api.FindDeletedFilms))))
+	api.mx.Handle("/api/v1/films/restore", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.RestoreFilm))), utils.TokenScopeWriteFilms))
+	api.mx.Handle("/api/v1/films/rollback", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.RollbackFilm))), utils.TokenScopeWriteFilms))
+	api.mx.Handle("/api/v1/films/poster", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.UploadFilmPoster))), utils.TokenScopeWriteFilms))
+	api.mx.Handle("/api/v1/films/relations/add", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.AddFilmRelation))), utils.TokenScopeWriteFilms))
+	api.mx.Handle("/api/v1/films/relations/delete", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.DeleteFilmRelation))), utils.TokenScopeWriteFilms))
+
+	api.mx.HandleFunc("/api/v1/franchises", api.FindFranchises)
+	api.mx.Handle("/api/v1/franchises/", api.pathRouter("/api/v1/franchises/", map[string]http.Handler{
+		"GET ": http.HandlerFunc(api.GetFranchise),
+	}))
+	api.mx.Handle("/api/v1/franchises/add", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.AddFranchise))), utils.TokenScopeWriteFilms))
+	api.mx.Handle("/api/v1/franchises/update", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.UpdateFranchise))), utils.TokenScopeWriteFilms))
+	api.mx.Handle("/api/v1/franchises/delete", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.DeleteFranchise))), utils.TokenScopeWriteFilms))
+
+	api.mx.Handle("/api/v1/catalog/import", md.Scope(md.AuthCheck(md.CheckRole(http.HandlerFunc(api.ImportCatalog))), utils.TokenScopeWriteFilms, utils.TokenScopeWriteActors))
+	api.mx.Handle("/api/v1/catalog/export", md.AuthCheck(md.CheckRole(http.HandlerFunc(api.ExportCatalog))))
+
+	api.mx.Handle("/api/v1/audit", md.AuthCheck(md.CheckRole(http.HandlerFunc(api.FindAudit))))
+	api.mx.Handle("/api/v1/stats", md.AuthCheck(md.CheckRole(http.HandlerFunc(api.GetStats))))
+	api.mx.Handle("/api/v1/profiles/role", md.AuthCheck(md.CheckRole(http.HandlerFunc(api.UpdateRole))))
+	api.mx.Handle("/api/v1/profiles/sessions", md.AuthCheck(md.CheckRole(http.HandlerFunc(api.FindUserSessions))))
+	api.mx.Handle("/api/v1/profiles/sessions/delete", md.AuthCheck(md.CheckRole(http.HandlerFunc(api.DeleteUserSessions))))
+
+	api.mx.HandleFunc(images.UrlPrefix, api.GetImage)
+
+	api.handler = md.RequestMeta(api.mx)
 
 	return api
 }
 
 func (a *Api) ListenAndServe(port string) error {
-	err := http.ListenAndServe(":"+port, a.mx)
+	err := http.ListenAndServe(":"+port, a.handler)
 	if err != nil {
 		a.log.Error("ListenAndServer error: ", err.Error())
 		return err
@@ -62,6 +146,176 @@ func (a *Api) ListenAndServe(port string) error {
 	return nil
 }
 
+// pathRouter dispatches requests under prefix of the form prefix{id}/action to
+// routes keyed by "METHOD action", where the action is empty for the item itself.
+func (a *Api) pathRouter(prefix string, routes map[string]http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := models.Response{Status: http.StatusNotFound, Body: nil}
+
+		_, action, err := utils.ParsePathId(r.URL.Path, prefix)
+		if err != nil {
+			response.Status = pathIdStatus(err)
+			httpResponse.SendResponse(w, r, &response, a.log)
+			return
+		}
+
+		handler, found := routes[r.Method+" "+action]
+		if found {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		for route := range routes {
+			if strings.HasSuffix(route, " "+action) {
+				response.Status = http.StatusMethodNotAllowed
+				break
+			}
+		}
+
+		httpResponse.SendResponse(w, r, &response, a.log)
+	})
+}
+
+// pathIdStatus returns the status of a request whose path id could not be
+// parsed: 400 when the id is not a number, 404 when there is none.
+func pathIdStatus(err error) int {
+	if errors.Is(err, utils.ErrPathId) {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusNotFound
+}
+
+// currentSessionId returns the id of the session the request came with, or an
+// empty string for a request authorised by a personal access token.
+func currentSessionId(r *http.Request) string {
+	active, isSession := r.Context().Value(utils.SessionKey).(*models.Session)
+	if !isSession {
+		return ""
+	}
+
+	return active.SID
+}
+
+// pageParams reads the page and per_page query parameters. Pages are counted
+// from 1 and per_page is capped by the configured maximum page size.
+func (a *Api) pageParams(query url.Values) (uint64, uint64) {
+	page, err := strconv.ParseUint(query.Get("page"), 10, 64)
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	perPage, err := strconv.ParseUint(query.Get("per_page"), 10, 64)
+	if err != nil || perPage < 1 {
+		perPage = a.cfg.DefaultPageSize
+	}
+
+	return page, min(perPage, a.cfg.MaxPageSize)
+}
+
+// limitParam reads the limit query parameter of top lists, which is
+// utils.FilmsTopLimit when absent and is capped by the configured maximum
+// page size.
+func (a *Api) limitParam(query url.Values) (uint64, error) {
+	if query.Get("limit") == "" {
+		return min(utils.FilmsTopLimit, a.cfg.MaxPageSize), nil
+	}
+
+	limit, err := strconv.ParseUint(query.Get("limit"), 10, 64)
+	if err != nil || limit < 1 {
+		return 0, fmt.Errorf("parse limit error: %s", query.Get("limit"))
+	}
+
+	return min(limit, a.cfg.MaxPageSize), nil
+}
+
+// timeParam parses the query parameter name given either as an RFC 3339
+// timestamp or as a date, which stands for its midnight in UTC. It is nil
+// when the parameter is absent.
+func timeParam(query url.Values, name string) (*time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.Parse(time.DateOnly, value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s error: %s", name, err.Error())
+	}
+
+	return &t, nil
+}
+
+// filmFilterParams are the query parameters read by filmFilters.
+var filmFilterParams = []string{"title", "actor", "director", "release_date_from", "release_date_to", "rating_from", "rating_to", "genres"}
+
+// filmFilters reads the film filters shared by the film listing and the
+// catalog export from query.
+func filmFilters(query url.Values) (*models.FindFilmRequest, error) {
+	ratingFrom, err := strconv.ParseFloat(query.Get("rating_from"), 32)
+	if err != nil {
+		ratingFrom = 0
+	}
+
+	ratingTo, err := strconv.ParseFloat(query.Get("rating_to"), 32)
+	if err != nil {
+		ratingTo = utils.VoteEnd
+	}
+
+	genres, err := utils.ParseIdList(query.Get("genres"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.FindFilmRequest{
+		Title:           query.Get("title"),
+		RatingFrom:      float32(ratingFrom),
+		RatingTo:        float32(ratingTo),
+		ReleaseDateFrom: query.Get("release_date_from"),
+		ReleaseDateTo:   query.Get("release_date_to"),
+		Actor:           query.Get("actor"),
+		Director:        query.Get("director"),
+		Genres:          genres,
+	}, nil
+}
+
+// cursorParam decodes the cursor query parameter, it is nil when absent.
+func cursorParam(query url.Values) (*models.Cursor, error) {
+	token := query.Get("cursor")
+	if token == "" {
+		return nil, nil
+	}
+
+	return utils.DecodeCursor(token)
+}
+
+// filmCursorValid tells whether cursor was made for one of the film orders
+// and holds values of its types. Films are ordered by relevance only when
+// filtered by title.
+func filmCursorValid(cursor *models.Cursor, title string) bool {
+	types, found := utils.FilmCursorTypes[cursor.Sort]
+	if !found || cursor.Sort == utils.FilmSortRelevance && title == "" {
+		return false
+	}
+
+	return utils.ValidateCursor(cursor, types) == nil
+}
+
+// actorCursorValid tells whether cursor was made for one of the actor sorts,
+// named with a ":desc" suffix when descending, and holds values of its types.
+func actorCursorValid(cursor *models.Cursor) bool {
+	sort, order, hasOrder := strings.Cut(cursor.Sort, ":")
+	types, found := utils.ActorCursorTypes[sort]
+	if !found || hasOrder && order != utils.OrderDesc {
+		return false
+	}
+
+	return utils.ValidateCursor(cursor, types) == nil
+}
+
 // @Summary signIn
 // @Tags Auth
 // @Description authenticate user by providing login and password credentials
@@ -102,7 +356,7 @@ func (a *Api) Signin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, found, err := a.core.Profiles.FindUserAccount(r.Context(), request.Login, request.Password)
+	user, found, err := a.core.Profiles.FindUserAccount(r.Context(), request.Login, request.Password)
 	if err != nil {
 		a.log.Error("Signin error: ", err.Error())
 		response.Status = http.StatusInternalServerError
@@ -116,7 +370,35 @@ func (a *Api) Signin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session, _ := a.core.Sessions.CreateSession(r.Context(), request.Login)
+	// A session the client already has is replaced rather than reused, so an id
+	// planted before signin never gains the privileges of the account.
+	previous := middleware.SessionId(r)
+	if previous != "" {
+		err = a.core.Sessions.KillSession(r.Context(), previous)
+		if err != nil {
+			a.log.Error("Signin error: ", err.Error())
+		}
+	}
+
+	err = a.startSession(w, r, user)
+	if err != nil {
+		a.log.Error("Signin error: ", err.Error())
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// startSession makes a new session for the user and sends it in the cookie.
+func (a *Api) startSession(w http.ResponseWriter, r *http.Request, user *models.UserItem) error {
+	ip, _ := r.Context().Value(utils.ClientIPKey).(string)
+	session, err := a.core.Sessions.CreateSession(r.Context(), user, ip, r.UserAgent())
+	if err != nil {
+		return err
+	}
+
 	cookie := &http.Cookie{
 		Name:     "session_id",
 		Value:    session.SID,
@@ -126,7 +408,7 @@ func (a *Api) Signin(w http.ResponseWriter, r *http.Request) {
 	}
 	http.SetCookie(w, cookie)
 
-	httpResponse.SendResponse(w, r, &response, a.log)
+	return nil
 }
 
 // @Summary signUp
@@ -184,7 +466,7 @@ func (a *Api) Signup(w http.ResponseWriter, r *http.Request) {
 	err = a.core.Profiles.CreateUserAccount(r.Context(), request.Login, request.Password)
 	if err != nil {
 		a.log.Error("create user error: ", err.Error())
-		response.Status = http.StatusBadRequest
+		response.Status = http.StatusInternalServerError
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
@@ -193,11 +475,12 @@ func (a *Api) Signup(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary add a new film
-// @Description add a new film along with associated actors
+// @Description add a new film along with associated actors and crew credits, the rating is computed from user votes
 // @Tags Film
 // @Accept json
 // @Produce json
 // @Param session_id header string false "Session ID"
+// @Security BearerAuth
 // @Param input body models.FilmRequest true "Film details and actors"
 // @Success 200 {object} models.Response
 // @Failure 400 {object} models.Response
@@ -230,7 +513,18 @@ func (a *Api) AddFilm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !validFilm(&request, false) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
 	_, err = a.core.Films.AddFilm(r.Context(), &request, request.Actors)
+	if errors.Is(err, utils.ErrUnknownId) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
 	if err != nil {
 		response.Status = http.StatusInternalServerError
 		httpResponse.SendResponse(w, r, &response, a.log)
@@ -246,6 +540,7 @@ func (a *Api) AddFilm(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param session_id header string false "Session ID"
+// @Security BearerAuth
 // @Param input body models.ActorItem true "Actor details"
 // @Success 200 {object} models.Response
 // @Failure 400 {object} models.Response
@@ -293,11 +588,14 @@ func (a *Api) AddActor(w http.ResponseWriter, r *http.Request) {
 // @Tags Film
 // @Accept json
 // @Produce json
-// @Param title_film query string false "Movie title fragment"
-// @Param name_actor query string false "Actor name fragment"
-// @Param page query uint64 false "Page number (optional)" Enums(0)
-// @Param per_page query uint64 false "Number of results per page (optional)" Enums(8)
-// @Success 200 {object} models.Response
+// @Param session_id header string false "Session ID, adds favorite and watchlist flags (optional)"
+// @Security BearerAuth
+// @Param title_film query string false "Full-text search over title, description and cast names"
+// @Param name_actor query string false "Actor name fragment, case-insensitive"
+// @Param page query integer false "Page number, starting from 1 (optional)" minimum="1"
+// @Param per_page query integer false "Number of results per page, capped by API_MAX_PAGE_SIZE (optional)" minimum="1"
+// @Param cursor query string false "Opaque next or prev cursor from a previous response, replaces page (optional)"
+// @Success 200 {object} models.FilmsResponse
 // @Failure 400 {object} models.Response
 // @Failure 401 {object} models.Response
 // @Failure 405 {object} models.Response
@@ -315,23 +613,39 @@ func (a *Api) SearchFilms(w http.ResponseWriter, r *http.Request) {
 	titleFilm := r.URL.Query().Get("title_film")
 	nameActor := r.URL.Query().Get("name_actor")
 
-	page, err := strconv.ParseUint(r.URL.Query().Get("page"), 10, 64)
-	if err != nil {
-		page = 0
+	page, pageSize := a.pageParams(r.URL.Query())
+
+	cursor, err := cursorParam(r.URL.Query())
+	if err != nil || cursor != nil && !filmCursorValid(cursor, titleFilm) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
 	}
 
-	pageSize, err := strconv.ParseUint(r.URL.Query().Get("per_page"), 10, 64)
-	if err != nil {
-		pageSize = 8
+	request := &models.FindFilmRequest{
+		Title:   titleFilm,
+		Actor:   nameActor,
+		Page:    page,
+		PerPage: pageSize,
+		Cursor:  cursor,
 	}
 
-	films, err := a.core.Films.SearchFilms(r.Context(), titleFilm, nameActor, page, pageSize)
+	films, err := a.core.Films.SearchFilms(r.Context(), request)
 	if err != nil {
 		response.Status = http.StatusInternalServerError
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
+	if userId, isAuth := r.Context().Value(middleware.UserIDKey).(uint64); isAuth {
+		err = a.core.Lists.MarkFilms(r.Context(), userId, *films.Films)
+		if err != nil {
+			response.Status = http.StatusInternalServerError
+			httpResponse.SendResponse(w, r, &response, a.log)
+			return
+		}
+	}
+
 	response.Body = films
 
 	httpResponse.SendResponse(w, r, &response, a.log)
@@ -342,15 +656,20 @@ func (a *Api) SearchFilms(w http.ResponseWriter, r *http.Request) {
 // @Tags Film
 // @Accept json
 // @Produce json
-// @Param title query string false "Film title" example:"The Shawshank Redemption"
+// @Param session_id header string false "Session ID, adds favorite and watchlist flags (optional)"
+// @Security BearerAuth
+// @Param title query string false "Full-text search over title, description and cast names, results are ranked by relevance" example:"The Shawshank Redemption"
 // @Param actor query string false "Actor name" example:"Tim Robbins"
+// @Param director query string false "Director name" example:"Frank Darabont"
 // @Param release_date_from query string false "Release date from" format="date" example:"1994-01-01"
 // @Param release_date_to query string false "Release date to" format="date" example:"1995-12-31"
 // @Param rating_from query number false "Minimum rating" example:"7.0" minimum="0" maximum="10"
 // @Param rating_to query number false "Maximum rating" example:"8.5" minimum="0" maximum="10"
-// @Param order query string false "Sorting order" enum:"asc,desc" default:"desc"
+// @Param genres query string false "Comma separated genre IDs, films with any of them match" example:"1,3"
+// @Param order query string false "Sort field, relevance is the default when title is set" Enums(rating, title, release_date, relevance)
 // @Param page query integer false "Page number" example:"1" minimum="1"
-// @Param per_page query integer false "Number of items per page" example:"20" minimum="1" maximum="100"
+// @Param per_page query integer false "Number of items per page, capped by API_MAX_PAGE_SIZE" example:"20" minimum="1"
+// @Param cursor query string false "Opaque next or prev cursor from a previous response, replaces page and order"
 // @Success 200 {object} models.FilmsResponse "Successful response"
 // @Failure 400 {object} models.Response
 // @Failure 401 {object} models.Response
@@ -366,200 +685,232 @@ func (a *Api) FindFilms(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	title := r.URL.Query().Get("title")
-	actor := r.URL.Query().Get("actor")
-	releaseDataFrom := r.URL.Query().Get("release_date_from")
-	releaseDataTo := r.URL.Query().Get("release_date_to")
-	order := r.URL.Query().Get("order")
-
-	RatingFrom, err := strconv.ParseFloat(r.URL.Query().Get("rating_from"), 32)
-	if err != nil {
-		RatingFrom = 0
-	}
-
-	RatingTo, err := strconv.ParseFloat(r.URL.Query().Get("rating_to"), 32)
+	request, err := filmFilters(r.URL.Query())
 	if err != nil {
-		RatingTo = 10
-	}
-
-	page, err := strconv.ParseUint(r.URL.Query().Get("page"), 10, 64)
-	if err != nil {
-		page = 0
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
 	}
 
-	pageSize, err := strconv.ParseUint(r.URL.Query().Get("per_page"), 10, 64)
-	if err != nil {
-		pageSize = 8
-	}
+	request.Page, request.PerPage = a.pageParams(r.URL.Query())
+	request.Order = r.URL.Query().Get("order")
 
-	request := &models.FindFilmRequest{
-		Title:           title,
-		RatingFrom:      float32(RatingFrom),
-		RatingTo:        float32(RatingTo),
-		ReleaseDateFrom: releaseDataFrom,
-		ReleaseDateTo:   releaseDataTo,
-		Actor:           actor,
-		Page:            page,
-		PerPage:         pageSize,
-		Order:           order,
+	request.Cursor, err = cursorParam(r.URL.Query())
+	if err != nil || request.Cursor != nil && !filmCursorValid(request.Cursor, request.Title) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
 	}
 
 	films, err := a.core.Films.GetFilms(r.Context(), request)
 	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
-	response.Body = &models.FilmsResponse{
-		Total: len(*films),
-		Films: films,
+	if userId, isAuth := r.Context().Value(middleware.UserIDKey).(uint64); isAuth {
+		err = a.core.Lists.MarkFilms(r.Context(), userId, *films.Films)
+		if err != nil {
+			response.Status = http.StatusInternalServerError
+			httpResponse.SendResponse(w, r, &response, a.log)
+			return
+		}
 	}
 
+	response.Body = films
+
 	httpResponse.SendResponse(w, r, &response, a.log)
 }
 
-// @Summary delete a film by ID
-// @Description deletes a film with the given ID
+// @Summary get film by ID
+// @Description get a single film together with its cast and crew credits
 // @Tags Film
-// @Accept json
 // @Produce json
-// @Param film_id query integer true "Film ID"
-// @Param session_id header string false "Session ID"
-// @Success 200 {object} models.Response
+// @Param id path integer true "Film ID"
+// @Success 200 {object} models.FilmResponse
 // @Failure 400 {object} models.Response
-// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
 // @Failure 405 {object} models.Response
 // @Failure 500 {object} models.Response
-// @Router /api/v1/films/delete [delete]
-func (a *Api) DeleteFilm(w http.ResponseWriter, r *http.Request) {
+// @Router /api/v1/films/{id} [get]
+func (a *Api) GetFilm(w http.ResponseWriter, r *http.Request) {
 	response := models.Response{Status: http.StatusOK, Body: nil}
 
-	if r.Method != http.MethodDelete {
+	if r.Method != http.MethodGet {
 		response.Status = http.StatusMethodNotAllowed
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
-	filmId, err := strconv.ParseUint(r.URL.Query().Get("film_id"), 10, 64)
+	filmId, _, err := utils.ParsePathId(r.URL.Path, "/api/v1/films/")
 	if err != nil {
-		response.Status = http.StatusBadRequest
+		response.Status = pathIdStatus(err)
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
-	_, err = a.core.Films.DeleteFilm(r.Context(), filmId)
+	film, found, err := a.core.Films.GetFilm(r.Context(), filmId)
 	if err != nil {
 		response.Status = http.StatusInternalServerError
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = film
+
 	httpResponse.SendResponse(w, r, &response, a.log)
 }
 
-// @Summary update film information
-// @Tags Film
-// @ID update-film
+// @Summary get film rating
+// @Description get the aggregate rating of a film and the vote of the current user
+// @Tags Rating
 // @Produce json
-// @Consume json
+// @Param id path integer true "Film ID"
 // @Param session_id header string false "Session ID"
-// @Param Film body models.FilmRequest true "Updated Film Information"
-// @Success 200 {object} models.Response
+// @Security BearerAuth
+// @Success 200 {object} models.FilmRatingResponse
 // @Failure 400 {object} models.Response
 // @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
 // @Failure 405 {object} models.Response
 // @Failure 500 {object} models.Response
-// @Router /api/v1/films/update [patch]
-func (a *Api) UpdateFilm(w http.ResponseWriter, r *http.Request) {
+// @Router /api/v1/films/{id}/rating [get]
+func (a *Api) GetFilmRating(w http.ResponseWriter, r *http.Request) {
 	response := models.Response{Status: http.StatusOK, Body: nil}
 
-	if r.Method != http.MethodPatch {
+	if r.Method != http.MethodGet {
 		response.Status = http.StatusMethodNotAllowed
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
-	var request models.FilmRequest
+	userId, isAuth := r.Context().Value(middleware.UserIDKey).(uint64)
+	if !isAuth {
+		response.Status = http.StatusUnauthorized
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
 
-	body, err := io.ReadAll(r.Body)
+	filmId, _, err := utils.ParsePathId(r.URL.Path, "/api/v1/films/")
 	if err != nil {
 		response.Status = http.StatusBadRequest
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
-	err = json.Unmarshal(body, &request)
+	rating, found, err := a.core.Ratings.GetRating(r.Context(), filmId, userId)
 	if err != nil {
 		response.Status = http.StatusInternalServerError
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
-	err = a.core.Films.UpdateFilm(r.Context(), &request)
-	if err != nil {
-		response.Status = http.StatusInternalServerError
+	if !found {
+		response.Status = http.StatusNotFound
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
+	response.Body = rating
+
 	httpResponse.SendResponse(w, r, &response, a.log)
 }
 
-// @Summary get list of actors with pagination
-// @Tags Actor
-// @ID find-actors
+// @Summary rate a film
+// @Description set or change the vote of the current user, from 1 to 10
+// @Tags Rating
+// @Accept json
 // @Produce json
-// @Param page query uint64 false "Page number, starting from 0 (optional)"
-// @Param per_size query uint64 false "Number of items per page, defaults to 8 (optional)"
-// @Success 200 {array} models.ActorItem
+// @Param id path integer true "Film ID"
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param input body models.RatingRequest true "User vote"
+// @Success 200 {object} models.FilmRatingResponse
 // @Failure 400 {object} models.Response
 // @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
 // @Failure 405 {object} models.Response
 // @Failure 500 {object} models.Response
-// @Router /api/v1/actors [get]
-func (a *Api) FindActors(w http.ResponseWriter, r *http.Request) {
+// @Router /api/v1/films/{id}/rating [post]
+func (a *Api) RateFilm(w http.ResponseWriter, r *http.Request) {
 	response := models.Response{Status: http.StatusOK, Body: nil}
 
-	if r.Method != http.MethodGet {
+	if r.Method != http.MethodPost {
 		response.Status = http.StatusMethodNotAllowed
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
-	page, err := strconv.ParseUint(r.URL.Query().Get("page"), 10, 64)
+	userId, isAuth := r.Context().Value(middleware.UserIDKey).(uint64)
+	if !isAuth {
+		response.Status = http.StatusUnauthorized
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	filmId, _, err := utils.ParsePathId(r.URL.Path, "/api/v1/films/")
 	if err != nil {
-		page = 0
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
 	}
 
-	perSize, err := strconv.ParseUint(r.URL.Query().Get("per_size"), 10, 64)
+	var request models.RatingRequest
+
+	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		perSize = 8
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	err = json.Unmarshal(body, &request)
+	if err != nil || request.Rating < utils.VoteBegin || request.Rating > utils.VoteEnd {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
 	}
 
-	actors, err := a.core.Actors.FindActors(r.Context(), page, perSize)
+	rating, found, err := a.core.Ratings.RateFilm(r.Context(), filmId, userId, request.Rating)
 	if err != nil {
 		response.Status = http.StatusInternalServerError
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
-	response.Body = actors
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = rating
 
 	httpResponse.SendResponse(w, r, &response, a.log)
 }
 
-// @Summary delete actor by ID
-// @Tags Actor
-// @ID delete-actor
+// @Summary withdraw film vote
+// @Description remove the vote of the current user from a film
+// @Tags Rating
 // @Produce json
-// @Param actor_id query uint64 true "Actor ID"
+// @Param id path integer true "Film ID"
 // @Param session_id header string false "Session ID"
-// @Success 200 {object} models.Response
+// @Security BearerAuth
+// @Success 200 {object} models.FilmRatingResponse
 // @Failure 400 {object} models.Response
 // @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
 // @Failure 405 {object} models.Response
 // @Failure 500 {object} models.Response
-// @Router /api/v1/actors/delete [delete]
-func (a *Api) DeleteActor(w http.ResponseWriter, r *http.Request) {
+// @Router /api/v1/films/{id}/rating [delete]
+func (a *Api) DeleteFilmRating(w http.ResponseWriter, r *http.Request) {
 	response := models.Response{Status: http.StatusOK, Body: nil}
 
 	if r.Method != http.MethodDelete {
@@ -568,64 +919,78 @@ func (a *Api) DeleteActor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	actorId, err := strconv.ParseUint(r.URL.Query().Get("actor_id"), 10, 64)
+	userId, isAuth := r.Context().Value(middleware.UserIDKey).(uint64)
+	if !isAuth {
+		response.Status = http.StatusUnauthorized
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	filmId, _, err := utils.ParsePathId(r.URL.Path, "/api/v1/films/")
 	if err != nil {
 		response.Status = http.StatusBadRequest
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
-	err = a.core.Actors.DeleteActor(r.Context(), actorId)
+	rating, found, err := a.core.Ratings.DeleteRating(r.Context(), filmId, userId)
 	if err != nil {
 		response.Status = http.StatusInternalServerError
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = rating
+
 	httpResponse.SendResponse(w, r, &response, a.log)
 }
 
-// @Summary update actor information
-// @Tags Actor
-// @ID update-actor
+// @Summary delete a film by ID
+// @Description moves the film with the given ID to the trash, it can be restored until the trash is purged
+// @Tags Film
+// @Accept json
 // @Produce json
-// @Consume json
+// @Param film_id query integer true "Film ID"
 // @Param session_id header string false "Session ID"
-// @Param Actor body models.ActorRequest true "Updated Actor Information"
+// @Security BearerAuth
 // @Success 200 {object} models.Response
 // @Failure 400 {object} models.Response
 // @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
 // @Failure 405 {object} models.Response
 // @Failure 500 {object} models.Response
-// @Router /api/v1/actors/update [patch]
-func (a *Api) UpdateActor(w http.ResponseWriter, r *http.Request) {
+// @Router /api/v1/films/delete [delete]
+func (a *Api) DeleteFilm(w http.ResponseWriter, r *http.Request) {
 	response := models.Response{Status: http.StatusOK, Body: nil}
 
-	if r.Method != http.MethodPatch {
+	if r.Method != http.MethodDelete {
 		response.Status = http.StatusMethodNotAllowed
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
-	var request models.ActorRequest
-
-	body, err := io.ReadAll(r.Body)
+	filmId, err := strconv.ParseUint(r.URL.Query().Get("film_id"), 10, 64)
 	if err != nil {
 		response.Status = http.StatusBadRequest
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
-	err = json.Unmarshal(body, &request)
+	deleted, err := a.core.Films.DeleteFilm(r.Context(), filmId)
 	if err != nil {
 		response.Status = http.StatusInternalServerError
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
-	err = a.core.Actors.UpdateActor(r.Context(), &request)
-	if err != nil {
-		response.Status = http.StatusInternalServerError
+	if !deleted {
+		response.Status = http.StatusNotFound
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
@@ -633,42 +998,389 @@ func (a *Api) UpdateActor(w http.ResponseWriter, r *http.Request) {
 	httpResponse.SendResponse(w, r, &response, a.log)
 }
 
-// @Summary end current user session
-// @Tags Auth
-// @ID logout
+// @Summary update film information
+// @Tags Film
+// @ID update-film
 // @Produce json
+// @Consume json
 // @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param Film body models.FilmRequest true "Updated Film Information"
 // @Success 200 {object} models.Response
 // @Failure 400 {object} models.Response
 // @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
 // @Failure 405 {object} models.Response
 // @Failure 500 {object} models.Response
-// @Router /logout [delete]
-func (a *Api) Logout(w http.ResponseWriter, r *http.Request) {
+// @Router /api/v1/films/update [patch]
+func (a *Api) UpdateFilm(w http.ResponseWriter, r *http.Request) {
 	response := models.Response{Status: http.StatusOK, Body: nil}
 
-	if r.Method != http.MethodDelete {
+	if r.Method != http.MethodPatch {
 		response.Status = http.StatusMethodNotAllowed
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
-	cookie, err := r.Cookie("session_id")
-	if err != nil {
+	var request models.FilmRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
 		response.Status = http.StatusBadRequest
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
-	err = a.core.Sessions.KillSession(r.Context(), cookie.Value)
+	err = json.Unmarshal(body, &request)
 	if err != nil {
 		response.Status = http.StatusInternalServerError
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
-	cookie.Expires = time.Now().AddDate(0, 0, -1)
-	http.SetCookie(w, cookie)
+	if !validFilm(&request, true) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	found, err := a.core.Films.UpdateFilm(r.Context(), &request)
+	if errors.Is(err, utils.ErrUnknownId) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// validFilm reports whether the title and description have an allowed length,
+// the credits have known roles and the ids fit the integer columns they are
+// stored in. An update may leave the title and description out.
+func validFilm(film *models.FilmRequest, update bool) bool {
+	title := utf8.RuneCountInString(film.Title)
+	if !(update && title == 0) && (title < utils.FilmTitleBegin || title > utils.FilmTitleEnd) {
+		return false
+	}
+
+	info := utf8.RuneCountInString(film.Info)
+	if !(update && info == 0) && (info < utils.FilmDescriptionBegin || info > utils.FilmDescriptionEnd) {
+		return false
+	}
+
+	ids := append(slices.Clone(film.Actors), film.Genres...)
+	for _, credit := range film.Credits {
+		if credit.Role != "" && !slices.Contains(utils.CreditRoles, credit.Role) {
+			return false
+		}
+		ids = append(ids, credit.ActorId)
+	}
+
+	for _, id := range ids {
+		if id > math.MaxInt32 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// @Summary find actors
+// @Description search actors by name fragment, gender, birthdate range and film, sorted by name, birthdate or number of films
+// @Tags Actor
+// @ID find-actors
+// @Produce json
+// @Param name query string false "Actor name fragment, case-insensitive (optional)"
+// @Param gen query string false "Gender (optional)"
+// @Param birthdate_from query string false "Born on or after (optional)" format="date"
+// @Param birthdate_to query string false "Born on or before (optional)" format="date"
+// @Param film_id query integer false "Only actors credited in this film (optional)"
+// @Param sort query string false "Sort field, name by default" Enums(name, birthdate, films)
+// @Param order query string false "Sort direction, asc by default" Enums(asc, desc)
+// @Param page query integer false "Page number, starting from 1 (optional)" minimum="1"
+// @Param per_page query integer false "Number of items per page, capped by API_MAX_PAGE_SIZE (optional)" minimum="1"
+// @Param per_size query integer false "Deprecated alias of per_page (optional)"
+// @Param cursor query string false "Opaque next or prev cursor from a previous response, replaces page (optional)"
+// @Success 200 {object} models.ActorsResponse
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/actors [get]
+func (a *Api) FindActors(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	query := r.URL.Query()
+	if !query.Has("per_page") {
+		query.Set("per_page", query.Get("per_size"))
+	}
+
+	page, perPage := a.pageParams(query)
+
+	cursor, err := cursorParam(query)
+	if err != nil || cursor != nil && !actorCursorValid(cursor) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	sort := query.Get("sort")
+	if sort == "" {
+		sort = utils.ActorSortName
+	}
+
+	order := query.Get("order")
+	if order == "" {
+		order = utils.OrderAsc
+	}
+
+	if !slices.Contains(utils.ActorSorts, sort) || (order != utils.OrderAsc && order != utils.OrderDesc) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	var filmId uint64
+	if query.Get("film_id") != "" {
+		filmId, err = strconv.ParseUint(query.Get("film_id"), 10, 64)
+		if err != nil {
+			response.Status = http.StatusBadRequest
+			httpResponse.SendResponse(w, r, &response, a.log)
+			return
+		}
+	}
+
+	request := &models.FindActorRequest{
+		Name:          query.Get("name"),
+		Gender:        query.Get("gen"),
+		BirthdateFrom: query.Get("birthdate_from"),
+		BirthdateTo:   query.Get("birthdate_to"),
+		FilmId:        filmId,
+		Sort:          sort,
+		Desc:          order == utils.OrderDesc,
+		Page:          page,
+		PerPage:       perPage,
+		Cursor:        cursor,
+	}
+
+	actors, err := a.core.Actors.FindActors(r.Context(), request)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = actors
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary get actor by ID
+// @Description get a single actor with age, number of films and filmography, newest films first
+// @Tags Actor
+// @ID get-actor
+// @Produce json
+// @Param id path integer true "Actor ID"
+// @Success 200 {object} models.ActorResponse
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/actors/{id} [get]
+func (a *Api) GetActor(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	actorId, _, err := utils.ParsePathId(r.URL.Path, "/api/v1/actors/")
+	if err != nil {
+		response.Status = pathIdStatus(err)
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	actor, found, err := a.core.Actors.GetActor(r.Context(), actorId)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = actor
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary delete actor by ID
+// @Description moves the actor to the trash, the credits are kept and come back on restore
+// @Tags Actor
+// @ID delete-actor
+// @Produce json
+// @Param actor_id query uint64 true "Actor ID"
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/actors/delete [delete]
+func (a *Api) DeleteActor(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodDelete {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	actorId, err := strconv.ParseUint(r.URL.Query().Get("actor_id"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	deleted, err := a.core.Actors.DeleteActor(r.Context(), actorId)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !deleted {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary update actor information
+// @Tags Actor
+// @ID update-actor
+// @Produce json
+// @Consume json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param Actor body models.ActorRequest true "Updated Actor Information"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/actors/update [patch]
+func (a *Api) UpdateActor(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPatch {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	var request models.ActorRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	err = json.Unmarshal(body, &request)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	found, err := a.core.Actors.UpdateActor(r.Context(), &request)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary end current user session
+// @Tags Auth
+// @ID logout
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /logout [delete]
+func (a *Api) Logout(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodDelete {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	sid := middleware.SessionId(r)
+	if sid == "" {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	err := a.core.Sessions.KillSession(r.Context(), sid)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session_id",
+		Path:     "/",
+		Expires:  time.Now().AddDate(0, 0, -1),
+		HttpOnly: true,
+	})
 
 	httpResponse.SendResponse(w, r, &response, a.log)
 }
@@ -694,27 +1406,2774 @@ func (a *Api) AuthAccept(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session, err := r.Cookie("session_id")
-	if err == nil && session != nil {
-		authorized, _ = a.core.Sessions.FindActiveSession(r.Context(), session.Value)
+	var active *models.Session
+	sid := middleware.SessionId(r)
+	if sid != "" {
+		var err error
+		active, authorized, err = a.core.Sessions.GetSession(r.Context(), sid)
+		if err != nil {
+			a.log.Error("auth accept error: ", err.Error())
+			response.Status = http.StatusInternalServerError
+			httpResponse.SendResponse(w, r, &response, a.log)
+			return
+		}
 	}
-	a.log.Warning("API", authorized)
+
 	if !authorized {
 		response.Status = http.StatusUnauthorized
 		httpResponse.SendResponse(w, r, &response, a.log)
 		return
 	}
 
-	login, err := a.core.Sessions.GetUserName(r.Context(), session.Value)
-	if err != nil {
-		a.log.Error("auth accept error: ", err.Error())
-		response.Status = http.StatusInternalServerError
-		httpResponse.SendResponse(w, r, &response, a.log)
-		return
+	response.Body = models.AuthCheckResponse{
+		Login: active.Login,
+		Role:  active.Role,
 	}
 
-	response.Body = models.AuthCheckResponse{
-		Login: login,
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary get list of genres
+// @Tags Genre
+// @ID find-genres
+// @Produce json
+// @Success 200 {array} models.GenreItem
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/genres [get]
+func (a *Api) FindGenres(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	genres, err := a.core.Genres.FindGenres(r.Context())
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = genres
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary add a new genre
+// @Tags Genre
+// @ID add-genre
+// @Accept json
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param input body models.GenreItem true "Genre details"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/genres/add [post]
+func (a *Api) AddGenre(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPost {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	var request models.GenreItem
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	err = json.Unmarshal(body, &request)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !validGenre(&request) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	found, err := a.core.Genres.FindGenreByName(r.Context(), request.Name)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if found {
+		response.Status = http.StatusConflict
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	_, err = a.core.Genres.AddGenre(r.Context(), &request)
+	if errors.Is(err, utils.ErrGenreExists) {
+		response.Status = http.StatusConflict
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = request
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary update genre name
+// @Tags Genre
+// @ID update-genre
+// @Accept json
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param Genre body models.GenreItem true "Updated Genre Information"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/genres/update [patch]
+func (a *Api) UpdateGenre(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPatch {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	var request models.GenreItem
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	err = json.Unmarshal(body, &request)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !validGenre(&request) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	found, err := a.core.Genres.UpdateGenre(r.Context(), &request)
+	if errors.Is(err, utils.ErrGenreExists) {
+		response.Status = http.StatusConflict
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// validGenre reports whether the genre has a name of an allowed length.
+func validGenre(genre *models.GenreItem) bool {
+	length := utf8.RuneCountInString(genre.Name)
+	return length >= utils.GenreNameBegin && length <= utils.GenreNameEnd
+}
+
+// @Summary delete genre by ID
+// @Tags Genre
+// @ID delete-genre
+// @Produce json
+// @Param genre_id query uint64 true "Genre ID"
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/genres/delete [delete]
+func (a *Api) DeleteGenre(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodDelete {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	genreId, err := strconv.ParseUint(r.URL.Query().Get("genre_id"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	deleted, err := a.core.Genres.DeleteGenre(r.Context(), genreId)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !deleted {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary get film reviews with pagination
+// @Tags Review
+// @ID find-reviews
+// @Produce json
+// @Param id path integer true "Film ID"
+// @Param page query integer false "Page number, starting from 1 (optional)" minimum="1"
+// @Param per_page query integer false "Number of items per page, capped by API_MAX_PAGE_SIZE (optional)" minimum="1"
+// @Success 200 {object} models.ReviewsResponse
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/films/{id}/reviews [get]
+func (a *Api) FindReviews(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	filmId, _, err := utils.ParsePathId(r.URL.Path, "/api/v1/films/")
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	page, pageSize := a.pageParams(r.URL.Query())
+
+	reviews, found, err := a.core.Reviews.FindReviews(r.Context(), filmId, page, pageSize)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = reviews
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary add a film review
+// @Tags Review
+// @ID add-review
+// @Accept json
+// @Produce json
+// @Param id path integer true "Film ID"
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param input body models.ReviewRequest true "Review title, body and spoiler flag"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/films/{id}/reviews [post]
+func (a *Api) AddReview(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPost {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	userId, isAuth := r.Context().Value(middleware.UserIDKey).(uint64)
+	if !isAuth {
+		response.Status = http.StatusUnauthorized
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	filmId, _, err := utils.ParsePathId(r.URL.Path, "/api/v1/films/")
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	var request models.ReviewRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	err = json.Unmarshal(body, &request)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !validReview(&request) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	_, found, err := a.core.Reviews.AddReview(r.Context(), filmId, userId, &request)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = request
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary edit own review
+// @Tags Review
+// @ID update-review
+// @Accept json
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param Review body models.ReviewRequest true "Updated Review"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/reviews/update [patch]
+func (a *Api) UpdateReview(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPatch {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	userId, isAuth := r.Context().Value(middleware.UserIDKey).(uint64)
+	if !isAuth {
+		response.Status = http.StatusUnauthorized
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	var request models.ReviewRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	err = json.Unmarshal(body, &request)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !validReview(&request) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	updated, err := a.core.Reviews.UpdateReview(r.Context(), userId, &request)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !updated {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// validReview reports whether the title and body of the review have an
+// allowed length.
+func validReview(review *models.ReviewRequest) bool {
+	title := utf8.RuneCountInString(review.Title)
+	body := utf8.RuneCountInString(review.Body)
+
+	return title >= utils.ReviewTitleBegin && title <= utils.ReviewTitleEnd &&
+		body >= utils.ReviewBodyBegin && body <= utils.ReviewBodyEnd
+}
+
+// @Summary delete own review
+// @Tags Review
+// @ID delete-review
+// @Produce json
+// @Param review_id query uint64 true "Review ID"
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/reviews/delete [delete]
+func (a *Api) DeleteReview(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodDelete {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	userId, isAuth := r.Context().Value(middleware.UserIDKey).(uint64)
+	if !isAuth {
+		response.Status = http.StatusUnauthorized
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	reviewId, err := strconv.ParseUint(r.URL.Query().Get("review_id"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	deleted, err := a.core.Reviews.DeleteReview(r.Context(), userId, reviewId)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !deleted {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary hide or show a review
+// @Tags Review
+// @ID hide-review
+// @Accept json
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param input body models.HideReviewRequest true "Review ID and visibility"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/reviews/hide [patch]
+func (a *Api) HideReview(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPatch {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	var request models.HideReviewRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	err = json.Unmarshal(body, &request)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	updated, err := a.core.Reviews.HideReview(r.Context(), request.Id, request.Hidden)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !updated {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary get films from own favorites or watchlist
+// @Tags List
+// @ID find-list
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param list query string true "List name" Enums(favorite, watchlist)
+// @Param page query integer false "Page number, starting from 1 (optional)" minimum="1"
+// @Param per_page query integer false "Number of items per page, capped by API_MAX_PAGE_SIZE (optional)" minimum="1"
+// @Success 200 {object} models.FilmsResponse
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/me/lists [get]
+func (a *Api) FindList(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	userId, isAuth := r.Context().Value(middleware.UserIDKey).(uint64)
+	if !isAuth {
+		response.Status = http.StatusUnauthorized
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	list := r.URL.Query().Get("list")
+	if !slices.Contains(utils.FilmLists, list) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	page, pageSize := a.pageParams(r.URL.Query())
+
+	films, err := a.core.Lists.FindList(r.Context(), userId, list, page, pageSize)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = films
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary add a film to own favorites or watchlist
+// @Tags List
+// @ID add-to-list
+// @Accept json
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param input body models.FilmListRequest true "Film ID and list name (favorite or watchlist)"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/me/lists/add [post]
+func (a *Api) AddToList(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPost {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	userId, isAuth := r.Context().Value(middleware.UserIDKey).(uint64)
+	if !isAuth {
+		response.Status = http.StatusUnauthorized
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	var request models.FilmListRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	err = json.Unmarshal(body, &request)
+	if err != nil || !slices.Contains(utils.FilmLists, request.List) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	found, err := a.core.Lists.AddToList(r.Context(), userId, request.FilmId, request.List)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary remove a film from own favorites or watchlist
+// @Tags List
+// @ID delete-from-list
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param film_id query uint64 true "Film ID"
+// @Param list query string true "List name" Enums(favorite, watchlist)
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/me/lists/delete [delete]
+func (a *Api) DeleteFromList(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodDelete {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	userId, isAuth := r.Context().Value(middleware.UserIDKey).(uint64)
+	if !isAuth {
+		response.Status = http.StatusUnauthorized
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	filmId, err := strconv.ParseUint(r.URL.Query().Get("film_id"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	list := r.URL.Query().Get("list")
+	if !slices.Contains(utils.FilmLists, list) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	deleted, err := a.core.Lists.DeleteFromList(r.Context(), userId, filmId, list)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !deleted {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary import films, actors and their links
+// @Description import a CSV, JSON or NDJSON catalog file in one transaction. Films are matched by title and release date, actors by name and birthday. The file is sent as the request body or as the file field of a multipart form.
+// @Tags Catalog
+// @ID import-catalog
+// @Accept text/csv,application/json,application/x-ndjson,multipart/form-data
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param format query string false "File format, taken from the content type when omitted" Enums(csv, json, ndjson)
+// @Param dry_run query boolean false "Report what would be imported without saving anything"
+// @Param file formData file false "Catalog file for multipart requests"
+// @Success 200 {object} models.ImportReport
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 413 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/catalog/import [post]
+func (a *Api) ImportCatalog(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPost {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, utils.CatalogImportMaxSize)
+
+	format := r.URL.Query().Get("format")
+	var file io.Reader = r.Body
+
+	var maxBytesError *http.MaxBytesError
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		formFile, header, err := r.FormFile("file")
+		if err != nil {
+			response.Status = http.StatusBadRequest
+			if errors.As(err, &maxBytesError) {
+				response.Status = http.StatusRequestEntityTooLarge
+			}
+			httpResponse.SendResponse(w, r, &response, a.log)
+			return
+		}
+		defer formFile.Close()
+
+		file = formFile
+		if format == "" {
+			format = strings.TrimPrefix(path.Ext(header.Filename), ".")
+		}
+	} else if format == "" {
+		format = catalog.FormatFromContentType(r.Header.Get("Content-Type"))
+	}
+
+	if !slices.Contains(catalog.Formats, format) {
+		a.log.Error(utils.CatalogFormatError)
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	body, err := io.ReadAll(file)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		if errors.As(err, &maxBytesError) {
+			response.Status = http.StatusRequestEntityTooLarge
+		}
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	records, err := catalog.Decode(format, bytes.NewReader(body))
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		response.Body = err.Error()
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	report, err := a.core.Catalog.ImportCatalog(r.Context(), records, dryRun)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if len(report.Errors) > 0 {
+		response.Status = http.StatusBadRequest
+	}
+	response.Body = report
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary export films, actors and their links
+// @Description stream the catalog as CSV, JSON or NDJSON in the layout accepted by the import. With film filters only the matching films, the actors credited in them and their links are exported.
+// @Tags Catalog
+// @ID export-catalog
+// @Produce text/csv,application/json,application/x-ndjson
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param format query string false "File format, json by default" Enums(csv, json, ndjson)
+// @Param title query string false "Full-text search over title, description and cast names"
+// @Param actor query string false "Actor name"
+// @Param director query string false "Director name"
+// @Param release_date_from query string false "Release date from" format="date"
+// @Param release_date_to query string false "Release date to" format="date"
+// @Param rating_from query number false "Minimum rating"
+// @Param rating_to query number false "Maximum rating"
+// @Param genres query string false "Comma separated genre IDs"
+// @Success 200 {file} file
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/catalog/export [get]
+func (a *Api) ExportCatalog(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = catalog.FormatJSON
+	}
+
+	if !slices.Contains(catalog.Formats, format) {
+		a.log.Error(utils.CatalogFormatError)
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	var request *models.FindFilmRequest
+	for _, param := range filmFilterParams {
+		if r.URL.Query().Has(param) {
+			var err error
+			request, err = filmFilters(r.URL.Query())
+			if err != nil {
+				response.Status = http.StatusBadRequest
+				httpResponse.SendResponse(w, r, &response, a.log)
+				return
+			}
+			break
+		}
+	}
+
+	file := &exportWriter{w: w, format: format}
+
+	err := a.core.Catalog.ExportCatalog(r.Context(), request, format, file)
+	if err != nil && !file.started {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+	if err != nil {
+		a.log.Error("export catalog stopped: ", err)
+	}
+}
+
+// exportWriter sets the file headers of an export on its first write, so that
+// an export failing before any output still gets an error response.
+type exportWriter struct {
+	w       http.ResponseWriter
+	format  string
+	started bool
+}
+
+func (e *exportWriter) Write(p []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.w.Header().Set("Content-Type", catalog.ContentType(e.format))
+		e.w.Header().Set("Content-Disposition", "attachment; filename=\"filmoteka-"+time.Now().Format("2006-01-02")+"."+e.format+"\"")
+	}
+
+	return e.w.Write(p)
+}
+
+// @Summary list deleted films
+// @Description films in the trash, most recently deleted first
+// @Tags Film
+// @ID find-deleted-films
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param page query integer false "Page number, starting from 1 (optional)" minimum="1"
+// @Param per_page query integer false "Number of items per page, capped by API_MAX_PAGE_SIZE (optional)" minimum="1"
+// @Success 200 {object} models.FilmsResponse
+// @Failure 401 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/films/trash [get]
+func (a *Api) FindDeletedFilms(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	page, pageSize := a.pageParams(r.URL.Query())
+
+	films, err := a.core.Trash.FindDeletedFilms(r.Context(), page, pageSize)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = films
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary restore a deleted film
+// @Description takes the film out of the trash with its credits, genres, ratings, reviews and lists
+// @Tags Film
+// @ID restore-film
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param film_id query integer true "Film ID"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/films/restore [post]
+func (a *Api) RestoreFilm(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPost {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	filmId, err := strconv.ParseUint(r.URL.Query().Get("film_id"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	restored, err := a.core.Trash.RestoreFilm(r.Context(), filmId)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !restored {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary list deleted actors
+// @Description actors in the trash, most recently deleted first
+// @Tags Actor
+// @ID find-deleted-actors
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param page query integer false "Page number, starting from 1 (optional)" minimum="1"
+// @Param per_page query integer false "Number of items per page, capped by API_MAX_PAGE_SIZE (optional)" minimum="1"
+// @Success 200 {object} models.ActorsResponse
+// @Failure 401 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/actors/trash [get]
+func (a *Api) FindDeletedActors(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	page, pageSize := a.pageParams(r.URL.Query())
+
+	actors, err := a.core.Trash.FindDeletedActors(r.Context(), page, pageSize)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = actors
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary restore a deleted actor
+// @Description takes the actor out of the trash with their credits
+// @Tags Actor
+// @ID restore-actor
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param actor_id query integer true "Actor ID"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/actors/restore [post]
+func (a *Api) RestoreActor(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPost {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	actorId, err := strconv.ParseUint(r.URL.Query().Get("actor_id"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	restored, err := a.core.Trash.RestoreActor(r.Context(), actorId)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !restored {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary find audit log entries
+// @Description who added, changed or deleted films, actors and profiles, newest first
+// @Tags Audit
+// @ID find-audit
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param user_id query integer false "Author of the change (optional)"
+// @Param action query string false "Action (optional)" Enums(add, update, delete, restore, import, rollback, purge)
+// @Param entity query string false "Entity (optional)" Enums(film, actor, profile, catalog, franchise, film_relation, access_token, genre)
+// @Param entity_id query integer false "Entity ID (optional)"
+// @Param from query string false "Changed at or after, RFC 3339 timestamp or date (optional)"
+// @Param to query string false "Changed before, RFC 3339 timestamp or date (optional)"
+// @Param page query integer false "Page number, starting from 1 (optional)" minimum="1"
+// @Param per_page query integer false "Number of items per page, capped by API_MAX_PAGE_SIZE (optional)" minimum="1"
+// @Success 200 {object} models.AuditResponse
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/audit [get]
+func (a *Api) FindAudit(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	query := r.URL.Query()
+	request := &models.FindAuditRequest{Action: query.Get("action"), Entity: query.Get("entity")}
+
+	if request.Action != "" && !slices.Contains(utils.AuditActions, request.Action) ||
+		request.Entity != "" && !slices.Contains(utils.AuditEntities, request.Entity) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	var err error
+	if query.Get("user_id") != "" {
+		request.UserId, err = strconv.ParseUint(query.Get("user_id"), 10, 64)
+		if err != nil {
+			response.Status = http.StatusBadRequest
+			httpResponse.SendResponse(w, r, &response, a.log)
+			return
+		}
+	}
+
+	if query.Get("entity_id") != "" {
+		request.EntityId, err = strconv.ParseUint(query.Get("entity_id"), 10, 64)
+		if err != nil {
+			response.Status = http.StatusBadRequest
+			httpResponse.SendResponse(w, r, &response, a.log)
+			return
+		}
+	}
+
+	request.From, err = timeParam(query, "from")
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	request.To, err = timeParam(query, "to")
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	request.Page, request.PerPage = a.pageParams(query)
+
+	entries, err := a.core.Audit.FindAudit(r.Context(), request)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = entries
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary get film revisions
+// @Description saved states of the film with genres and credits, newest first, each with the fields changed since the revision before it
+// @Tags Film
+// @ID find-film-revisions
+// @Produce json
+// @Param id path integer true "Film ID"
+// @Param page query integer false "Page number, starting from 1 (optional)" minimum="1"
+// @Param per_page query integer false "Number of items per page, capped by API_MAX_PAGE_SIZE (optional)" minimum="1"
+// @Success 200 {object} models.RevisionsResponse
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/films/{id}/revisions [get]
+func (a *Api) FindFilmRevisions(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	filmId, _, err := utils.ParsePathId(r.URL.Path, "/api/v1/films/")
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	page, pageSize := a.pageParams(r.URL.Query())
+
+	revisions, found, err := a.core.Revisions.FindFilmRevisions(r.Context(), filmId, page, pageSize)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = revisions
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary roll a film back to a revision
+// @Description restores the film fields, genres and credits saved in the revision, the replaced state is kept as a revision too
+// @Tags Film
+// @ID rollback-film
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param film_id query integer true "Film ID"
+// @Param revision query integer true "Revision number"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/films/rollback [post]
+func (a *Api) RollbackFilm(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPost {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	filmId, err := strconv.ParseUint(r.URL.Query().Get("film_id"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	revision, err := strconv.ParseUint(r.URL.Query().Get("revision"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	found, err := a.core.Revisions.RollbackFilm(r.Context(), filmId, revision)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary get actor revisions
+// @Description saved states of the actor with their credits, newest first, each with the fields changed since the revision before it
+// @Tags Actor
+// @ID find-actor-revisions
+// @Produce json
+// @Param id path integer true "Actor ID"
+// @Param page query integer false "Page number, starting from 1 (optional)" minimum="1"
+// @Param per_page query integer false "Number of items per page, capped by API_MAX_PAGE_SIZE (optional)" minimum="1"
+// @Success 200 {object} models.RevisionsResponse
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/actors/{id}/revisions [get]
+func (a *Api) FindActorRevisions(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	actorId, _, err := utils.ParsePathId(r.URL.Path, "/api/v1/actors/")
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	page, pageSize := a.pageParams(r.URL.Query())
+
+	revisions, found, err := a.core.Revisions.FindActorRevisions(r.Context(), actorId, page, pageSize)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = revisions
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary roll a actor back to a revision
+// @Description restores the actor fields and credits saved in the revision, the replaced state is kept as a revision too
+// @Tags Actor
+// @ID rollback-actor
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param actor_id query integer true "Actor ID"
+// @Param revision query integer true "Revision number"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/actors/rollback [post]
+func (a *Api) RollbackActor(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPost {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	actorId, err := strconv.ParseUint(r.URL.Query().Get("actor_id"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	revision, err := strconv.ParseUint(r.URL.Query().Get("revision"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	found, err := a.core.Revisions.RollbackActor(r.Context(), actorId, revision)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// readImage reads the image field of a multipart upload and checks it with
+// images.Decode. On failure it returns the status to respond with.
+func readImage(w http.ResponseWriter, r *http.Request) (*images.Upload, int) {
+	r.Body = http.MaxBytesReader(w, r.Body, utils.ImageUploadMaxSize)
+
+	var maxBytesError *http.MaxBytesError
+
+	formFile, header, err := r.FormFile("image")
+	if err != nil {
+		if errors.As(err, &maxBytesError) {
+			return nil, http.StatusRequestEntityTooLarge
+		}
+		return nil, http.StatusBadRequest
+	}
+	defer formFile.Close()
+
+	data, err := io.ReadAll(formFile)
+	if err != nil {
+		if errors.As(err, &maxBytesError) {
+			return nil, http.StatusRequestEntityTooLarge
+		}
+		return nil, http.StatusBadRequest
+	}
+
+	upload, err := images.Decode(data, header.Header.Get("Content-Type"))
+	if errors.Is(err, images.ErrContentType) {
+		return nil, http.StatusUnsupportedMediaType
+	}
+	if err != nil {
+		return nil, http.StatusBadRequest
+	}
+
+	return upload, http.StatusOK
+}
+
+// @Summary upload a film poster
+// @Description upload a JPEG, PNG or WebP poster of up to 10 MB and 10000 pixels a side, replacing the current one. Small, medium and large JPEG thumbnails are made from it.
+// @Tags Film
+// @ID upload-film-poster
+// @Accept multipart/form-data
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param film_id query integer true "Film ID"
+// @Param image formData file true "Poster image"
+// @Success 200 {object} models.ImageUrls
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 413 {object} models.Response
+// @Failure 415 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/films/poster [post]
+func (a *Api) UploadFilmPoster(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPost {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	filmId, err := strconv.ParseUint(r.URL.Query().Get("film_id"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	upload, status := readImage(w, r)
+	if upload == nil {
+		response.Status = status
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	urls, found, err := a.core.Images.UploadFilmPoster(r.Context(), filmId, upload)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = urls
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary upload an actor photo
+// @Description upload a JPEG, PNG or WebP photo of up to 10 MB and 10000 pixels a side, replacing the current one. Small, medium and large JPEG thumbnails are made from it.
+// @Tags Actor
+// @ID upload-actor-photo
+// @Accept multipart/form-data
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param actor_id query integer true "Actor ID"
+// @Param image formData file true "Photo image"
+// @Success 200 {object} models.ImageUrls
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 413 {object} models.Response
+// @Failure 415 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/actors/photo [post]
+func (a *Api) UploadActorPhoto(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPost {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	actorId, err := strconv.ParseUint(r.URL.Query().Get("actor_id"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	upload, status := readImage(w, r)
+	if upload == nil {
+		response.Status = status
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	urls, found, err := a.core.Images.UploadActorPhoto(r.Context(), actorId, upload)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = urls
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary get an image
+// @Description a poster, a photo or one of their thumbnails by the path from the poster or photo URLs. Images never change under the same path, so they may be cached for good.
+// @Tags Image
+// @ID get-image
+// @Produce image/jpeg,image/png,image/webp
+// @Param key path string true "Image path, e.g. films/{name}/small.jpg"
+// @Success 200 {file} file
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/images/{key} [get]
+func (a *Api) GetImage(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, images.UrlPrefix)
+
+	file, found, err := a.core.Images.OpenImage(r.Context(), key)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+	defer file.Close()
+
+	// every upload gets a new path, so the content behind a path never changes
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", "\""+key+"\"")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(w, r, path.Base(key), file.ModTime, file)
+}
+
+// @Summary list franchises
+// @Description franchises and collections by name with the number of their films
+// @Tags Franchise
+// @ID find-franchises
+// @Produce json
+// @Param page query integer false "Page number, starting from 1 (optional)" minimum="1"
+// @Param per_page query integer false "Number of items per page, capped by API_MAX_PAGE_SIZE (optional)" minimum="1"
+// @Success 200 {object} models.FranchisesResponse
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/franchises [get]
+func (a *Api) FindFranchises(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	page, perPage := a.pageParams(r.URL.Query())
+
+	franchises, err := a.core.Franchises.FindFranchises(r.Context(), page, perPage)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = franchises
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary get franchise
+// @Description the franchise with its films in story order, where films at the same position follow each other by release, or in release order
+// @Tags Franchise
+// @ID get-franchise
+// @Produce json
+// @Param id path integer true "Franchise ID"
+// @Param order query string false "Order of the films, chronology by default" Enums(chronology, release)
+// @Success 200 {object} models.FranchiseResponse
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/franchises/{id} [get]
+func (a *Api) GetFranchise(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	franchiseId, _, err := utils.ParsePathId(r.URL.Path, "/api/v1/franchises/")
+	if err != nil {
+		response.Status = pathIdStatus(err)
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	order := r.URL.Query().Get("order")
+	if order == "" {
+		order = utils.FranchiseOrderChronology
+	}
+
+	if !slices.Contains(utils.FranchiseOrders, order) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	franchise, found, err := a.core.Franchises.GetFranchise(r.Context(), franchiseId, order)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = franchise
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary add a new franchise
+// @Description films are listed with their position in the story, unknown films are skipped
+// @Tags Franchise
+// @ID add-franchise
+// @Accept json
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param input body models.FranchiseRequest true "Franchise details"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/franchises/add [post]
+func (a *Api) AddFranchise(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPost {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	var request models.FranchiseRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	err = json.Unmarshal(body, &request)
+	if err != nil || !validFranchise(&request) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	found, err := a.core.Franchises.FindFranchiseByName(r.Context(), request.Name)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if found {
+		response.Status = http.StatusConflict
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	_, err = a.core.Franchises.AddFranchise(r.Context(), &request)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = request
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary update franchise
+// @Description changes the name and the description, the films are replaced when they are given
+// @Tags Franchise
+// @ID update-franchise
+// @Accept json
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param input body models.FranchiseRequest true "Updated franchise information"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/franchises/update [patch]
+func (a *Api) UpdateFranchise(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPatch {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	var request models.FranchiseRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	err = json.Unmarshal(body, &request)
+	if err != nil || !validFranchise(&request) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	found, err := a.core.Franchises.UpdateFranchise(r.Context(), &request)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// validFranchise reports whether the franchise has a name of an allowed
+// length and its films fit the integer columns they are stored in.
+func validFranchise(franchise *models.FranchiseRequest) bool {
+	length := utf8.RuneCountInString(franchise.Name)
+	if length < utils.FranchiseNameBegin || length > utils.FranchiseNameEnd {
+		return false
+	}
+
+	for _, film := range franchise.Films {
+		if film.FilmId > math.MaxInt32 || film.Position < math.MinInt32 || film.Position > math.MaxInt32 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// @Summary delete franchise by ID
+// @Description the films of the franchise stay
+// @Tags Franchise
+// @ID delete-franchise
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param franchise_id query integer true "Franchise ID"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/franchises/delete [delete]
+func (a *Api) DeleteFranchise(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodDelete {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	franchiseId, err := strconv.ParseUint(r.URL.Query().Get("franchise_id"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	deleted, err := a.core.Franchises.DeleteFranchise(r.Context(), franchiseId)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !deleted {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary add film relation
+// @Description records that the related film is a sequel, prequel, remake or spin-off of the film, the film details show it from both sides
+// @Tags Film
+// @ID add-film-relation
+// @Accept json
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param input body models.FilmRelationRequest true "Relation"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/films/relations/add [post]
+func (a *Api) AddFilmRelation(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPost {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	var request models.FilmRelationRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	err = json.Unmarshal(body, &request)
+	if err != nil || !validRelation(&request) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	found, err := a.core.Franchises.AddFilmRelation(r.Context(), &request)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = request
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary delete film relation
+// @Tags Film
+// @ID delete-film-relation
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param film_id query integer true "Film ID"
+// @Param related_id query integer true "Related film ID"
+// @Param kind query string true "What the related film is to the film" Enums(sequel, prequel, remake, spin_off)
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/films/relations/delete [delete]
+func (a *Api) DeleteFilmRelation(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodDelete {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	query := r.URL.Query()
+
+	filmId, err := strconv.ParseUint(query.Get("film_id"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	relatedId, err := strconv.ParseUint(query.Get("related_id"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	request := models.FilmRelationRequest{FilmId: filmId, RelatedId: relatedId, Kind: query.Get("kind")}
+	if !validRelation(&request) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	deleted, err := a.core.Franchises.DeleteFilmRelation(r.Context(), &request)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !deleted {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// validRelation reports whether the relation links two different films with
+// a known kind.
+func validRelation(relation *models.FilmRelationRequest) bool {
+	return slices.Contains(utils.FilmRelationKinds, relation.Kind) && relation.FilmId != relation.RelatedId
+}
+
+// @Summary get similar films
+// @Description films ranked by shared cast and crew, then shared genres, then closeness of release date and rating
+// @Tags Film
+// @ID find-similar-films
+// @Produce json
+// @Param id path integer true "Film ID"
+// @Param limit query integer false "Number of films, 10 by default, capped by API_MAX_PAGE_SIZE (optional)" minimum="1"
+// @Success 200 {array} models.SimilarFilmItem
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/films/{id}/similar [get]
+func (a *Api) FindSimilarFilms(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	filmId, _, err := utils.ParsePathId(r.URL.Path, "/api/v1/films/")
+	if err != nil {
+		response.Status = pathIdStatus(err)
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	limit, err := a.limitParam(r.URL.Query())
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	films, found, err := a.core.Similar.FindSimilarFilms(r.Context(), filmId, limit)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = films
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary get recommended films
+// @Description films predicted from the ratings of the current user with item-item collaborative filtering, topped up with popular films the user has not rated
+// @Tags Film
+// @ID find-recommendations
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param limit query integer false "Number of films, 10 by default, capped by API_MAX_PAGE_SIZE (optional)" minimum="1"
+// @Success 200 {array} models.RecommendedFilmItem
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/me/recommendations [get]
+func (a *Api) FindRecommendations(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	userId, isAuth := r.Context().Value(middleware.UserIDKey).(uint64)
+	if !isAuth {
+		response.Status = http.StatusUnauthorized
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	limit, err := a.limitParam(r.URL.Query())
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	films, err := a.core.Recommendations.FindRecommendations(r.Context(), userId, limit)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = films
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary get catalog statistics
+// @Description films per release year and rating, actors by average rating of their films and by number of films, actors without films, films without cast and growth of the catalog over time. Films and actors in the trash are left out.
+// @Tags Stats
+// @ID get-stats
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param from query string false "Films and actors added at or after, RFC 3339 timestamp or date (optional)"
+// @Param to query string false "Films and actors added before, RFC 3339 timestamp or date (optional)"
+// @Param release_from query string false "Films released on or after (optional)" format="date"
+// @Param release_to query string false "Films released before (optional)" format="date"
+// @Param interval query string false "Growth period, month by default" Enums(day, week, month, year)
+// @Param limit query integer false "Number of actors and films in lists, 10 by default, capped by API_MAX_PAGE_SIZE (optional)" minimum="1"
+// @Success 200 {object} models.StatsResponse
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/stats [get]
+func (a *Api) GetStats(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	query := r.URL.Query()
+	request := &models.StatsRequest{Interval: query.Get("interval")}
+	if request.Interval == "" {
+		request.Interval = utils.StatsIntervalMonth
+	}
+
+	if !slices.Contains(utils.StatsIntervals, request.Interval) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	var err error
+	for name, bound := range map[string]**time.Time{
+		"from":         &request.From,
+		"to":           &request.To,
+		"release_from": &request.ReleaseFrom,
+		"release_to":   &request.ReleaseTo,
+	} {
+		*bound, err = timeParam(query, name)
+		if err != nil {
+			response.Status = http.StatusBadRequest
+			httpResponse.SendResponse(w, r, &response, a.log)
+			return
+		}
+	}
+
+	request.Limit, err = a.limitParam(query)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	stats, err := a.core.Stats.GetStats(r.Context(), request)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = stats
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary get co-star path between actors
+// @Description a shortest chain of actors from one actor to the other where each shares a film with the next, "degrees of separation"
+// @Tags Actor
+// @ID find-actor-path
+// @Produce json
+// @Param from query integer true "Actor ID the path starts from"
+// @Param to query integer true "Actor ID the path leads to"
+// @Success 200 {object} models.ActorPathResponse
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/actors/path [get]
+func (a *Api) FindActorPath(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	fromId, err := strconv.ParseUint(r.URL.Query().Get("from"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	toId, err := strconv.ParseUint(r.URL.Query().Get("to"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	path, found, err := a.core.Graph.FindActorPath(r.Context(), fromId, toId)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = path
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary get actor collaborators
+// @Description actors who played with the actor, those sharing the most films first
+// @Tags Actor
+// @ID find-collaborators
+// @Produce json
+// @Param id path integer true "Actor ID"
+// @Param limit query integer false "Number of actors, 10 by default, capped by API_MAX_PAGE_SIZE (optional)" minimum="1"
+// @Success 200 {array} models.CollaboratorItem
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/actors/{id}/collaborators [get]
+func (a *Api) FindCollaborators(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	actorId, _, err := utils.ParsePathId(r.URL.Path, "/api/v1/actors/")
+	if err != nil {
+		response.Status = pathIdStatus(err)
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	limit, err := a.limitParam(r.URL.Query())
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	collaborators, found, err := a.core.Graph.FindCollaborators(r.Context(), actorId, limit)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = collaborators
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary change role of user
+// @Description signs the user out of all sessions, the new role applies from the next signin. An admin changing their own role gets a new session cookie instead. The last admin cannot be demoted (409).
+// @Tags Auth
+// @ID update-role
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param user_id query integer true "User ID"
+// @Param role query string true "Role" Enums(user, admin)
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/profiles/role [patch]
+func (a *Api) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPatch {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	userId, err := strconv.ParseUint(r.URL.Query().Get("user_id"), 10, 64)
+	role := r.URL.Query().Get("role")
+	if err != nil || !slices.Contains(utils.Roles, role) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	found, err := a.core.Profiles.UpdateRole(r.Context(), userId, role)
+	if errors.Is(err, utils.ErrLastAdmin) {
+		response.Status = http.StatusConflict
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !found {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	// An admin changing their own role gets a new session with the new role
+	// in place of the one that was voided.
+	active, isSession := r.Context().Value(utils.SessionKey).(*models.Session)
+	if isSession && active.UserId == userId {
+		err = a.startSession(w, r, &models.UserItem{Id: active.UserId, Login: active.Login})
+		if err != nil {
+			a.log.Error("update role error: ", err.Error())
+			response.Status = http.StatusInternalServerError
+			httpResponse.SendResponse(w, r, &response, a.log)
+			return
+		}
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary get sessions of current user
+// @Description the signed in devices of the user with their IP at signin and last activity, the most recent first
+// @Tags Auth
+// @ID find-my-sessions
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Success 200 {array} models.SessionItem
+// @Failure 401 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/me/sessions [get]
+func (a *Api) FindMySessions(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	userId, isAuth := r.Context().Value(middleware.UserIDKey).(uint64)
+	if !isAuth {
+		response.Status = http.StatusUnauthorized
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	sessions, err := a.core.Sessions.FindUserSessions(r.Context(), userId, currentSessionId(r))
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = sessions
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary end session of current user
+// @Description the id is the one from the list of sessions, ending the current session signs out
+// @Tags Auth
+// @ID delete-my-session
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param id query string true "Session ID from the list of sessions"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/me/sessions/delete [delete]
+func (a *Api) DeleteMySession(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodDelete {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	userId, isAuth := r.Context().Value(middleware.UserIDKey).(uint64)
+	if !isAuth {
+		response.Status = http.StatusUnauthorized
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	deleted, err := a.core.Sessions.KillUserSession(r.Context(), userId, id)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !deleted {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary end other sessions of current user
+// @Description signs out everywhere except the current session, or everywhere for a request with a token
+// @Tags Auth
+// @ID delete-other-sessions
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Success 200 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/me/sessions/delete_others [delete]
+func (a *Api) DeleteOtherSessions(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodDelete {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	userId, isAuth := r.Context().Value(middleware.UserIDKey).(uint64)
+	if !isAuth {
+		response.Status = http.StatusUnauthorized
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	err := a.core.Sessions.KillOtherSessions(r.Context(), userId, currentSessionId(r))
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary get sessions of user
+// @Description the signed in devices of any user, the most recent first
+// @Tags Auth
+// @ID find-user-sessions
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param user_id query integer true "User ID"
+// @Success 200 {array} models.SessionItem
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/profiles/sessions [get]
+func (a *Api) FindUserSessions(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	userId, err := strconv.ParseUint(r.URL.Query().Get("user_id"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	sessions, err := a.core.Sessions.FindUserSessions(r.Context(), userId, currentSessionId(r))
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = sessions
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary end sessions of user
+// @Description ends the session with the given id, or all sessions of the user when there is no id
+// @Tags Auth
+// @ID delete-user-sessions
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param user_id query integer true "User ID"
+// @Param id query string false "Session ID from the list of sessions (optional)"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/profiles/sessions/delete [delete]
+func (a *Api) DeleteUserSessions(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodDelete {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	userId, err := strconv.ParseUint(r.URL.Query().Get("user_id"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		err = a.core.Sessions.KillUserSessions(r.Context(), userId)
+		if err != nil {
+			response.Status = http.StatusInternalServerError
+		}
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	deleted, err := a.core.Sessions.KillUserSession(r.Context(), userId, id)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !deleted {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary get personal access tokens of current user
+// @Description the tokens that are not revoked, the newest first. The tokens themselves are not stored and cannot be shown again.
+// @Tags Auth
+// @ID find-tokens
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Success 200 {array} models.AccessTokenItem
+// @Failure 401 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/me/tokens [get]
+func (a *Api) FindTokens(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodGet {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	userId, isAuth := r.Context().Value(middleware.UserIDKey).(uint64)
+	if !isAuth {
+		response.Status = http.StatusUnauthorized
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	tokens, err := a.core.Tokens.FindTokens(r.Context(), userId)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = tokens
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// @Summary create personal access token
+// @Description the token is sent as Authorization: Bearer and is shown only in this response. Scopes are read, write:films, write:actors and admin, which only admins can give.
+// @Tags Auth
+// @ID add-token
+// @Accept json
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param input body models.AccessTokenRequest true "Token name and scopes"
+// @Success 200 {object} models.AccessTokenResponse
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/me/tokens/add [post]
+func (a *Api) AddToken(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodPost {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	userId, isAuth := r.Context().Value(middleware.UserIDKey).(uint64)
+	if !isAuth {
+		response.Status = http.StatusUnauthorized
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	var request models.AccessTokenRequest
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	err = json.Unmarshal(body, &request)
+	if err != nil || !a.validToken(&request) {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	role, _ := r.Context().Value(utils.RoleKey).(string)
+	if slices.Contains(request.Scopes, utils.TokenScopeAdmin) && role != utils.RoleAdmin {
+		response.Status = http.StatusConflict
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	token, err := a.core.Tokens.CreateToken(r.Context(), userId, &request)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	response.Body = token
+
+	httpResponse.SendResponse(w, r, &response, a.log)
+}
+
+// validToken checks the name and the scopes of a new token and sorts the
+// scopes, dropping repeated ones.
+func (a *Api) validToken(request *models.AccessTokenRequest) bool {
+	err := utils.ValidateStringSize(request.Name, utils.TokenNameBegin, utils.TokenNameEnd, utils.TokenNameSizeError, a.log)
+	if err != nil || len(request.Scopes) == 0 {
+		return false
+	}
+
+	for _, scope := range request.Scopes {
+		if !slices.Contains(utils.TokenScopes, scope) {
+			return false
+		}
+	}
+
+	slices.Sort(request.Scopes)
+	request.Scopes = slices.Compact(request.Scopes)
+
+	return true
+}
+
+// @Summary revoke personal access token
+// @Description the token stops working at once
+// @Tags Auth
+// @ID delete-token
+// @Produce json
+// @Param session_id header string false "Session ID"
+// @Security BearerAuth
+// @Param token_id query integer true "Token ID"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 405 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /api/v1/me/tokens/delete [delete]
+func (a *Api) DeleteToken(w http.ResponseWriter, r *http.Request) {
+	response := models.Response{Status: http.StatusOK, Body: nil}
+
+	if r.Method != http.MethodDelete {
+		response.Status = http.StatusMethodNotAllowed
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	userId, isAuth := r.Context().Value(middleware.UserIDKey).(uint64)
+	if !isAuth {
+		response.Status = http.StatusUnauthorized
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	tokenId, err := strconv.ParseUint(r.URL.Query().Get("token_id"), 10, 64)
+	if err != nil {
+		response.Status = http.StatusBadRequest
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	revoked, err := a.core.Tokens.RevokeToken(r.Context(), userId, tokenId)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
+	}
+
+	if !revoked {
+		response.Status = http.StatusNotFound
+		httpResponse.SendResponse(w, r, &response, a.log)
+		return
 	}
 
 	httpResponse.SendResponse(w, r, &response, a.log)
@@ -0,0 +1,130 @@
+package castgraph
+
+import (
+	"cmp"
+	"filmoteka/pkg/models"
+	"slices"
+)
+
+// Graph is the collaboration graph of the catalog: actors and the films they
+// are credited in, linked both ways. Two actors are co-stars when they share
+// a film. A Graph is not changed after New and is safe for concurrent use.
+type Graph struct {
+	films  map[uint64][]uint64
+	actors map[uint64][]uint64
+}
+
+// Link is a step of a path, the actor and the film they share with the next
+// actor of the path, zero for the last one.
+type Link struct {
+	ActorId uint64
+	FilmId  uint64
+}
+
+type Collaborator struct {
+	ActorId uint64
+	Films   int
+}
+
+// New builds the graph of links. Neighbours are kept in ascending order of
+// id, so paths and collaborators with equal rank always come out the same.
+func New(links []models.CastLink) *Graph {
+	g := &Graph{films: make(map[uint64][]uint64), actors: make(map[uint64][]uint64)}
+
+	for _, link := range links {
+		g.films[link.ActorId] = append(g.films[link.ActorId], link.FilmId)
+		g.actors[link.FilmId] = append(g.actors[link.FilmId], link.ActorId)
+	}
+
+	for _, neighbours := range []map[uint64][]uint64{g.films, g.actors} {
+		for id := range neighbours {
+			slices.Sort(neighbours[id])
+			neighbours[id] = slices.Compact(neighbours[id])
+		}
+	}
+
+	return g
+}
+
+// Path finds a shortest chain of co-stars from one actor to the other with a
+// breadth-first search. Found is false when they are not connected.
+func (g *Graph) Path(from uint64, to uint64) ([]Link, bool) {
+	if from == to {
+		return []Link{{ActorId: from}}, true
+	}
+
+	// previous holds the actor each reached actor was first reached from and
+	// the film they share, films are expanded once
+	previous := map[uint64]Link{from: {}}
+	expanded := make(map[uint64]bool)
+	queue := []uint64{from}
+
+	for len(queue) > 0 {
+		actor := queue[0]
+		queue = queue[1:]
+
+		for _, film := range g.films[actor] {
+			if expanded[film] {
+				continue
+			}
+			expanded[film] = true
+
+			for _, costar := range g.actors[film] {
+				if _, reached := previous[costar]; reached {
+					continue
+				}
+				previous[costar] = Link{ActorId: actor, FilmId: film}
+
+				if costar == to {
+					return g.chain(previous, to), true
+				}
+				queue = append(queue, costar)
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// chain walks previous back from the actor to the start of the search.
+func (g *Graph) chain(previous map[uint64]Link, actor uint64) []Link {
+	path := []Link{{ActorId: actor}}
+	for {
+		step := previous[actor]
+		if step.ActorId == 0 {
+			break
+		}
+		path = append(path, step)
+		actor = step.ActorId
+	}
+
+	slices.Reverse(path)
+	return path
+}
+
+// Collaborators returns up to limit co-stars of the actor, those sharing the
+// most films first.
+func (g *Graph) Collaborators(actorId uint64, limit int) []Collaborator {
+	shared := make(map[uint64]int)
+	for _, film := range g.films[actorId] {
+		for _, costar := range g.actors[film] {
+			if costar != actorId {
+				shared[costar]++
+			}
+		}
+	}
+
+	collaborators := make([]Collaborator, 0, len(shared))
+	for costar, films := range shared {
+		collaborators = append(collaborators, Collaborator{ActorId: costar, Films: films})
+	}
+
+	slices.SortFunc(collaborators, func(a, b Collaborator) int {
+		if a.Films != b.Films {
+			return cmp.Compare(b.Films, a.Films)
+		}
+		return cmp.Compare(a.ActorId, b.ActorId)
+	})
+
+	return collaborators[:min(limit, len(collaborators))]
+}
@@ -0,0 +1,76 @@
+package castgraph
+
+import (
+	"filmoteka/pkg/models"
+	"slices"
+	"testing"
+)
+
+// testGraph casts actors 1 to 6 in connected films, 1 and 2 share two of
+// them, and actors 7 and 8 in a film of their own.
+func testGraph() *Graph {
+	return New([]models.CastLink{
+		{ActorId: 1, FilmId: 10}, {ActorId: 2, FilmId: 10}, {ActorId: 1, FilmId: 10},
+		{ActorId: 2, FilmId: 11}, {ActorId: 3, FilmId: 11},
+		{ActorId: 3, FilmId: 12}, {ActorId: 4, FilmId: 12},
+		{ActorId: 1, FilmId: 13}, {ActorId: 2, FilmId: 13},
+		{ActorId: 1, FilmId: 14}, {ActorId: 6, FilmId: 14}, {ActorId: 5, FilmId: 14},
+		{ActorId: 7, FilmId: 20}, {ActorId: 8, FilmId: 20},
+	})
+}
+
+func TestPath(t *testing.T) {
+	g := testGraph()
+
+	tests := []struct {
+		name  string
+		from  uint64
+		to    uint64
+		path  []Link
+		found bool
+	}{
+		{"same actor", 1, 1, []Link{{ActorId: 1}}, true},
+		{"co-stars", 1, 2, []Link{{ActorId: 1, FilmId: 10}, {ActorId: 2}}, true},
+		{"co-stars backwards", 2, 1, []Link{{ActorId: 2, FilmId: 10}, {ActorId: 1}}, true},
+		{"two hops", 1, 3, []Link{{ActorId: 1, FilmId: 10}, {ActorId: 2, FilmId: 11}, {ActorId: 3}}, true},
+		{"three hops", 5, 4, []Link{{ActorId: 5, FilmId: 14}, {ActorId: 1, FilmId: 10}, {ActorId: 2, FilmId: 11},
+			{ActorId: 3, FilmId: 12}, {ActorId: 4}}, true},
+		{"disconnected", 1, 7, nil, false},
+		{"unknown actor", 1, 99, nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path, found := g.Path(test.from, test.to)
+			if found != test.found || !slices.Equal(path, test.path) {
+				t.Errorf("Path(%d, %d) = %v, %t, want %v, %t", test.from, test.to, path, found, test.path, test.found)
+			}
+		})
+	}
+}
+
+func TestCollaborators(t *testing.T) {
+	g := testGraph()
+
+	tests := []struct {
+		name          string
+		actorId       uint64
+		limit         int
+		collaborators []Collaborator
+	}{
+		{"most shared films first, ties by id", 1, 10, []Collaborator{{ActorId: 2, Films: 2}, {ActorId: 5, Films: 1}, {ActorId: 6, Films: 1}}},
+		{"limited", 1, 2, []Collaborator{{ActorId: 2, Films: 2}, {ActorId: 5, Films: 1}}},
+		{"zero limit", 1, 0, []Collaborator{}},
+		{"single film", 7, 10, []Collaborator{{ActorId: 8, Films: 1}}},
+		{"unknown actor", 99, 10, []Collaborator{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			collaborators := g.Collaborators(test.actorId, test.limit)
+			if !slices.Equal(collaborators, test.collaborators) {
+				t.Errorf("Collaborators(%d, %d) = %v, want %v", test.actorId, test.limit, collaborators, test.collaborators)
+			}
+		})
+	}
+}
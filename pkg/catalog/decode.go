@@ -0,0 +1,211 @@
+package catalog
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"filmoteka/pkg/models"
+	"fmt"
+	"io"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+var Formats = []string{FormatCSV, FormatJSON, FormatNDJSON}
+
+const (
+	RecordFilm  = "film"
+	RecordActor = "actor"
+	RecordLink  = "link"
+)
+
+// csvColumns is the header of catalog CSV files. Imported files may list the
+// columns in any order and leave out all but type.
+var csvColumns = []string{"type", "title", "info", "release_date", "name", "gen", "birthday", "role", "character", "billing_order"}
+
+// maxLineSize bounds a single NDJSON line.
+const maxLineSize = 1 << 20
+
+// FormatFromContentType maps a request content type to a catalog format, it
+// returns an empty string for unknown types.
+func FormatFromContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+
+	switch mediaType {
+	case "text/csv":
+		return FormatCSV
+	case "application/json":
+		return FormatJSON
+	case "application/x-ndjson", "application/jsonl":
+		return FormatNDJSON
+	}
+
+	return ""
+}
+
+// Decode reads a whole catalog file in format. Every record gets a Source
+// describing where it came from, so that import errors can point at it.
+func Decode(format string, r io.Reader) (*models.Catalog, error) {
+	switch format {
+	case FormatCSV:
+		return decodeCSV(r)
+	case FormatJSON:
+		return decodeJSON(r)
+	case FormatNDJSON:
+		return decodeNDJSON(r)
+	}
+
+	return nil, fmt.Errorf("unknown catalog format %s", format)
+}
+
+func decodeCSV(r io.Reader) (*models.Catalog, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header error: %s", err.Error())
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(csvColumns, name) {
+			return nil, fmt.Errorf("unknown csv column %s", name)
+		}
+		columns[name] = i
+	}
+	if _, found := columns["type"]; !found {
+		return nil, fmt.Errorf("csv header has no type column")
+	}
+
+	catalog := &models.Catalog{}
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read csv error: %s", err.Error())
+		}
+
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			i, found := columns[name]
+			if !found || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+
+		record := models.CatalogRecord{
+			Type:        field("type"),
+			Title:       field("title"),
+			Info:        field("info"),
+			ReleaseDate: field("release_date"),
+			Name:        field("name"),
+			Gender:      field("gen"),
+			Birthday:    field("birthday"),
+			Role:        field("role"),
+			Character:   field("character"),
+			Source:      "line " + strconv.Itoa(line),
+		}
+
+		if order := field("billing_order"); order != "" {
+			record.BillingOrder, err = strconv.Atoi(order)
+			if err != nil {
+				return nil, fmt.Errorf("%s: billing_order must be a number", record.Source)
+			}
+		}
+
+		err = appendRecord(catalog, record)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return catalog, nil
+}
+
+func decodeJSON(r io.Reader) (*models.Catalog, error) {
+	catalog := &models.Catalog{}
+
+	err := json.NewDecoder(r).Decode(catalog)
+	if err != nil {
+		return nil, fmt.Errorf("read json error: %s", err.Error())
+	}
+
+	for i := range catalog.Films {
+		catalog.Films[i].Type = RecordFilm
+		catalog.Films[i].Source = "films[" + strconv.Itoa(i) + "]"
+	}
+	for i := range catalog.Actors {
+		catalog.Actors[i].Type = RecordActor
+		catalog.Actors[i].Source = "actors[" + strconv.Itoa(i) + "]"
+	}
+	for i := range catalog.Links {
+		catalog.Links[i].Type = RecordLink
+		catalog.Links[i].Source = "links[" + strconv.Itoa(i) + "]"
+	}
+
+	return catalog, nil
+}
+
+func decodeNDJSON(r io.Reader) (*models.Catalog, error) {
+	catalog := &models.Catalog{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var record models.CatalogRecord
+		err := json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: read json error: %s", line, err.Error())
+		}
+		record.Source = "line " + strconv.Itoa(line)
+
+		err = appendRecord(catalog, record)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read ndjson error: %s", err.Error())
+	}
+
+	return catalog, nil
+}
+
+func appendRecord(catalog *models.Catalog, record models.CatalogRecord) error {
+	switch record.Type {
+	case RecordFilm:
+		catalog.Films = append(catalog.Films, record)
+	case RecordActor:
+		catalog.Actors = append(catalog.Actors, record)
+	case RecordLink:
+		catalog.Links = append(catalog.Links, record)
+	default:
+		return fmt.Errorf("%s: record type must be one of film, actor, link", record.Source)
+	}
+
+	return nil
+}
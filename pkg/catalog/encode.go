@@ -0,0 +1,166 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"filmoteka/pkg/models"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// jsonSections lists the arrays of a JSON catalog in the order they are written.
+var jsonSections = []struct {
+	record string
+	key    string
+}{
+	{RecordFilm, "films"},
+	{RecordActor, "actors"},
+	{RecordLink, "links"},
+}
+
+// Encoder writes catalog records one at a time in a format Decode reads back.
+// Records have to come grouped by type, films first, then actors, then links.
+type Encoder struct {
+	format  string
+	w       io.Writer
+	csv     *csv.Writer
+	section int
+	first   bool
+}
+
+func NewEncoder(format string, w io.Writer) (*Encoder, error) {
+	encoder := &Encoder{format: format, w: w, section: -1}
+
+	switch format {
+	case FormatCSV:
+		encoder.csv = csv.NewWriter(w)
+		err := encoder.csv.Write(csvColumns)
+		if err != nil {
+			return nil, fmt.Errorf("write csv header error: %s", err.Error())
+		}
+	case FormatJSON, FormatNDJSON:
+	default:
+		return nil, fmt.Errorf("unknown catalog format %s", format)
+	}
+
+	return encoder, nil
+}
+
+// ContentType returns the media type of files written in format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	}
+
+	return "application/json"
+}
+
+func (e *Encoder) Encode(record *models.CatalogRecord) error {
+	switch e.format {
+	case FormatCSV:
+		return e.encodeCSV(record)
+	case FormatNDJSON:
+		return e.encodeNDJSON(record)
+	}
+
+	return e.encodeJSON(record)
+}
+
+// Close writes whatever the format needs after the last record. It does not
+// close the underlying writer.
+func (e *Encoder) Close() error {
+	switch e.format {
+	case FormatCSV:
+		e.csv.Flush()
+		return e.csv.Error()
+	case FormatJSON:
+		err := e.openSection(len(jsonSections) - 1)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(e.w, "]}\n")
+		return err
+	}
+
+	return nil
+}
+
+func (e *Encoder) encodeCSV(record *models.CatalogRecord) error {
+	billingOrder := ""
+	if record.Type == RecordLink {
+		billingOrder = strconv.Itoa(record.BillingOrder)
+	}
+
+	return e.csv.Write([]string{record.Type, record.Title, record.Info, record.ReleaseDate,
+		record.Name, record.Gender, record.Birthday, record.Role, record.Character, billingOrder})
+}
+
+func (e *Encoder) encodeNDJSON(record *models.CatalogRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encode record error: %s", err.Error())
+	}
+
+	_, err = e.w.Write(append(data, '\n'))
+	return err
+}
+
+func (e *Encoder) encodeJSON(record *models.CatalogRecord) error {
+	section := -1
+	for i := range jsonSections {
+		if jsonSections[i].record == record.Type {
+			section = i
+		}
+	}
+	if section < 0 {
+		return fmt.Errorf("unknown record type %s", record.Type)
+	}
+	if section < e.section {
+		return fmt.Errorf("%s record after %s records", record.Type, jsonSections[e.section].record)
+	}
+
+	err := e.openSection(section)
+	if err != nil {
+		return err
+	}
+
+	typeless := *record
+	typeless.Type = ""
+	data, err := json.Marshal(&typeless)
+	if err != nil {
+		return fmt.Errorf("encode record error: %s", err.Error())
+	}
+
+	if !e.first {
+		data = append([]byte{','}, data...)
+	}
+	e.first = false
+
+	_, err = e.w.Write(data)
+	return err
+}
+
+// openSection closes the current JSON array and opens the following ones up
+// to section.
+func (e *Encoder) openSection(section int) error {
+	for e.section < section {
+		prefix := "],"
+		if e.section < 0 {
+			prefix = "{"
+		}
+		e.section++
+		e.first = true
+
+		_, err := io.WriteString(e.w, prefix+`"`+jsonSections[e.section].key+`":[`)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package images
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/models"
+	"fmt"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Directories of the storage that film posters and actor photos are kept in.
+const (
+	DirFilms  = "films"
+	DirActors = "actors"
+)
+
+// UrlPrefix is the path images are served under, followed by their storage key.
+const UrlPrefix = "/api/v1/images/"
+
+const (
+	SizeOriginal = "original"
+	SizeSmall    = "small"
+	SizeMedium   = "medium"
+	SizeLarge    = "large"
+)
+
+// thumbnails are the widths every image is scaled down to, keeping the
+// aspect ratio. Narrower images keep their width.
+var thumbnails = []struct {
+	size  string
+	width int
+}{
+	{SizeSmall, 160},
+	{SizeMedium, 320},
+	{SizeLarge, 640},
+}
+
+// thumbnailQuality is the JPEG quality thumbnails are encoded with.
+const thumbnailQuality = 85
+
+// extensions maps the accepted content types to the extension the original
+// file is stored with.
+var extensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/webp": "webp",
+}
+
+// keyPattern matches the storage keys of images and their thumbnails.
+var keyPattern = regexp.MustCompile(`^(films|actors)/[0-9a-f]{32}/(original\.(jpg|png|webp)|(small|medium|large)\.jpg)$`)
+
+var (
+	ErrContentType = errors.New(utils.ImageContentTypeError)
+	ErrDimension   = errors.New(utils.ImageDimensionError)
+)
+
+// Upload is an uploaded image that passed the checks of Decode.
+type Upload struct {
+	Data  []byte
+	Ext   string
+	Image image.Image
+}
+
+// Decode checks that data is a JPEG, PNG or WebP image no larger than
+// utils.ImageMaxDimension pixels a side and decodes it. The type is detected
+// from the content, the contentType sent by the client has to agree with it
+// unless it is empty or application/octet-stream.
+func Decode(data []byte, contentType string) (*Upload, error) {
+	detected := http.DetectContentType(data)
+	ext, found := extensions[detected]
+	if !found {
+		return nil, ErrContentType
+	}
+
+	if contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != detected && mediaType != "application/octet-stream") {
+			return nil, ErrContentType
+		}
+	}
+
+	// the dimensions are checked before decoding, which allocates all the pixels
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image config error: %s", err.Error())
+	}
+	if config.Width > utils.ImageMaxDimension || config.Height > utils.ImageMaxDimension {
+		return nil, ErrDimension
+	}
+	if config.Width == 0 || config.Height == 0 {
+		return nil, fmt.Errorf("decode image config error: empty image")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image error: %s", err.Error())
+	}
+
+	return &Upload{Data: data, Ext: ext, Image: img}, nil
+}
+
+// NewName returns a random name for an image with extension ext. Every upload
+// gets a new name, so that the file behind an URL never changes.
+func NewName(ext string) (string, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", fmt.Errorf("image name error: %s", err.Error())
+	}
+
+	return hex.EncodeToString(id) + "." + ext, nil
+}
+
+// Files returns the original file of upload and its thumbnails by their
+// storage keys for the image name in dir.
+func Files(dir string, name string, upload *Upload) (map[string][]byte, error) {
+	files := map[string][]byte{Key(dir, name, SizeOriginal): upload.Data}
+
+	for _, thumbnail := range thumbnails {
+		data, err := scale(upload.Image, thumbnail.width)
+		if err != nil {
+			return nil, fmt.Errorf("%s thumbnail error: %s", thumbnail.size, err.Error())
+		}
+		files[Key(dir, name, thumbnail.size)] = data
+	}
+
+	return files, nil
+}
+
+// scale resizes img to width, keeping the aspect ratio, and encodes it as
+// JPEG. Transparent parts are laid over white.
+func scale(img image.Image, width int) ([]byte, error) {
+	bounds := img.Bounds()
+	width = min(width, bounds.Dx())
+	height := max(bounds.Dy()*width/bounds.Dx(), 1)
+
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(thumbnail, thumbnail.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), img, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: thumbnailQuality})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Key returns the storage key of the image name in dir at size. Thumbnails
+// are always JPEG, the original keeps the extension of the name.
+func Key(dir string, name string, size string) string {
+	id, ext, _ := strings.Cut(name, ".")
+	if size != SizeOriginal {
+		ext = "jpg"
+	}
+
+	return dir + "/" + id + "/" + size + "." + ext
+}
+
+// Keys returns the storage keys of the image name in dir and its thumbnails.
+func Keys(dir string, name string) []string {
+	keys := []string{Key(dir, name, SizeOriginal)}
+	for _, thumbnail := range thumbnails {
+		keys = append(keys, Key(dir, name, thumbnail.size))
+	}
+
+	return keys
+}
+
+// ValidKey reports whether key is the storage key of an image or a thumbnail.
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key)
+}
+
+// Urls returns the addresses of the image name in dir and its thumbnails, or
+// nil when name is empty.
+func Urls(dir string, name string) *models.ImageUrls {
+	if name == "" {
+		return nil
+	}
+
+	return &models.ImageUrls{
+		Original: UrlPrefix + Key(dir, name, SizeOriginal),
+		Small:    UrlPrefix + Key(dir, name, SizeSmall),
+		Medium:   UrlPrefix + Key(dir, name, SizeMedium),
+		Large:    UrlPrefix + Key(dir, name, SizeLarge),
+	}
+}
@@ -2,44 +2,160 @@ package middleware
 
 import (
 	"context"
-	"errors"
+	"crypto/rand"
+	"encoding/hex"
+	utils "filmoteka/pkg"
 	"filmoteka/pkg/models"
 	httpResponse "filmoteka/pkg/response"
-	core_profiles "filmoteka/usecase/profiles"
 	core_session "filmoteka/usecase/sessions"
+	core_tokens "filmoteka/usecase/tokens"
 	"github.com/sirupsen/logrus"
+	"net"
 	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
 )
 
-type contextKey string
+const UserIDKey = utils.UserIDKey
 
-const UserIDKey contextKey = "userId"
+// requestIdMaxLen bounds the length of a request id accepted from the X-Request-Id header.
+const requestIdMaxLen = 64
 
 type Middleware struct {
 	Lg       *logrus.Logger
 	Sessions core_session.ISessions
-	Profiles core_profiles.IProfiles
+	Tokens   core_tokens.ITokens
+	// TrustedProxies are the networks whose X-Real-IP header is believed.
+	TrustedProxies []netip.Prefix
 }
 
-func (m *Middleware) AuthCheck(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		session, err := r.Cookie("session_id")
-		if errors.Is(err, http.ErrNoCookie) {
-			response := models.Response{Status: http.StatusUnauthorized, Body: nil}
-			httpResponse.SendResponse(w, r, &response, m.Lg)
-			return
+// SessionId returns the id of the session from the session_id cookie, or from
+// the session_id header for clients that cannot keep cookies.
+func SessionId(r *http.Request) string {
+	cookie, err := r.Cookie("session_id")
+	if err == nil {
+		return cookie.Value
+	}
+
+	return r.Header.Get("session_id")
+}
+
+// bearerToken returns the personal access token from the Authorization header.
+func bearerToken(r *http.Request) string {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+// tokenAllows reports whether a token with the scopes may make the request.
+// GET needs read, anything else the scopes the route asks for with Scope, or
+// admin when it asks for none.
+func tokenAllows(scopes []string, r *http.Request) bool {
+	if slices.Contains(scopes, utils.TokenScopeAdmin) {
+		return true
+	}
+
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return slices.Contains(scopes, utils.TokenScopeRead)
+	}
+
+	required, _ := r.Context().Value(utils.ScopesKey).([]string)
+	if len(required) == 0 {
+		return false
+	}
+
+	for _, scope := range required {
+		if !slices.Contains(scopes, scope) {
+			return false
 		}
+	}
+
+	return true
+}
+
+// tokenAdmin reports whether a request may use the admin role of its user.
+// Sessions always may. A token needs the admin scope, or for a change the
+// scopes the route asks for with Scope, which AuthCheck has checked already.
+// Reads on admin routes, such as the audit log or the sessions of other
+// users, always need the admin scope.
+func tokenAdmin(r *http.Request) bool {
+	accessToken, isToken := r.Context().Value(utils.TokenKey).(*models.AccessToken)
+	if !isToken || slices.Contains(accessToken.Scopes, utils.TokenScopeAdmin) {
+		return true
+	}
+
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return false
+	}
 
-		userId, err := m.Sessions.GetUserId(r.Context(), session.Value)
+	required, _ := r.Context().Value(utils.ScopesKey).([]string)
+
+	return len(required) > 0
+}
+
+// authenticate resolves the user of the request from a bearer token, or else
+// from the session. It puts the user id and role into the context along with
+// the token or the session, and returns the status to answer with when the
+// request is not authorised, or 0.
+func (m *Middleware) authenticate(r *http.Request) (*http.Request, int) {
+	ctx := r.Context()
+
+	token := bearerToken(r)
+	if token != "" {
+		accessToken, found, err := m.Tokens.CheckToken(ctx, token)
 		if err != nil {
 			m.Lg.Error("auth check error", "err", err.Error())
-			next.ServeHTTP(w, r)
-			return
+			return r, http.StatusInternalServerError
 		}
 
-		r = r.WithContext(context.WithValue(r.Context(), UserIDKey, userId))
-		if userId == 0 {
-			response := models.Response{Status: http.StatusUnauthorized, Body: nil}
+		if !found {
+			return r, http.StatusUnauthorized
+		}
+
+		if !tokenAllows(accessToken.Scopes, r) {
+			return r, http.StatusConflict
+		}
+
+		ctx = context.WithValue(ctx, UserIDKey, accessToken.UserId)
+		ctx = context.WithValue(ctx, utils.RoleKey, accessToken.Role)
+		ctx = context.WithValue(ctx, utils.TokenKey, accessToken)
+
+		return r.WithContext(ctx), 0
+	}
+
+	sid := SessionId(r)
+	if sid == "" {
+		return r, http.StatusUnauthorized
+	}
+
+	active, found, err := m.Sessions.GetSession(ctx, sid)
+	if err != nil {
+		m.Lg.Error("auth check error", "err", err.Error())
+		return r, http.StatusInternalServerError
+	}
+
+	if !found {
+		return r, http.StatusUnauthorized
+	}
+
+	ctx = context.WithValue(ctx, UserIDKey, active.UserId)
+	ctx = context.WithValue(ctx, utils.RoleKey, active.Role)
+	ctx = context.WithValue(ctx, utils.SessionKey, active)
+
+	return r.WithContext(ctx), 0
+}
+
+// AuthCheck lets through requests with a valid personal access token in the
+// Authorization header, or a valid session in the session_id cookie or header.
+func (m *Middleware) AuthCheck(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, status := m.authenticate(r)
+		if status != 0 {
+			response := models.Response{Status: status, Body: nil}
 			httpResponse.SendResponse(w, r, &response, m.Lg)
 			return
 		}
@@ -48,23 +164,37 @@ func (m *Middleware) AuthCheck(next http.Handler) http.Handler {
 	})
 }
 
+// AuthOptional resolves the user of a valid session or token like AuthCheck,
+// but lets anonymous requests through without a UserIDKey in the context.
+func (m *Middleware) AuthOptional(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorised, status := m.authenticate(r)
+		if status == 0 {
+			r = authorised
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Scope names the token scopes a route needs for requests other than GET and
+// goes outside of AuthCheck. Sessions are not limited by scopes.
+func (m *Middleware) Scope(next http.Handler, scopes ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), utils.ScopesKey, scopes)))
+	})
+}
+
 func (m *Middleware) CheckRole(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		userId, isAuth := r.Context().Value(UserIDKey).(uint64)
+		role, isAuth := r.Context().Value(utils.RoleKey).(string)
 		if !isAuth {
 			response := models.Response{Status: http.StatusUnauthorized, Body: nil}
 			httpResponse.SendResponse(w, r, &response, m.Lg)
 			return
 		}
 
-		result, err := m.Profiles.GetRole(r.Context(), userId)
-		if err != nil {
-			m.Lg.Error("auth check error", "err", err.Error())
-			next.ServeHTTP(w, r)
-			return
-		}
-
-		if result != "admin" {
+		if role != utils.RoleAdmin || !tokenAdmin(r) {
 			response := models.Response{Status: http.StatusConflict, Body: nil}
 			httpResponse.SendResponse(w, r, &response, m.Lg)
 			return
@@ -73,3 +203,57 @@ func (m *Middleware) CheckRole(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// RequestMeta puts the request id and the client address into the context of
+// every request. The request id comes from the X-Request-Id header set by
+// nginx, or is generated when there is none, and is echoed in the response.
+func (m *Middleware) RequestMeta(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get("X-Request-Id")
+		if requestId == "" || len(requestId) > requestIdMaxLen {
+			requestId = newRequestId()
+		}
+		w.Header().Set("X-Request-Id", requestId)
+
+		ctx := context.WithValue(r.Context(), utils.RequestIDKey, requestId)
+		ctx = context.WithValue(ctx, utils.ClientIPKey, m.clientIP(r))
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func newRequestId() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// clientIP returns the address of the client. Behind nginx it is passed in
+// X-Real-IP, which is only believed when the request comes from a trusted
+// proxy: anyone else could put any address there.
+func (m *Middleware) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := r.Header.Get("X-Real-IP")
+	if ip == "" {
+		return host
+	}
+
+	remote, err := netip.ParseAddr(host)
+	if err != nil {
+		return host
+	}
+
+	remote = remote.Unmap()
+	for _, proxy := range m.TrustedProxies {
+		if proxy.Contains(remote) {
+			return ip
+		}
+	}
+
+	return host
+}
@@ -0,0 +1,303 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/models"
+	httpResponse "filmoteka/pkg/response"
+	core_session "filmoteka/usecase/sessions"
+	core_tokens "filmoteka/usecase/tokens"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+type fakeSessions struct {
+	core_session.ISessions
+	sessions map[string]*models.Session
+}
+
+func (f *fakeSessions) GetSession(ctx context.Context, sid string) (*models.Session, bool, error) {
+	active, found := f.sessions[sid]
+	return active, found, nil
+}
+
+type fakeTokens struct {
+	core_tokens.ITokens
+	tokens map[string]*models.AccessToken
+}
+
+func (f *fakeTokens) CheckToken(ctx context.Context, token string) (*models.AccessToken, bool, error) {
+	accessToken, found := f.tokens[token]
+	return accessToken, found, nil
+}
+
+func testMiddleware() *Middleware {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	token := func(id uint64, role string, scopes ...string) *models.AccessToken {
+		return &models.AccessToken{Id: id, UserId: 1, Login: "login", Role: role, Scopes: scopes}
+	}
+
+	return &Middleware{
+		Lg: log,
+		Sessions: &fakeSessions{sessions: map[string]*models.Session{
+			"user":  {SID: "user", UserId: 2, Login: "user", Role: utils.RoleUser},
+			"admin": {SID: "admin", UserId: 1, Login: "admin", Role: utils.RoleAdmin},
+		}},
+		Tokens: &fakeTokens{tokens: map[string]*models.AccessToken{
+			"flm_read":         token(1, utils.RoleAdmin, utils.TokenScopeRead),
+			"flm_films":        token(2, utils.RoleAdmin, utils.TokenScopeWriteFilms),
+			"flm_films_actors": token(3, utils.RoleAdmin, utils.TokenScopeWriteFilms, utils.TokenScopeWriteActors),
+			"flm_admin":        token(4, utils.RoleAdmin, utils.TokenScopeAdmin),
+			"flm_user_admin":   token(5, utils.RoleUser, utils.TokenScopeAdmin),
+		}},
+	}
+}
+
+func TestTokenAllows(t *testing.T) {
+	read := []string{utils.TokenScopeRead}
+	films := []string{utils.TokenScopeWriteFilms}
+	filmsActors := []string{utils.TokenScopeWriteFilms, utils.TokenScopeWriteActors}
+	admin := []string{utils.TokenScopeAdmin}
+
+	tests := []struct {
+		name     string
+		method   string
+		scopes   []string
+		required []string
+		want     bool
+	}{
+		{name: "get with read", method: http.MethodGet, scopes: read, want: true},
+		{name: "head with read", method: http.MethodHead, scopes: read, want: true},
+		{name: "get without read", method: http.MethodGet, scopes: films, want: false},
+		{name: "get with admin", method: http.MethodGet, scopes: admin, want: true},
+		{name: "post with read", method: http.MethodPost, scopes: read, required: films, want: false},
+		{name: "post with required scope", method: http.MethodPost, scopes: films, required: films, want: true},
+		{name: "post with other write scope", method: http.MethodPost, scopes: films, required: []string{utils.TokenScopeWriteActors}, want: false},
+		{name: "post with one of two required", method: http.MethodPost, scopes: films, required: filmsActors, want: false},
+		{name: "post with both required", method: http.MethodPost, scopes: filmsActors, required: filmsActors, want: true},
+		{name: "post to route without scopes", method: http.MethodPost, scopes: filmsActors, want: false},
+		{name: "post to route without scopes with admin", method: http.MethodPost, scopes: admin, want: true},
+		{name: "delete with admin", method: http.MethodDelete, scopes: admin, required: films, want: true},
+		{name: "no scopes", method: http.MethodGet, scopes: nil, want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(test.method, "/", nil)
+			if test.required != nil {
+				r = r.WithContext(context.WithValue(r.Context(), utils.ScopesKey, test.required))
+			}
+
+			if got := tokenAllows(test.scopes, r); got != test.want {
+				t.Errorf("tokenAllows = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSessionId(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie string
+		header string
+		want   string
+	}{
+		{name: "cookie", cookie: "from-cookie", want: "from-cookie"},
+		{name: "header", header: "from-header", want: "from-header"},
+		{name: "cookie before header", cookie: "from-cookie", header: "from-header", want: "from-cookie"},
+		{name: "none", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: "session_id", Value: test.cookie})
+			}
+			if test.header != "" {
+				r.Header.Set("session_id", test.header)
+			}
+
+			if got := SessionId(r); got != test.want {
+				t.Errorf("SessionId = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "Bearer flm_token", want: "flm_token"},
+		{header: "bearer flm_token", want: "flm_token"},
+		{header: "Basic dXNlcjpwYXNz", want: ""},
+		{header: "Bearer", want: ""},
+		{header: "", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.header, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", test.header)
+
+			if got := bearerToken(r); got != test.want {
+				t.Errorf("bearerToken = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// TestAuthorise runs requests through the middleware the way the routes in
+// delivery/http chain it and checks the status they are answered with.
+func TestAuthorise(t *testing.T) {
+	m := testMiddleware()
+
+	var userId uint64
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userId, _ = r.Context().Value(UserIDKey).(uint64)
+		httpResponse.SendResponse(w, r, &models.Response{Status: http.StatusOK}, m.Lg)
+	})
+
+	user := m.AuthCheck(ok)
+	adminRead := m.AuthCheck(m.CheckRole(ok))
+	adminAny := m.AuthCheck(m.CheckRole(ok))
+	adminFilms := m.Scope(m.AuthCheck(m.CheckRole(ok)), utils.TokenScopeWriteFilms)
+	adminImport := m.Scope(m.AuthCheck(m.CheckRole(ok)), utils.TokenScopeWriteFilms, utils.TokenScopeWriteActors)
+	userFilms := m.Scope(m.AuthCheck(ok), utils.TokenScopeWriteFilms)
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		method  string
+		session string
+		token   string
+		want    int
+		userId  uint64
+	}{
+		{name: "no credentials", handler: user, method: http.MethodGet, want: http.StatusUnauthorized},
+		{name: "unknown session", handler: user, method: http.MethodGet, session: "other", want: http.StatusUnauthorized},
+		{name: "unknown token", handler: user, method: http.MethodGet, token: "flm_other", want: http.StatusUnauthorized},
+		{name: "session", handler: user, method: http.MethodGet, session: "user", want: http.StatusOK, userId: 2},
+		{name: "token before session", handler: user, method: http.MethodGet, session: "user", token: "flm_read", want: http.StatusOK, userId: 1},
+		{name: "user session on admin route", handler: adminRead, method: http.MethodGet, session: "user", want: http.StatusConflict},
+		{name: "admin session on admin route", handler: adminRead, method: http.MethodGet, session: "admin", want: http.StatusOK, userId: 1},
+		{name: "admin session on write route", handler: adminFilms, method: http.MethodPost, session: "admin", want: http.StatusOK, userId: 1},
+		{name: "read token on admin read route", handler: adminRead, method: http.MethodGet, token: "flm_read", want: http.StatusConflict},
+		{name: "admin token on admin read route", handler: adminRead, method: http.MethodGet, token: "flm_admin", want: http.StatusOK, userId: 1},
+		{name: "admin scope of user on admin route", handler: adminRead, method: http.MethodGet, token: "flm_user_admin", want: http.StatusConflict},
+		{name: "write token on admin route without scopes", handler: adminAny, method: http.MethodPatch, token: "flm_films_actors", want: http.StatusConflict},
+		{name: "write token on its admin write route", handler: adminFilms, method: http.MethodPost, token: "flm_films", want: http.StatusOK, userId: 1},
+		{name: "write token on admin read route", handler: adminFilms, method: http.MethodGet, token: "flm_films", want: http.StatusConflict},
+		{name: "read token on admin write route", handler: adminFilms, method: http.MethodPost, token: "flm_read", want: http.StatusConflict},
+		{name: "films token on import", handler: adminImport, method: http.MethodPost, token: "flm_films", want: http.StatusConflict},
+		{name: "films and actors token on import", handler: adminImport, method: http.MethodPost, token: "flm_films_actors", want: http.StatusOK, userId: 1},
+		{name: "write token on user write route", handler: userFilms, method: http.MethodPost, token: "flm_films", want: http.StatusOK, userId: 1},
+		{name: "read token on user write route", handler: userFilms, method: http.MethodPost, token: "flm_read", want: http.StatusConflict},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			userId = 0
+
+			r := httptest.NewRequest(test.method, "/", nil)
+			if test.session != "" {
+				r.AddCookie(&http.Cookie{Name: "session_id", Value: test.session})
+			}
+			if test.token != "" {
+				r.Header.Set("Authorization", "Bearer "+test.token)
+			}
+
+			w := httptest.NewRecorder()
+			test.handler.ServeHTTP(w, r)
+
+			var response models.Response
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			if err != nil {
+				t.Fatalf("response error: %s", err.Error())
+			}
+
+			if response.Status != test.want {
+				t.Errorf("status = %d, want %d", response.Status, test.want)
+			}
+			if userId != test.userId {
+				t.Errorf("user id = %d, want %d", userId, test.userId)
+			}
+		})
+	}
+}
+
+func TestAuthOptional(t *testing.T) {
+	m := testMiddleware()
+
+	tests := []struct {
+		name   string
+		token  string
+		userId uint64
+	}{
+		{name: "anonymous"},
+		{name: "read token", token: "flm_read", userId: 1},
+		{name: "token without read", token: "flm_films"},
+		{name: "unknown token", token: "flm_other"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var userId uint64
+			handler := m.AuthOptional(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				userId, _ = r.Context().Value(UserIDKey).(uint64)
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.token != "" {
+				r.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if userId != test.userId {
+				t.Errorf("user id = %d, want %d", userId, test.userId)
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	m := testMiddleware()
+	m.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("172.28.0.0/16"), netip.MustParsePrefix("::1/128")}
+
+	tests := []struct {
+		name   string
+		remote string
+		header string
+		want   string
+	}{
+		{name: "trusted proxy", remote: "172.28.0.5:40000", header: "203.0.113.7", want: "203.0.113.7"},
+		{name: "trusted ipv6 proxy", remote: "[::1]:40000", header: "203.0.113.7", want: "203.0.113.7"},
+		{name: "trusted proxy without header", remote: "172.28.0.5:40000", want: "172.28.0.5"},
+		{name: "untrusted client", remote: "198.51.100.1:40000", header: "203.0.113.7", want: "198.51.100.1"},
+		{name: "untrusted client without header", remote: "198.51.100.1:40000", want: "198.51.100.1"},
+		{name: "mapped trusted proxy", remote: "[::ffff:172.28.0.5]:40000", header: "203.0.113.7", want: "203.0.113.7"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = test.remote
+			if test.header != "" {
+				r.Header.Set("X-Real-IP", test.header)
+			}
+
+			if got := m.clientIP(r); got != test.want {
+				t.Errorf("clientIP = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditEntry struct {
+	Id        uint64          `json:"id"`
+	UserId    uint64          `json:"user_id"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityId  uint64          `json:"entity_id"`
+	Before    json.RawMessage `json:"before" swaggertype:"object"`
+	After     json.RawMessage `json:"after" swaggertype:"object"`
+	Ip        string          `json:"ip"`
+	RequestId string          `json:"request_id"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type FindAuditRequest struct {
+	UserId   uint64
+	Action   string
+	Entity   string
+	EntityId uint64
+	From     *time.Time
+	To       *time.Time
+	Page     uint64
+	PerPage  uint64
+}
+
+type AuditResponse struct {
+	Total   int          `json:"total"`
+	Page    uint64       `json:"page"`
+	PerPage uint64       `json:"per_page"`
+	Entries []AuditEntry `json:"entries"`
+}
@@ -0,0 +1,42 @@
+package models
+
+// CatalogRecord is one row of an imported or exported catalog file. Type
+// tells which fields are used: a film has a title, info and release date, an
+// actor a name, gender and birthday, and a link refers to a film by title and
+// release date and to an actor by name and birthday.
+type CatalogRecord struct {
+	Type         string `json:"type,omitempty"`
+	Title        string `json:"title,omitempty"`
+	Info         string `json:"info,omitempty"`
+	ReleaseDate  string `json:"release_date,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Gender       string `json:"gen,omitempty"`
+	Birthday     string `json:"birthday,omitempty"`
+	Role         string `json:"role,omitempty"`
+	Character    string `json:"character,omitempty"`
+	BillingOrder int    `json:"billing_order,omitempty"`
+	Source       string `json:"-"`
+}
+
+type Catalog struct {
+	Films  []CatalogRecord `json:"films"`
+	Actors []CatalogRecord `json:"actors"`
+	Links  []CatalogRecord `json:"links"`
+}
+
+type ImportError struct {
+	Source  string `json:"source"`
+	Message string `json:"message"`
+}
+
+type ImportReport struct {
+	DryRun        bool          `json:"dry_run"`
+	Applied       bool          `json:"applied"`
+	FilmsCreated  int           `json:"films_created"`
+	FilmsMatched  int           `json:"films_matched"`
+	ActorsCreated int           `json:"actors_created"`
+	ActorsMatched int           `json:"actors_matched"`
+	LinksCreated  int           `json:"links_created"`
+	LinksExisting int           `json:"links_existing"`
+	Errors        []ImportError `json:"errors"`
+}
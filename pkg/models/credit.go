@@ -0,0 +1,22 @@
+package models
+
+type CreditRequest struct {
+	ActorId      uint64 `json:"actor_id"`
+	Role         string `json:"role"`
+	Character    string `json:"character"`
+	BillingOrder int    `json:"billing_order"`
+}
+
+type FilmCreditItem struct {
+	ActorItem
+	Role         string `json:"role"`
+	Character    string `json:"character"`
+	BillingOrder int    `json:"billing_order"`
+}
+
+type ActorCreditItem struct {
+	FilmItem
+	Role         string `json:"role"`
+	Character    string `json:"character"`
+	BillingOrder int    `json:"billing_order"`
+}
@@ -1,9 +1,36 @@
 package models
 
+import "time"
+
 type FilmItem struct {
-	Id          uint64  `json:"id"`
-	Title       string  `json:"title"`
-	Info        string  `json:"info"`
-	Rating      float64 `json:"rating"`
-	ReleaseDate string  `json:"release_date"`
+	Id          uint64     `json:"id"`
+	Title       string     `json:"title"`
+	Info        string     `json:"info"`
+	Rating      float64    `json:"rating"`
+	Votes       uint64     `json:"votes"`
+	ReleaseDate string     `json:"release_date"`
+	Snippet     string     `json:"snippet,omitempty"`
+	InFavorites *bool      `json:"in_favorites,omitempty"`
+	InWatchlist *bool      `json:"in_watchlist,omitempty"`
+	Poster      *ImageUrls `json:"poster,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// SimilarFilmItem is a film ranked by what it has in common with another one,
+// the higher the score the closer it is.
+type SimilarFilmItem struct {
+	FilmItem
+	SharedActors int     `json:"shared_actors"`
+	SharedGenres int     `json:"shared_genres"`
+	Score        float64 `json:"score"`
+}
+
+// RecommendedFilmItem is a film recommended to a user. Source tells whether
+// it was predicted from the ratings of the user or is one of the popular
+// films, Score is the predicted rating above the usual one of the user or
+// the weighted rating of the film respectively.
+type RecommendedFilmItem struct {
+	FilmItem
+	Score  float64 `json:"score"`
+	Source string  `json:"source"`
 }
@@ -0,0 +1,67 @@
+package models
+
+type FranchiseItem struct {
+	Id         uint64 `json:"id"`
+	Name       string `json:"name"`
+	Info       string `json:"info"`
+	FilmsCount int    `json:"films_count"`
+}
+
+// FranchiseRequest adds or changes a franchise. On update the films replace
+// the current ones unless they are left out.
+type FranchiseRequest struct {
+	Id    uint64                 `json:"id"`
+	Name  string                 `json:"name"`
+	Info  string                 `json:"info"`
+	Films []FranchiseFilmRequest `json:"films"`
+}
+
+type FranchiseFilmRequest struct {
+	FilmId   uint64 `json:"film_id"`
+	Position int    `json:"position"`
+}
+
+type FranchiseFilmItem struct {
+	FilmItem
+	Position int `json:"position"`
+}
+
+type FranchiseResponse struct {
+	Id    uint64              `json:"id"`
+	Name  string              `json:"name"`
+	Info  string              `json:"info"`
+	Order string              `json:"order"`
+	Films []FranchiseFilmItem `json:"films"`
+}
+
+type FranchisesResponse struct {
+	Total      int             `json:"total"`
+	Page       uint64          `json:"page"`
+	PerPage    uint64          `json:"per_page"`
+	Franchises []FranchiseItem `json:"franchises"`
+}
+
+// FilmFranchiseItem is a franchise in the film details with the films that
+// come before and after the film in the story, nil at either end.
+type FilmFranchiseItem struct {
+	Id       uint64    `json:"id"`
+	Name     string    `json:"name"`
+	Position int       `json:"position"`
+	Previous *FilmItem `json:"previous"`
+	Next     *FilmItem `json:"next"`
+}
+
+// FilmRelationRequest links two films, it reads as "the related film is a
+// <kind> of the film".
+type FilmRelationRequest struct {
+	FilmId    uint64 `json:"film_id"`
+	RelatedId uint64 `json:"related_id"`
+	Kind      string `json:"kind"`
+}
+
+// FilmRelationItem is a film related to the film in the details, Kind tells
+// what Film is to it.
+type FilmRelationItem struct {
+	Kind string   `json:"kind"`
+	Film FilmItem `json:"film"`
+}
@@ -0,0 +1,6 @@
+package models
+
+type GenreItem struct {
+	Id   uint64 `json:"id"`
+	Name string `json:"name"`
+}
@@ -0,0 +1,41 @@
+package models
+
+// CastLink is an actor credited in a film, an edge of the collaboration graph.
+type CastLink struct {
+	ActorId uint64
+	FilmId  uint64
+}
+
+type GraphActor struct {
+	Id   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+type GraphFilm struct {
+	Id    uint64 `json:"id"`
+	Title string `json:"title"`
+}
+
+// ActorPathStep is an actor of a co-star path and the film they share with
+// the next actor, nil for the last one.
+type ActorPathStep struct {
+	Actor GraphActor `json:"actor"`
+	Film  *GraphFilm `json:"film,omitempty"`
+}
+
+// ActorPathResponse is a shortest chain of co-stars between two actors.
+// Degrees is the number of films in it, the path is empty when the actors
+// are not connected.
+type ActorPathResponse struct {
+	Connected bool            `json:"connected"`
+	Degrees   int             `json:"degrees"`
+	Path      []ActorPathStep `json:"path"`
+}
+
+// CollaboratorItem is an actor who played with another one, Films counts the
+// films they share.
+type CollaboratorItem struct {
+	Id    uint64 `json:"id"`
+	Name  string `json:"name"`
+	Films int    `json:"films"`
+}
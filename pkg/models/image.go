@@ -0,0 +1,20 @@
+package models
+
+import (
+	"io"
+	"time"
+)
+
+// ImageUrls are the addresses of an uploaded image and of its thumbnails.
+type ImageUrls struct {
+	Original string `json:"original"`
+	Small    string `json:"small"`
+	Medium   string `json:"medium"`
+	Large    string `json:"large"`
+}
+
+// StoredFile is a file opened for reading from the image storage.
+type StoredFile struct {
+	io.ReadSeekCloser
+	ModTime time.Time
+}
@@ -0,0 +1,10 @@
+package models
+
+// Cursor marks the row a keyset page continues from. Values holds the sort
+// key of that row as text, ending with its id, and Sort names the order it was
+// issued for. A backward cursor reads the page that precedes the row.
+type Cursor struct {
+	Sort     string   `json:"s"`
+	Values   []string `json:"v"`
+	Backward bool     `json:"b,omitempty"`
+}
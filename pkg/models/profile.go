@@ -6,3 +6,18 @@ type UserItem struct {
 	Password string `json:"password"`
 	Role     string `json:"role"`
 }
+
+// ProfileItem is a profile without credentials, safe to show and to log.
+type ProfileItem struct {
+	Id    uint64 `json:"id"`
+	Login string `json:"login"`
+	Role  string `json:"role"`
+}
+
+// UserCredentials is a user with the stored password hash and the algorithm
+// it was made with, used only to check a password at signin.
+type UserCredentials struct {
+	User UserItem
+	Hash []byte
+	Algo string
+}
@@ -1,13 +1,33 @@
 package models
 
+import "time"
+
 type Response struct {
 	Status int `json:"status"`
 	Body   any `json:"body"`
 }
 
 type FilmsResponse struct {
-	Total int         `json:"total"`
-	Films *[]FilmItem `json:"films"`
+	Total   int         `json:"total"`
+	Page    uint64      `json:"page,omitempty"`
+	PerPage uint64      `json:"per_page"`
+	Next    string      `json:"next,omitempty"`
+	Prev    string      `json:"prev,omitempty"`
+	Films   *[]FilmItem `json:"films"`
+}
+
+type ActorsResponse struct {
+	Total   int             `json:"total"`
+	Page    uint64          `json:"page,omitempty"`
+	PerPage uint64          `json:"per_page"`
+	Next    string          `json:"next,omitempty"`
+	Prev    string          `json:"prev,omitempty"`
+	Actors  []ActorResponse `json:"actors"`
+}
+
+type FilmListRequest struct {
+	FilmId uint64 `json:"film_id"`
+	List   string `json:"list"`
 }
 
 type SigninRequest struct {
@@ -21,23 +41,55 @@ type SignupRequest struct {
 
 type AuthCheckResponse struct {
 	Login string `json:"login"`
+	Role  string `json:"role"`
 }
 
 type FilmRequest struct {
-	Id          uint64   `json:"id"`
-	Title       string   `json:"title"`
-	Info        string   `json:"info"`
-	ReleaseDate string   `json:"release_date"`
-	Rating      float32  `json:"rating"`
-	Actors      []uint64 `json:"actors"`
+	Id          uint64          `json:"id"`
+	Title       string          `json:"title"`
+	Info        string          `json:"info"`
+	ReleaseDate string          `json:"release_date"`
+	Actors      []uint64        `json:"actors"`
+	Credits     []CreditRequest `json:"credits"`
+	Genres      []uint64        `json:"genres"`
+}
+
+type FilmResponse struct {
+	Id          uint64              `json:"id"`
+	Title       string              `json:"title"`
+	Info        string              `json:"info"`
+	Rating      float64             `json:"rating"`
+	Votes       uint64              `json:"votes"`
+	ReleaseDate string              `json:"release_date"`
+	Poster      *ImageUrls          `json:"poster,omitempty"`
+	Genres      []GenreItem         `json:"genres"`
+	Credits     []FilmCreditItem    `json:"credits"`
+	Franchises  []FilmFranchiseItem `json:"franchises,omitempty"`
+	Relations   []FilmRelationItem  `json:"relations,omitempty"`
+	DeletedAt   *time.Time          `json:"deleted_at,omitempty"`
+}
+
+type RatingRequest struct {
+	Rating int `json:"rating"`
+}
+
+type FilmRatingResponse struct {
+	FilmId     uint64  `json:"film_id"`
+	Rating     float64 `json:"rating"`
+	Votes      uint64  `json:"votes"`
+	UserRating int     `json:"user_rating"`
 }
 
 type ActorResponse struct {
-	Id       uint64     `json:"id"`
-	Name     string     `json:"name"`
-	Gender   string     `json:"gen"`
-	Birthday string     `json:"birthday"`
-	Films    []FilmItem `json:"films"`
+	Id         uint64            `json:"id"`
+	Name       string            `json:"name"`
+	Gender     string            `json:"gen"`
+	Birthday   string            `json:"birthday"`
+	Age        int               `json:"age"`
+	FilmsCount int               `json:"films_count"`
+	Photo      *ImageUrls        `json:"photo,omitempty"`
+	Films      []ActorCreditItem `json:"films"`
+	DeletedAt  *time.Time        `json:"deleted_at,omitempty"`
 }
 
 type ActorRequest struct {
@@ -49,13 +101,29 @@ type ActorRequest struct {
 }
 
 type FindFilmRequest struct {
-	Title           string  `json:"title"`
-	RatingFrom      float32 `json:"rating_from"`
-	RatingTo        float32 `json:"rating_to"`
-	ReleaseDateFrom string  `json:"release_date_from"`
-	ReleaseDateTo   string  `json:"release_date_to"`
-	Actor           string  `json:"actor"`
-	Page            uint64  `json:"page"`
-	PerPage         uint64  `json:"per_page"`
-	Order           string  `json:"order"`
+	Title           string   `json:"title"`
+	RatingFrom      float32  `json:"rating_from"`
+	RatingTo        float32  `json:"rating_to"`
+	ReleaseDateFrom string   `json:"release_date_from"`
+	ReleaseDateTo   string   `json:"release_date_to"`
+	Actor           string   `json:"actor"`
+	Director        string   `json:"director"`
+	Genres          []uint64 `json:"genres"`
+	Page            uint64   `json:"page"`
+	PerPage         uint64   `json:"per_page"`
+	Order           string   `json:"order"`
+	Cursor          *Cursor  `json:"-"`
+}
+
+type FindActorRequest struct {
+	Name          string  `json:"name"`
+	Gender        string  `json:"gen"`
+	BirthdateFrom string  `json:"birthdate_from"`
+	BirthdateTo   string  `json:"birthdate_to"`
+	FilmId        uint64  `json:"film_id"`
+	Sort          string  `json:"sort"`
+	Desc          bool    `json:"desc"`
+	Page          uint64  `json:"page"`
+	PerPage       uint64  `json:"per_page"`
+	Cursor        *Cursor `json:"-"`
 }
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+type ReviewItem struct {
+	Id        uint64     `json:"id"`
+	FilmId    uint64     `json:"film_id"`
+	AuthorId  uint64     `json:"author_id"`
+	Author    string     `json:"author"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	Spoiler   bool       `json:"spoiler"`
+	Hidden    bool       `json:"hidden"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+}
+
+type ReviewRequest struct {
+	Id      uint64 `json:"id"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	Spoiler bool   `json:"spoiler"`
+}
+
+type HideReviewRequest struct {
+	Id     uint64 `json:"id"`
+	Hidden bool   `json:"hidden"`
+}
+
+type ReviewsResponse struct {
+	Total   uint64       `json:"total"`
+	Page    uint64       `json:"page"`
+	PerPage uint64       `json:"per_page"`
+	Reviews []ReviewItem `json:"reviews"`
+}
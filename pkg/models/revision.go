@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// FilmRevisionData is the state of a film kept in a revision.
+type FilmRevisionData struct {
+	Title       string          `json:"title"`
+	Info        string          `json:"info"`
+	ReleaseDate string          `json:"release_date"`
+	Genres      []uint64        `json:"genres"`
+	Credits     []CreditRequest `json:"credits"`
+}
+
+// ActorRevisionData is the state of an actor kept in a revision.
+type ActorRevisionData struct {
+	Name     string                `json:"name"`
+	Gender   string                `json:"gen"`
+	Birthday string                `json:"birthday"`
+	Credits  []ActorRevisionCredit `json:"credits"`
+}
+
+type ActorRevisionCredit struct {
+	FilmId       uint64 `json:"film_id"`
+	Role         string `json:"role"`
+	Character    string `json:"character"`
+	BillingOrder int    `json:"billing_order"`
+}
+
+// FieldChange is a top-level field of a revision that differs from the
+// previous revision, Before is null for the first one.
+type FieldChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before" swaggertype:"object"`
+	After  json.RawMessage `json:"after" swaggertype:"object"`
+}
+
+type Revision struct {
+	Revision  uint64          `json:"revision"`
+	UserId    uint64          `json:"user_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data" swaggertype:"object"`
+	Previous  json.RawMessage `json:"-"`
+	Changes   []FieldChange   `json:"changes"`
+}
+
+type RevisionsResponse struct {
+	Total     int        `json:"total"`
+	Page      uint64     `json:"page"`
+	PerPage   uint64     `json:"per_page"`
+	Revisions []Revision `json:"revisions"`
+}
@@ -2,8 +2,31 @@ package models
 
 import "time"
 
+// Session is the record kept in Redis for a signed in client. It carries the
+// user id and role, so requests are authorised without asking Postgres.
 type Session struct {
-	Login     string
-	SID       string
-	ExpiresAt time.Time
+	SID        string    `json:"-"`
+	UserId     uint64    `json:"user_id"`
+	Login      string    `json:"login"`
+	Role       string    `json:"role"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Version is the session version of the user at signin, the session is
+	// void once the version of the user is past it.
+	Version uint64 `json:"version"`
+}
+
+// SessionItem is a session as shown to its user or an admin. Id is derived
+// from the session id but cannot be used in its place.
+type SessionItem struct {
+	Id         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
 }
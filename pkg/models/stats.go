@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// StatsRequest narrows the catalog statistics. From and To bound when films
+// and actors were added, ReleaseFrom and ReleaseTo the release dates of the
+// films, all of them are optional. Lists of actors and films hold up to Limit
+// entries.
+type StatsRequest struct {
+	From        *time.Time
+	To          *time.Time
+	ReleaseFrom *time.Time
+	ReleaseTo   *time.Time
+	Interval    string
+	Limit       uint64
+}
+
+type StatsResponse struct {
+	Films              int               `json:"films"`
+	Actors             int               `json:"actors"`
+	FilmsByYear        []YearStatsItem   `json:"films_by_year"`
+	FilmsByRating      []RatingStatsItem `json:"films_by_rating"`
+	TopRatedActors     []ActorStatsItem  `json:"top_rated_actors"`
+	ProlificActors     []ActorStatsItem  `json:"prolific_actors"`
+	ActorsWithoutFilms EntityStatsList   `json:"actors_without_films"`
+	FilmsWithoutCast   EntityStatsList   `json:"films_without_cast"`
+	Growth             []GrowthStatsItem `json:"growth"`
+}
+
+type YearStatsItem struct {
+	Year  int `json:"year"`
+	Films int `json:"films"`
+}
+
+// RatingStatsItem counts the films rated from Min up to but not including
+// Max, 10 included in the last bucket. Films without votes have both zero.
+type RatingStatsItem struct {
+	Min   int `json:"min"`
+	Max   int `json:"max"`
+	Films int `json:"films"`
+}
+
+// ActorStatsItem is an actor with the number of their films and the average
+// rating of those of them that have votes.
+type ActorStatsItem struct {
+	Id            uint64  `json:"id"`
+	Name          string  `json:"name"`
+	Films         int     `json:"films"`
+	AverageRating float64 `json:"average_rating"`
+}
+
+type EntityStatsItem struct {
+	Id   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+type EntityStatsList struct {
+	Total int               `json:"total"`
+	Items []EntityStatsItem `json:"items"`
+}
+
+// GrowthStatsItem is the number of films and actors added in the period that
+// starts at Period, and their totals at its end.
+type GrowthStatsItem struct {
+	Period      time.Time `json:"period"`
+	Films       int       `json:"films"`
+	Actors      int       `json:"actors"`
+	FilmsTotal  int       `json:"films_total"`
+	ActorsTotal int       `json:"actors_total"`
+}
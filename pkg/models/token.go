@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// AccessTokenRequest names a new personal access token and lists its scopes.
+type AccessTokenRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+type AccessTokenItem struct {
+	Id         uint64     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// AccessTokenResponse is a created token. Only its hash is stored, so Token is
+// shown this once.
+type AccessTokenResponse struct {
+	AccessTokenItem
+	Token string `json:"token"`
+}
+
+// AccessToken is the user a request authorised by a token acts as, with the
+// scopes the token was given.
+type AccessToken struct {
+	Id     uint64
+	UserId uint64
+	Login  string
+	Role   string
+	Scopes []string
+}
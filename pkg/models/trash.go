@@ -0,0 +1,13 @@
+package models
+
+type PurgeReport struct {
+	Films  int64 `json:"films"`
+	Actors int64 `json:"actors"`
+	// PurgedFilms and PurgedActors are the removed records as they were in
+	// the trash, recorded in the audit log.
+	PurgedFilms  []FilmItem      `json:"-"`
+	PurgedActors []ActorResponse `json:"-"`
+	// Images are the names of the posters and photos of the purged records,
+	// by image directory, whose files are to be removed from the storage.
+	Images map[string][]string `json:"-"`
+}
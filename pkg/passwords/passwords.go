@@ -0,0 +1,150 @@
+package passwords
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"runtime"
+	"strings"
+)
+
+// Algorithms passwords are stored with, kept in profile.password_algo.
+// SHA-512 without salt is only verified, so that the passwords of old
+// accounts can be upgraded when they sign in.
+const (
+	AlgoArgon2id = "argon2id"
+	AlgoSha512   = "sha512"
+)
+
+// Argon2id parameters of new hashes, following RFC 9106. Hashes made with
+// other parameters are still verified and reported for rehashing.
+const (
+	argonMemory  = 64 * 1024
+	argonTime    = 3
+	argonThreads = 2
+	argonKeyLen  = 32
+	argonSaltLen = 16
+)
+
+var ErrFormat = errors.New("unknown password hash format")
+
+// params are the argon2id parameters a hash was made with.
+type params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+var current = params{memory: argonMemory, time: argonTime, threads: argonThreads}
+
+// slots bounds how many argon2id keys are derived at once. Each takes
+// argonMemory KiB, and signin and signup need no authentication, so without
+// it a burst of requests could take all the memory of the service.
+var slots = make(chan struct{}, runtime.GOMAXPROCS(0))
+
+// idKey derives an argon2id key once a slot is free.
+func idKey(password []byte, salt []byte, p params, keyLen uint32) []byte {
+	slots <- struct{}{}
+	defer func() { <-slots }()
+
+	return argon2.IDKey(password, salt, p.time, p.memory, p.threads, keyLen)
+}
+
+// Hash hashes password with argon2id and a random salt. The result is in the
+// PHC string format, e.g. $argon2id$v=19$m=65536,t=3,p=2$salt$hash, and goes
+// with AlgoArgon2id.
+func Hash(password string) ([]byte, error) {
+	salt := make([]byte, argonSaltLen)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, fmt.Errorf("password salt error: %s", err.Error())
+	}
+
+	key := idKey([]byte(password), salt, current, argonKeyLen)
+
+	return []byte(fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, current.memory, current.time,
+		current.threads, base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))), nil
+}
+
+// Verify reports whether password matches hash stored with algo. Rehash is
+// true for a match whose hash should be replaced by a new one from Hash,
+// either for being legacy SHA-512 or for outdated argon2id parameters.
+func Verify(password string, hash []byte, algo string) (match bool, rehash bool, err error) {
+	switch algo {
+	case AlgoSha512:
+		sum := sha512.Sum512([]byte(password))
+		return subtle.ConstantTimeCompare(sum[:], hash) == 1, true, nil
+	case AlgoArgon2id:
+		hashParams, salt, key, err := decode(hash)
+		if err != nil {
+			return false, false, err
+		}
+
+		other := idKey([]byte(password), salt, hashParams, uint32(len(key)))
+		match = subtle.ConstantTimeCompare(key, other) == 1
+
+		return match, match && hashParams != current, nil
+	default:
+		return false, false, fmt.Errorf("unknown password algorithm %s", algo)
+	}
+}
+
+// dummy is a hash of a random password made at start, verified when there is
+// no account to check so that an unknown login takes as long to reject as a
+// wrong password.
+var dummy []byte
+
+func init() {
+	password := make([]byte, argonSaltLen)
+	_, err := rand.Read(password)
+	if err != nil {
+		panic(fmt.Sprintf("password dummy error: %s", err.Error()))
+	}
+
+	dummy, err = Hash(base64.RawStdEncoding.EncodeToString(password))
+	if err != nil {
+		panic(fmt.Sprintf("password dummy error: %s", err.Error()))
+	}
+}
+
+// Waste verifies password against a fixed hash and throws the result away.
+func Waste(password string) {
+	_, _, _ = Verify(password, dummy, AlgoArgon2id)
+}
+
+// decode splits a PHC string made by Hash into its parameters, salt and key.
+func decode(hash []byte) (params, []byte, []byte, error) {
+	var p params
+	var version int
+
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != AlgoArgon2id {
+		return p, nil, nil, ErrFormat
+	}
+
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return p, nil, nil, ErrFormat
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads)
+	if err != nil || p.memory == 0 || p.time == 0 || p.threads == 0 {
+		return p, nil, nil, ErrFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrFormat
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, ErrFormat
+	}
+
+	return p, salt, key, nil
+}
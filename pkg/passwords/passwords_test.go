@@ -0,0 +1,128 @@
+package passwords
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"testing"
+)
+
+func TestHashVerify(t *testing.T) {
+	hash, err := Hash("secret")
+	if err != nil {
+		t.Fatalf("hash error: %s", err.Error())
+	}
+
+	other, err := Hash("secret")
+	if err != nil {
+		t.Fatalf("hash error: %s", err.Error())
+	}
+	if string(hash) == string(other) {
+		t.Errorf("two hashes of the same password are equal, the salt is not random")
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		match    bool
+	}{
+		{name: "right password", password: "secret", match: true},
+		{name: "wrong password", password: "Secret", match: false},
+		{name: "empty password", password: "", match: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			match, rehash, err := Verify(test.password, hash, AlgoArgon2id)
+			if err != nil {
+				t.Fatalf("verify error: %s", err.Error())
+			}
+			if match != test.match {
+				t.Errorf("match = %v, want %v", match, test.match)
+			}
+			if rehash {
+				t.Errorf("rehash = true for a hash with the current parameters")
+			}
+		})
+	}
+}
+
+func TestVerifyRehash(t *testing.T) {
+	legacy := sha512.Sum512([]byte("secret"))
+
+	salt := []byte("0123456789abcdef")
+	outdated := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, 1024, 1, 1,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(argon2.IDKey([]byte("secret"), salt, 1, 1024, 1, argonKeyLen)))
+
+	tests := []struct {
+		name     string
+		password string
+		hash     []byte
+		algo     string
+		match    bool
+		rehash   bool
+	}{
+		{name: "legacy sha512", password: "secret", hash: legacy[:], algo: AlgoSha512, match: true, rehash: true},
+		{name: "legacy sha512 wrong password", password: "other", hash: legacy[:], algo: AlgoSha512, match: false, rehash: true},
+		{name: "outdated parameters", password: "secret", hash: []byte(outdated), algo: AlgoArgon2id, match: true, rehash: true},
+		{name: "outdated parameters wrong password", password: "other", hash: []byte(outdated), algo: AlgoArgon2id, match: false, rehash: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			match, rehash, err := Verify(test.password, test.hash, test.algo)
+			if err != nil {
+				t.Fatalf("verify error: %s", err.Error())
+			}
+			if match != test.match || rehash != test.rehash {
+				t.Errorf("match, rehash = %v, %v, want %v, %v", match, rehash, test.match, test.rehash)
+			}
+		})
+	}
+}
+
+func TestVerifyRehashRoundTrip(t *testing.T) {
+	legacy := sha512.Sum512([]byte("secret"))
+
+	match, rehash, err := Verify("secret", legacy[:], AlgoSha512)
+	if err != nil || !match || !rehash {
+		t.Fatalf("legacy verify = %v, %v, %v", match, rehash, err)
+	}
+
+	hash, err := Hash("secret")
+	if err != nil {
+		t.Fatalf("hash error: %s", err.Error())
+	}
+
+	match, rehash, err = Verify("secret", hash, AlgoArgon2id)
+	if err != nil || !match || rehash {
+		t.Errorf("upgraded verify = %v, %v, %v, want true, false, nil", match, rehash, err)
+	}
+}
+
+func TestVerifyMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		hash string
+		algo string
+	}{
+		{name: "unknown algorithm", hash: "x", algo: "md5"},
+		{name: "not a phc string", hash: "secret", algo: AlgoArgon2id},
+		{name: "other algorithm in phc string", hash: "$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$a2V5", algo: AlgoArgon2id},
+		{name: "other version", hash: "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5", algo: AlgoArgon2id},
+		{name: "zero parameters", hash: "$argon2id$v=19$m=0,t=1,p=1$c2FsdA$a2V5", algo: AlgoArgon2id},
+		{name: "bad salt", hash: "$argon2id$v=19$m=1024,t=1,p=1$!!$a2V5", algo: AlgoArgon2id},
+		{name: "empty key", hash: "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$", algo: AlgoArgon2id},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			match, _, err := Verify("secret", []byte(test.hash), test.algo)
+			if err == nil || match {
+				t.Errorf("verify = %v, %v, want an error", match, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// Every runs job once per interval until ctx is done. A failed run is logged
+// and retried on the next tick, so one error does not stop the job.
+func Every(ctx context.Context, interval time.Duration, name string, job func(ctx context.Context) error, log *logrus.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := job(ctx)
+			if err != nil {
+				log.Errorf("%s job error: %s", name, err.Error())
+			}
+		}
+	}
+}
@@ -1,41 +1,223 @@
 package utils
 
 import (
-	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"filmoteka/pkg/models"
 	"fmt"
 	"github.com/sirupsen/logrus"
-	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 	"unicode/utf8"
 )
 
-var letterRunes = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+const (
+	CreditRoleActor    = "actor"
+	CreditRoleDirector = "director"
+	CreditRoleWriter   = "writer"
+	CreditRoleProducer = "producer"
+	CreditRoleComposer = "composer"
+)
+
+var CreditRoles = []string{CreditRoleActor, CreditRoleDirector, CreditRoleWriter, CreditRoleProducer, CreditRoleComposer}
+
+const (
+	FilmListFavorite  = "favorite"
+	FilmListWatchlist = "watchlist"
+)
+
+var FilmLists = []string{FilmListFavorite, FilmListWatchlist}
+
+const (
+	ActorSortName      = "name"
+	ActorSortBirthdate = "birthdate"
+	ActorSortFilms     = "films"
+)
+
+var ActorSorts = []string{ActorSortName, ActorSortBirthdate, ActorSortFilms}
+
+const (
+	FilmSortRating      = "rating"
+	FilmSortTitle       = "title"
+	FilmSortReleaseDate = "release_date"
+	FilmSortRelevance   = "relevance"
+)
+
+// Types of the values of a keyset cursor, which the database casts them back to.
+const (
+	CursorText    = "text"
+	CursorInteger = "integer"
+	CursorBigint  = "bigint"
+	CursorReal    = "real"
+	CursorFloat   = "float8"
+	CursorDate    = "date"
+)
+
+// FilmCursorTypes and ActorCursorTypes hold the types of the cursor values of
+// each film and actor sort, in the order of the sort key columns.
+var FilmCursorTypes = map[string][]string{
+	FilmSortRating:      {CursorFloat, CursorInteger, CursorInteger},
+	FilmSortTitle:       {CursorText, CursorInteger},
+	FilmSortReleaseDate: {CursorDate, CursorInteger},
+	FilmSortRelevance:   {CursorReal, CursorFloat, CursorInteger},
+}
+
+var ActorCursorTypes = map[string][]string{
+	ActorSortName:      {CursorText, CursorInteger},
+	ActorSortBirthdate: {CursorDate, CursorInteger},
+	ActorSortFilms:     {CursorBigint, CursorInteger},
+}
+
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+const (
+	FranchiseOrderChronology = "chronology"
+	FranchiseOrderRelease    = "release"
+)
+
+var FranchiseOrders = []string{FranchiseOrderChronology, FranchiseOrderRelease}
+
+const (
+	FilmRelationSequel   = "sequel"
+	FilmRelationPrequel  = "prequel"
+	FilmRelationRemake   = "remake"
+	FilmRelationSpinOff  = "spin_off"
+	FilmRelationOriginal = "original"
+)
+
+var FilmRelationKinds = []string{FilmRelationSequel, FilmRelationPrequel, FilmRelationRemake, FilmRelationSpinOff}
+
+// FilmRelationInverse gives what the film is to the related one for each
+// kind of relation, e.g. a film is the original of its remake.
+var FilmRelationInverse = map[string]string{
+	FilmRelationSequel:  FilmRelationPrequel,
+	FilmRelationPrequel: FilmRelationSequel,
+	FilmRelationRemake:  FilmRelationOriginal,
+	FilmRelationSpinOff: FilmRelationOriginal,
+}
+
+// Names of the catalog_version counters of cached data derived from the catalog.
+const (
+	CatalogVersionSimilarFilms = "similar_films"
+	CatalogVersionCastGraph    = "cast_graph"
+)
+
+const (
+	StatsIntervalDay   = "day"
+	StatsIntervalWeek  = "week"
+	StatsIntervalMonth = "month"
+	StatsIntervalYear  = "year"
+)
+
+var StatsIntervals = []string{StatsIntervalDay, StatsIntervalWeek, StatsIntervalMonth, StatsIntervalYear}
+
+const (
+	RecommendationSourceRatings = "ratings"
+	RecommendationSourcePopular = "popular"
+)
+
+// ContextKey is the type of the request scoped values put into the context by the middleware.
+type ContextKey string
+
+const (
+	UserIDKey    ContextKey = "userId"
+	RoleKey      ContextKey = "role"
+	SessionKey   ContextKey = "session"
+	TokenKey     ContextKey = "token"
+	ScopesKey    ContextKey = "scopes"
+	RequestIDKey ContextKey = "requestId"
+	ClientIPKey  ContextKey = "clientIp"
+)
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+var Roles = []string{RoleUser, RoleAdmin}
+
+// ErrLastAdmin is returned for a role change that would leave no admin.
+var ErrLastAdmin = errors.New(LastAdminError)
+
+// ErrUnknownId is returned for a film whose genres or credits name an actor
+// or genre that does not exist.
+var ErrUnknownId = errors.New(UnknownIdError)
+
+// ErrGenreExists is returned for renaming a genre to the name of another one.
+var ErrGenreExists = errors.New(GenreExistsError)
+
+// Scopes of personal access tokens. Read allows GET requests, the write scopes
+// allow changes to films and actors, admin allows everything the user can do.
+const (
+	TokenScopeRead        = "read"
+	TokenScopeWriteFilms  = "write:films"
+	TokenScopeWriteActors = "write:actors"
+	TokenScopeAdmin       = "admin"
+)
+
+var TokenScopes = []string{TokenScopeRead, TokenScopeWriteFilms, TokenScopeWriteActors, TokenScopeAdmin}
+
+// TokenPrefix starts every personal access token, so a leaked one is easy to
+// recognise.
+const TokenPrefix = "flm_"
+
+const (
+	AuditActionAdd      = "add"
+	AuditActionUpdate   = "update"
+	AuditActionDelete   = "delete"
+	AuditActionRestore  = "restore"
+	AuditActionImport   = "import"
+	AuditActionRollback = "rollback"
+	AuditActionPurge    = "purge"
+)
+
+var AuditActions = []string{AuditActionAdd, AuditActionUpdate, AuditActionDelete, AuditActionRestore, AuditActionImport, AuditActionRollback, AuditActionPurge}
+
+const (
+	AuditEntityFilm      = "film"
+	AuditEntityActor     = "actor"
+	AuditEntityProfile   = "profile"
+	AuditEntityCatalog   = "catalog"
+	AuditEntityFranchise = "franchise"
+	AuditEntityRelation  = "film_relation"
+	AuditEntityToken     = "access_token"
+	AuditEntityGenre     = "genre"
+)
+
+var AuditEntities = []string{AuditEntityFilm, AuditEntityActor, AuditEntityProfile, AuditEntityCatalog, AuditEntityFranchise, AuditEntityRelation, AuditEntityToken, AuditEntityGenre}
 
 const (
 	FilmTitleBegin       = 1
 	FilmTitleEnd         = 150
 	FilmDescriptionBegin = 1
 	FilmDescriptionEnd   = 1000
-	FilmRatingBegin      = 0
-	FilmRatingEnd        = 10
+	VoteBegin            = 1
+	VoteEnd              = 10
+	ReviewTitleBegin     = 1
+	ReviewTitleEnd       = 150
+	ReviewBodyBegin      = 1
+	ReviewBodyEnd        = 10000
 	ActorNameBegin       = 1
 	ActorNameEnd         = 150
+	GenreNameBegin       = 1
+	GenreNameEnd         = 50
+	FranchiseNameBegin   = 1
+	FranchiseNameEnd     = 150
+	TokenNameBegin       = 1
+	TokenNameEnd         = 100
+	CatalogImportMaxSize = 64 << 20
+	ImageUploadMaxSize   = 10 << 20
+	ImageMaxDimension    = 10000
+	FilmsTopLimit        = 10
 	MaxRetries           = 3
 )
 
-func HashPassword(password string) []byte {
-	hashPassword := sha512.Sum512([]byte(password))
-	passwordByteSlice := hashPassword[:]
-	return passwordByteSlice
-}
-
-func RandStringRunes(seed int) string {
-	symbols := make([]rune, seed)
-	for i := range symbols {
-		symbols[i] = letterRunes[rand.Intn(len(letterRunes))]
-	}
-	return string(symbols)
-}
-
 func ValidateStringSize(validatedString string, begin int, end int, validateError string, logger *logrus.Logger) error {
 	validateStringLength := utf8.RuneCountInString(validatedString)
 	if validateStringLength > end || validateStringLength < begin {
@@ -45,6 +227,145 @@ func ValidateStringSize(validatedString string, begin int, end int, validateErro
 	return nil
 }
 
+// ErrPathId is returned by ParsePathId for a path whose id is not a number.
+var ErrPathId = errors.New("path id is not a number")
+
+// ParsePathId extracts the numeric id that follows prefix in path and returns
+// it together with the rest of the path, e.g. "/api/v1/films/5/reviews" with
+// prefix "/api/v1/films/" gives 5 and "reviews". The error wraps ErrPathId
+// when there is an id but it is not a number.
+func ParsePathId(path string, prefix string) (uint64, string, error) {
+	rest, found := strings.CutPrefix(path, prefix)
+	if !found {
+		return 0, "", fmt.Errorf("path %s has no prefix %s", path, prefix)
+	}
+
+	idPart, action, _ := strings.Cut(strings.Trim(rest, "/"), "/")
+	if idPart == "" {
+		return 0, "", fmt.Errorf("path %s has no id", path)
+	}
+
+	id, err := strconv.ParseUint(idPart, 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("%w: %s", ErrPathId, err.Error())
+	}
+
+	return id, action, nil
+}
+
+// ParseIdList parses a comma separated list of ids such as "1,4,7".
+func ParseIdList(value string) ([]uint64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(value, ",")
+	ids := make([]uint64, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse id list error: %s", err.Error())
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// PageOffset converts a page number counted from 1 into a row offset.
+func PageOffset(page uint64, perPage uint64) uint64 {
+	if page < 1 {
+		return 0
+	}
+
+	return (page - 1) * perPage
+}
+
+// EncodeCursor packs cursor into the opaque token handed out as next or prev.
+func EncodeCursor(cursor *models.Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor unpacks a token made by EncodeCursor.
+func DecodeCursor(token string) (*models.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("decode cursor error: %s", err.Error())
+	}
+
+	cursor := &models.Cursor{}
+	err = json.Unmarshal(data, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("decode cursor error: %s", err.Error())
+	}
+	if cursor.Sort == "" || len(cursor.Values) == 0 {
+		return nil, fmt.Errorf("decode cursor error: empty cursor")
+	}
+
+	return cursor, nil
+}
+
+var cursorNumber = regexp.MustCompile(`^[+-]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+
+// ValidateCursor checks that the values of cursor are as many as types and
+// each can be read back as its type, so that a forged or outdated cursor is
+// rejected before it reaches the database.
+func ValidateCursor(cursor *models.Cursor, types []string) error {
+	if len(cursor.Values) != len(types) {
+		return fmt.Errorf("cursor of %s has %d values, want %d", cursor.Sort, len(cursor.Values), len(types))
+	}
+
+	for i, value := range cursor.Values {
+		var err error
+
+		switch types[i] {
+		case CursorText:
+			if !utf8.ValidString(value) || strings.ContainsRune(value, 0) {
+				err = fmt.Errorf("invalid text")
+			}
+		case CursorInteger:
+			_, err = strconv.ParseInt(value, 10, 32)
+		case CursorBigint:
+			_, err = strconv.ParseInt(value, 10, 64)
+		case CursorReal:
+			err = parseCursorFloat(value, 32)
+		case CursorFloat:
+			err = parseCursorFloat(value, 64)
+		case CursorDate:
+			_, err = time.Parse("2006-01-02", value)
+		default:
+			err = fmt.Errorf("unknown type %s", types[i])
+		}
+
+		if err != nil {
+			return fmt.Errorf("cursor value %d of %s error: %s", i, cursor.Sort, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// parseCursorFloat accepts the decimal numbers the database reads as a float
+// of bitSize bits, without overflow or underflow.
+func parseCursorFloat(value string, bitSize int) error {
+	if !cursorNumber.MatchString(value) {
+		return fmt.Errorf("invalid number %q", value)
+	}
+
+	number, err := strconv.ParseFloat(value, bitSize)
+	if err != nil {
+		return err
+	}
+
+	mantissa, _, _ := strings.Cut(strings.ToLower(value), "e")
+	if number == 0 && strings.Trim(mantissa, "+-0.") != "" {
+		return fmt.Errorf("number %q out of range", value)
+	}
+
+	return nil
+}
+
 const (
 	InvalidEmailOrPasswordError     = "Invalid email or password"
 	SessionRepositoryNotActiveError = "Session repository not active"
@@ -53,10 +374,29 @@ const (
 	ProfileNotFoundError            = "Profile not found"
 	GetProfileError                 = "Get profile failed"
 	GetProfileRoleError             = "Get profile role failed"
-	RatingSizeError                 = "Rating must be from 0 to 10"
+	VoteSizeError                   = "Vote must be from 1 to 10"
 	TitleSizeError                  = "Title size must be from 1 to 150"
 	DescriptionSizeError            = "Description size must be from 1 to 1000"
 	FilmsListNotFoundError          = "Films list not found"
 	ActorNameSizeError              = "Actor name size must be from 1 to 150"
+	GenreNameSizeError              = "Genre name size must be from 1 to 50"
+	ReviewTitleSizeError            = "Review title size must be from 1 to 150"
+	ReviewBodySizeError             = "Review body size must be from 1 to 10000"
+	FilmListError                   = "List must be one of favorite, watchlist"
+	CreditRoleError                 = "Credit role must be one of actor, director, writer, producer, composer"
+	DateFormatError                 = "Date must be in YYYY-MM-DD format"
+	CatalogFormatError              = "Catalog format must be one of csv, json, ndjson"
+	ActorSortError                  = "Actor sort must be one of name, birthdate, films"
+	ImageContentTypeError           = "Image must be a JPEG, PNG or WebP file"
+	ImageDimensionError             = "Image width and height must be at most 10000 pixels"
+	FranchiseNameSizeError          = "Franchise name size must be from 1 to 150"
+	FranchiseOrderError             = "Franchise order must be one of chronology, release"
+	FilmRelationKindError           = "Relation kind must be one of sequel, prequel, remake, spin_off"
+	FilmRelationSelfError           = "Film cannot be related to itself"
+	TokenNameSizeError              = "Token name size must be from 1 to 100"
+	LastAdminError                  = "The last admin cannot be demoted"
+	UnknownIdError                  = "Actor or genre not found"
+	GenreExistsError                = "Genre with this name already exists"
+	StatsIntervalError              = "Interval must be one of day, week, month, year"
 	GrpcRecievError                 = "gRPC recieve error"
 )
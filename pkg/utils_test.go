@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"errors"
+	"filmoteka/pkg/models"
+	"testing"
+)
+
+func TestValidateCursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		types  []string
+		values []string
+		valid  bool
+	}{
+		{name: "rating", types: FilmCursorTypes[FilmSortRating], values: []string{"7.5", "120", "3"}, valid: true},
+		{name: "rating exponent", types: FilmCursorTypes[FilmSortRating], values: []string{"1e-05", "0", "3"}, valid: true},
+		{name: "relevance", types: FilmCursorTypes[FilmSortRelevance], values: []string{"0.0607927", "8", "3"}, valid: true},
+		{name: "title", types: FilmCursorTypes[FilmSortTitle], values: []string{"Начало", "3"}, valid: true},
+		{name: "release date", types: FilmCursorTypes[FilmSortReleaseDate], values: []string{"2010-07-16", "3"}, valid: true},
+		{name: "films", types: ActorCursorTypes[ActorSortFilms], values: []string{"4294967296", "3"}, valid: true},
+		{name: "too few values", types: FilmCursorTypes[FilmSortRating], values: []string{"7.5", "3"}},
+		{name: "too many values", types: FilmCursorTypes[FilmSortTitle], values: []string{"a", "3", "4"}},
+		{name: "text id", types: FilmCursorTypes[FilmSortTitle], values: []string{"a", "b"}},
+		{name: "integer overflow", types: FilmCursorTypes[FilmSortTitle], values: []string{"a", "2147483648"}},
+		{name: "float id", types: FilmCursorTypes[FilmSortTitle], values: []string{"a", "3.5"}},
+		{name: "nul in text", types: FilmCursorTypes[FilmSortTitle], values: []string{"a\x00b", "3"}},
+		{name: "invalid utf-8", types: FilmCursorTypes[FilmSortTitle], values: []string{"a\xffb", "3"}},
+		{name: "bad date", types: FilmCursorTypes[FilmSortReleaseDate], values: []string{"2010-13-40", "3"}},
+		{name: "hex float", types: FilmCursorTypes[FilmSortRating], values: []string{"0x1p-2", "1", "3"}},
+		{name: "not a number", types: FilmCursorTypes[FilmSortRating], values: []string{"NaN", "1", "3"}},
+		{name: "real overflow", types: FilmCursorTypes[FilmSortRelevance], values: []string{"1e39", "1", "3"}},
+		{name: "real underflow", types: FilmCursorTypes[FilmSortRelevance], values: []string{"1e-50", "1", "3"}},
+		{name: "float overflow", types: FilmCursorTypes[FilmSortRating], values: []string{"1e309", "1", "3"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateCursor(&models.Cursor{Sort: test.name, Values: test.values}, test.types)
+			if valid := err == nil; valid != test.valid {
+				t.Errorf("ValidateCursor valid = %v, want %v (error %v)", valid, test.valid, err)
+			}
+		})
+	}
+}
+
+func TestParsePathId(t *testing.T) {
+	tests := []struct {
+		path   string
+		id     uint64
+		action string
+		badId  bool
+		err    bool
+	}{
+		{path: "/api/v1/films/5", id: 5},
+		{path: "/api/v1/films/5/", id: 5},
+		{path: "/api/v1/films/5/similar", id: 5, action: "similar"},
+		{path: "/api/v1/films/abc", badId: true, err: true},
+		{path: "/api/v1/films/-1/similar", badId: true, err: true},
+		{path: "/api/v1/films/99999999999999999999", badId: true, err: true},
+		{path: "/api/v1/films/", err: true},
+		{path: "/api/v1/actors/5", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			id, action, err := ParsePathId(test.path, "/api/v1/films/")
+			if (err != nil) != test.err || errors.Is(err, ErrPathId) != test.badId {
+				t.Fatalf("ParsePathId error = %v, want error %v, bad id %v", err, test.err, test.badId)
+			}
+			if id != test.id || action != test.action {
+				t.Errorf("ParsePathId = %d, %q, want %d, %q", id, action, test.id, test.action)
+			}
+		})
+	}
+}
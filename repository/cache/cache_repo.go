@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"filmoteka/configs"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type CacheRepo struct {
+	DB *redis.Client
+}
+
+func GetCacheRepo(cfg *configs.DbRedisCfg, log *logrus.Logger) (ICacheRepo, error) {
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     cfg.Host,
+		Password: cfg.Password,
+		DB:       cfg.DbNumber,
+	})
+
+	_, err := redisClient.Ping(context.Background()).Result()
+	if err != nil {
+		log.Error("Ping redis error: ", err)
+		return nil, err
+	}
+
+	return &CacheRepo{DB: redisClient}, nil
+}
+
+// Get returns the value cached under key, found is false when there is none
+// or it has expired.
+func (repo *CacheRepo) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := repo.DB.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("get cache %s error: %s", key, err.Error())
+	}
+
+	return value, true, nil
+}
+
+func (repo *CacheRepo) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	err := repo.DB.Set(ctx, key, value, ttl).Err()
+	if err != nil {
+		return fmt.Errorf("set cache %s error: %s", key, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,11 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+type ICacheRepo interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
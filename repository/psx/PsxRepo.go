@@ -6,10 +6,12 @@ import (
 	"errors"
 	"filmoteka/configs"
 	utils "filmoteka/pkg"
+	"filmoteka/pkg/images"
 	"filmoteka/pkg/models"
 	"fmt"
 	_ "github.com/jackc/pgx/stdlib"
 	"github.com/sirupsen/logrus"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -62,234 +64,378 @@ func (repo *PsxRepo) pingDb(timer uint32, log *logrus.Logger) error {
 	return fmt.Errorf("sql max pinging error: %s", err.Error())
 }
 
-func (repo *PsxRepo) GetFilms(ctx context.Context, request *models.FindFilmRequest) (*[]models.FilmItem, error) {
-	films := make([]models.FilmItem, 0, request.PerPage)
-	var s strings.Builder
-	var hasWhere bool
-	paramNum := 1
-	var params []interface{}
-
-	s.WriteString("SELECT film.id ,film.title, film.rating, film.release_date, film.info FROM film " +
-		"LEFT JOIN actor_in_film ON actor_in_film.id_film = film.id " +
-		"LEFT JOIN actor ON actor_in_film.id_actor = actor.id ")
-
-	if request.Title != "" {
-		s.WriteString("WHERE ")
-		hasWhere = true
-		s.WriteString("fts @@ to_tsquery($" + strconv.Itoa(paramNum) + ") ")
-		paramNum++
-		params = append(params, request.Title)
-	}
-	if request.ReleaseDateFrom != "" {
-		if !hasWhere {
-			s.WriteString("WHERE ")
-			hasWhere = true
-		} else {
-			s.WriteString("AND ")
-		}
-		s.WriteString("release_date >= $" + strconv.Itoa(paramNum) + " ")
-		paramNum++
-		params = append(params, request.ReleaseDateFrom)
+// filmSortKey returns the order of a film listing. query is the full-text
+// query of the title filter; without an explicit order the films are ranked
+// by relevance to it, or by rating when there is none.
+func filmSortKey(order string, query string) sortKey {
+	switch {
+	case order == utils.FilmSortTitle:
+		return sortKey{name: utils.FilmSortTitle, desc: true, columns: []sortColumn{
+			{expr: "film.title", cast: utils.CursorText}, {expr: "film.id", cast: utils.CursorInteger}}}
+	case order == utils.FilmSortReleaseDate:
+		return sortKey{name: utils.FilmSortReleaseDate, desc: true, columns: []sortColumn{
+			{expr: "film.release_date", cast: utils.CursorDate}, {expr: "film.id", cast: utils.CursorInteger}}}
+	case query != "" && order != utils.FilmSortRating:
+		return sortKey{name: utils.FilmSortRelevance, desc: true, columns: []sortColumn{
+			{expr: "ts_rank(film.fts, " + query + ")", cast: utils.CursorReal}, {expr: "film.rating", cast: utils.CursorFloat},
+			{expr: "film.id", cast: utils.CursorInteger}}}
+	default:
+		return sortKey{name: utils.FilmSortRating, desc: true, columns: []sortColumn{
+			{expr: "film.rating", cast: utils.CursorFloat}, {expr: "film.votes", cast: utils.CursorInteger},
+			{expr: "film.id", cast: utils.CursorInteger}}}
 	}
-	if request.ReleaseDateTo != "" {
-		if !hasWhere {
-			s.WriteString("WHERE ")
-			hasWhere = true
-		} else {
-			s.WriteString("AND ")
-		}
-		s.WriteString("release_date <= $" + strconv.Itoa(paramNum) + " ")
-		paramNum++
+}
+
+// GetFilms returns one page of the films matching request together with the
+// total number of matches. The page is located by request.Cursor if present,
+// which also decides the order, or by request.Page counted from 1.
+func (repo *PsxRepo) GetFilms(ctx context.Context, request *models.FindFilmRequest) (*models.FilmsResponse, error) {
+	response := &models.FilmsResponse{PerPage: request.PerPage}
+	var countArgs queryArgs
+
+	countConditions, _ := filmConditions(request, &countArgs)
+	err := repo.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM film "+whereClause(countConditions), countArgs.params...).Scan(&response.Total)
+	if err != nil {
+		return nil, fmt.Errorf("count films error: %s", err.Error())
 	}
 
-	if !hasWhere {
-		s.WriteString("WHERE ")
+	var s strings.Builder
+	var args queryArgs
+
+	conditions, query := filmConditions(request, &args)
+
+	order := request.Order
+	if request.Cursor != nil {
+		order = request.Cursor.Sort
 	} else {
-		s.WriteString("AND ")
+		response.Page = max(request.Page, 1)
 	}
-	s.WriteString("rating >= $" + strconv.Itoa(paramNum) + " AND " + "rating <= $" + strconv.Itoa(paramNum+1) + " ")
-	paramNum += 2
-	params = append(params, request.RatingFrom, request.RatingTo)
 
-	s.WriteString("GROUP BY film.rating, film.id, film.title, film.release_date, film.info ")
+	key := filmSortKey(order, query)
 
-	switch request.Order {
-	case "title":
-		s.WriteString("ORDER BY film.title DESC ")
-	case "release_date":
-		s.WriteString("ORDER BY film.release_date DESC ")
-	case "rating":
-		s.WriteString("ORDER BY film.rating DESC ")
-	default:
-		s.WriteString("ORDER BY film.rating DESC ")
+	if request.Cursor != nil {
+		seek, err := key.seek(request.Cursor, &args)
+		if err != nil {
+			return nil, fmt.Errorf("find film err: %s", err.Error())
+		}
+		conditions = append(conditions, seek)
+	}
+
+	snippet := "''"
+	if query != "" {
+		snippet = "ts_headline('russian', film.title || '. ' || film.info, " + query + ", " +
+			"'StartSel=<b>, StopSel=</b>, MaxWords=35, MinWords=15, MaxFragments=2')"
 	}
 
-	s.WriteString("OFFSET $" + strconv.Itoa(paramNum) + " LIMIT $" + strconv.Itoa(paramNum+1))
-	params = append(params, request.Page, request.PerPage)
+	s.WriteString("SELECT film.id, film.title, film.info, film.rating, film.votes, film.release_date, film.poster, " + snippet + ", " +
+		key.selectList() + " FROM film ")
+	s.WriteString(whereClause(conditions))
+	s.WriteString(key.orderBy(request.Cursor != nil && request.Cursor.Backward))
+	s.WriteString(pageClause(request.Page, request.PerPage, request.Cursor, &args))
 
-	rows, err := repo.db.QueryContext(ctx, s.String(), params...)
+	rows, err := repo.db.QueryContext(ctx, s.String(), args.params...)
 	if err != nil {
 		return nil, fmt.Errorf("find film err: %s", err.Error())
 	}
 	defer rows.Close()
 
+	films := make([]models.FilmItem, 0, request.PerPage+1)
+	keys := make([][]string, 0, request.PerPage+1)
 	for rows.Next() {
 		post := models.FilmItem{}
+		values := make([]string, len(key.columns))
+
+		dest := []any{&post.Id, &post.Title, &post.Info, &post.Rating, &post.Votes, &post.ReleaseDate,
+			imageColumn{images.DirFilms, &post.Poster}, &post.Snippet}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
 
-		err := rows.Scan(&post.Id, &post.Title, &post.Rating, &post.Info, &post.ReleaseDate)
+		err := rows.Scan(dest...)
 		if err != nil {
 			return nil, fmt.Errorf("find film scan err: %s", err.Error())
 		}
 
 		films = append(films, post)
+		keys = append(keys, values)
 	}
 
-	return &films, nil
+	films, response.Next, response.Prev = paginate(key, request.Page, request.PerPage, request.Cursor, films, keys)
+	response.Films = &films
+
+	return response, nil
 }
 
-func (repo *PsxRepo) SearchFilms(ctx context.Context, titleFilm string, nameActor string, page uint64, perPage uint64) ([]models.FilmItem, error) {
-	films := make([]models.FilmItem, 0, perPage)
-	var s strings.Builder
-	haveSelect := false
-	var params []interface{}
-	count := 0
+// SearchFilms runs a full-text search by the title of request and a
+// case-insensitive match of cast names by its actor, ordered by relevance
+// when a title is given. Other filters of request are ignored.
+func (repo *PsxRepo) SearchFilms(ctx context.Context, request *models.FindFilmRequest) (*models.FilmsResponse, error) {
+	films, err := repo.GetFilms(ctx, &models.FindFilmRequest{
+		Title:    request.Title,
+		Actor:    request.Actor,
+		RatingTo: utils.VoteEnd,
+		Page:     request.Page,
+		PerPage:  request.PerPage,
+		Cursor:   request.Cursor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("find film error: %s", err.Error())
+	}
 
-	s.WriteString("SELECT film.id ,film.title, film.info, film.rating, film.release_date FROM film " +
-		"LEFT JOIN actor_in_film ON actor_in_film.id_film = film.id " +
-		"LEFT JOIN actor ON actor_in_film.id_actor = actor.id ")
+	return films, nil
+}
 
-	if titleFilm != "" {
-		haveSelect = true
-		params = append(params, titleFilm)
-		count++
-		s.WriteString("WHERE film.title LIKE '%' || $" + strconv.Itoa(count) + " || '%'")
+// actorSortKey returns the order of an actor listing by name, birthdate or
+// number of films, ties broken by id. Descending keys are named with a
+// ":desc" suffix, so that cursors remember the direction.
+func actorSortKey(sort string, desc bool) sortKey {
+	key := sortKey{name: sort, desc: desc}
+
+	switch sort {
+	case utils.ActorSortBirthdate:
+		key.columns = []sortColumn{{expr: "actor.birthdate", cast: utils.CursorDate}}
+	case utils.ActorSortFilms:
+		key.columns = []sortColumn{{expr: actorFilmsCount, cast: utils.CursorBigint}}
+	default:
+		key.name = utils.ActorSortName
+		key.columns = []sortColumn{{expr: "actor.name", cast: utils.CursorText}}
 	}
 
-	if nameActor != "" {
-		params = append(params, nameActor)
-		count++
-		if haveSelect {
-			s.WriteString("AND actor.name LIKE '%' || $" + strconv.Itoa(count) + " || '%'")
-		} else {
-			s.WriteString("WHERE actor.name LIKE '%' || $" + strconv.Itoa(count) + " || '%'")
+	key.columns = append(key.columns, sortColumn{expr: "actor.id", cast: utils.CursorInteger})
+	if desc {
+		key.name += ":" + utils.OrderDesc
+	}
+
+	return key
+}
+
+// FindActors returns one page of the actors matching request with their
+// credits and the total number of matches. The page is located by
+// request.Cursor if present, which also decides the order, or by request.Page
+// counted from 1.
+func (repo *PsxRepo) FindActors(ctx context.Context, request *models.FindActorRequest) (*models.ActorsResponse, error) {
+	response := &models.ActorsResponse{PerPage: request.PerPage}
+	var countArgs queryArgs
+
+	countConditions := actorConditions(request, &countArgs)
+	err := repo.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM actor "+whereClause(countConditions), countArgs.params...).Scan(&response.Total)
+	if err != nil {
+		return nil, fmt.Errorf("count actors error: %s", err.Error())
+	}
+
+	var s strings.Builder
+	var args queryArgs
+
+	conditions := actorConditions(request, &args)
+
+	key := actorSortKey(request.Sort, request.Desc)
+	if request.Cursor != nil {
+		sort, order, _ := strings.Cut(request.Cursor.Sort, ":")
+		key = actorSortKey(sort, order == utils.OrderDesc)
+
+		seek, err := key.seek(request.Cursor, &args)
+		if err != nil {
+			return nil, fmt.Errorf("find actors error: %s", err.Error())
 		}
+		conditions = append(conditions, seek)
+	} else {
+		response.Page = max(request.Page, 1)
 	}
 
-	s.WriteString("ORDER BY film.rating DESC ")
-	s.WriteString("OFFSET $" + strconv.Itoa(count+1) + " LIMIT $" + strconv.Itoa(count+2) + " ")
-	params = append(params, page, perPage)
+	s.WriteString("SELECT actor.id, actor.name, actor.gen, actor.birthdate, actor.photo, " + actorAge + ", " + actorFilmsCount + ", " +
+		key.selectList() + " FROM actor ")
+	s.WriteString(whereClause(conditions))
+	s.WriteString(key.orderBy(request.Cursor != nil && request.Cursor.Backward))
+	s.WriteString(pageClause(request.Page, request.PerPage, request.Cursor, &args))
 
-	rows, err := repo.db.QueryContext(ctx, s.String(), params...)
+	rows, err := repo.db.QueryContext(ctx, s.String(), args.params...)
 	if err != nil {
-		return nil, fmt.Errorf("find film error: %s", err.Error())
+		return nil, fmt.Errorf("sql query error: %s", err.Error())
 	}
 	defer rows.Close()
 
+	actors := make([]models.ActorResponse, 0, request.PerPage+1)
+	keys := make([][]string, 0, request.PerPage+1)
 	for rows.Next() {
-		post := models.FilmItem{}
-		err := rows.Scan(&post.Id, &post.Title, &post.Info, &post.Rating, &post.ReleaseDate)
+		actor := models.ActorResponse{Films: []models.ActorCreditItem{}}
+		values := make([]string, len(key.columns))
+
+		dest := []any{&actor.Id, &actor.Name, &actor.Gender, &actor.Birthday, imageColumn{images.DirActors, &actor.Photo},
+			&actor.Age, &actor.FilmsCount}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+
+		err := rows.Scan(dest...)
 		if err != nil {
-			return nil, fmt.Errorf("find film scan err: %s", err.Error())
+			return nil, fmt.Errorf("sql Scan error: %s", err.Error())
 		}
-		films = append(films, post)
+
+		actors = append(actors, actor)
+		keys = append(keys, values)
 	}
 
-	return films, nil
+	actors, response.Next, response.Prev = paginate(key, request.Page, request.PerPage, request.Cursor, actors, keys)
+	response.Actors = actors
+
+	err = repo.addActorCredits(ctx, actors)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
 }
 
-func (repo *PsxRepo) FindActors(ctx context.Context, page uint64, perPage uint64) ([]models.ActorResponse, error) {
-	rows, err := repo.db.QueryContext(ctx, `
-		SELECT
-			actor.id,
-			actor.name,
-			actor.gen,
-			actor.birthdate,
-			film.id,
-			film.title,
-			film.info,
-			film.release_date,
-			film.rating
-		FROM (
-				 SELECT * FROM actor
-				 OFFSET $1 LIMIT $2
-			 ) AS actor
-				 LEFT JOIN actor_in_film ON actor.id = actor_in_film.id_actor
-				 LEFT JOIN film ON actor_in_film.id_film = film.id`, page, perPage)
+// addActorCredits fills the filmography of every actor, newest films first.
+func (repo *PsxRepo) addActorCredits(ctx context.Context, actors []models.ActorResponse) error {
+	if len(actors) == 0 {
+		return nil
+	}
+
+	var args queryArgs
+	positions := make(map[uint64]int, len(actors))
+	actorIds := make([]uint64, 0, len(actors))
+	for i, actor := range actors {
+		positions[actor.Id] = i
+		actorIds = append(actorIds, actor.Id)
+	}
+
+	rows, err := repo.db.QueryContext(ctx, "SELECT actor_in_film.id_actor, film.id, film.title, film.info, film.release_date, "+
+		"film.rating, film.votes, film.poster, actor_in_film.role, actor_in_film.character_name, actor_in_film.billing_order FROM actor_in_film "+
+		"JOIN film ON actor_in_film.id_film = film.id "+
+		"WHERE actor_in_film.id_actor IN ("+args.addList(actorIds)+") AND film.deleted_at IS NULL "+
+		"ORDER BY film.release_date DESC, film.id DESC, actor_in_film.billing_order", args.params...)
 	if err != nil {
-		return nil, fmt.Errorf("sql query error: %s", err.Error())
+		return fmt.Errorf("sql query actor credits error: %s", err.Error())
 	}
 	defer rows.Close()
 
-	actorsMap := make(map[uint64]*models.ActorResponse)
 	for rows.Next() {
-		var actorID uint64
-		var actorName, actorGender, actorBirthday string
-		var filmID sql.NullInt64
-		var filmTitle, filmInfo, filmReleaseDate string
-		var filmRating float64
-
-		err := rows.Scan(&actorID, &actorName, &actorGender, &actorBirthday, &filmID, &filmTitle, &filmInfo, &filmReleaseDate, &filmRating)
-		if err != nil && filmID.Valid {
-			return nil, fmt.Errorf("sql Scan error: %s", err.Error())
-		}
-		actor, ok := actorsMap[actorID]
-		if !ok {
-			actor = &models.ActorResponse{
-				Id:       actorID,
-				Name:     actorName,
-				Gender:   actorGender,
-				Birthday: actorBirthday,
-			}
-			actorsMap[actorID] = actor
-		}
+		var actorId uint64
+		var credit models.ActorCreditItem
 
-		if filmID.Valid {
-			actor.Films = append(actor.Films, models.FilmItem{
-				Id:          uint64(filmID.Int64),
-				Title:       filmTitle,
-				Info:        filmInfo,
-				ReleaseDate: filmReleaseDate,
-				Rating:      filmRating,
-			})
+		err := rows.Scan(&actorId, &credit.Id, &credit.Title, &credit.Info, &credit.ReleaseDate,
+			&credit.Rating, &credit.Votes, imageColumn{images.DirFilms, &credit.Poster}, &credit.Role, &credit.Character, &credit.BillingOrder)
+		if err != nil {
+			return fmt.Errorf("sql scan actor credits error: %s", err.Error())
 		}
+
+		actor := &actors[positions[actorId]]
+		actor.Films = append(actor.Films, credit)
 	}
 
-	var actors []models.ActorResponse
-	for _, actor := range actorsMap {
-		actors = append(actors, *actor)
+	return nil
+}
+
+// actorAge and actorFilmsCount compute the age of an actor and the number of
+// distinct films they are credited in.
+const (
+	actorAge        = "date_part('year', age(actor.birthdate))::integer"
+	actorFilmsCount = "(SELECT COUNT(DISTINCT actor_in_film.id_film) FROM actor_in_film " +
+		"JOIN film ON actor_in_film.id_film = film.id WHERE actor_in_film.id_actor = actor.id AND film.deleted_at IS NULL)"
+)
+
+// GetActor returns the actor with actorId without the filmography, found is
+// false when there is no such actor.
+func (repo *PsxRepo) GetActor(ctx context.Context, actorId uint64) (*models.ActorResponse, bool, error) {
+	actor := &models.ActorResponse{}
+
+	err := repo.db.QueryRowContext(ctx, "SELECT actor.id, actor.name, actor.gen, actor.birthdate, actor.photo, "+actorAge+", "+actorFilmsCount+" "+
+		"FROM actor WHERE actor.id = $1 AND actor.deleted_at IS NULL", actorId).Scan(&actor.Id, &actor.Name, &actor.Gender, &actor.Birthday,
+		imageColumn{images.DirActors, &actor.Photo}, &actor.Age, &actor.FilmsCount)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("get actor error: %s", err.Error())
 	}
 
-	return actors, nil
+	return actor, true, nil
 }
 
-func (repo *PsxRepo) FindFilmsByActor(ctx context.Context, actorId uint64) ([]models.FilmItem, error) {
-	rows, err := repo.db.QueryContext(ctx, "SELECT film.id, film.title, film,info, film.release_date FROM film LEFT JOIN actor_in_film ON actor_in_film.id_film = film.id LEFT JOIN actor ON actor_in_film.id_actor = actor.id WHERE actor.id = $1", actorId)
+// FindFilmsByActor returns every credit of actorId, newest films first.
+func (repo *PsxRepo) FindFilmsByActor(ctx context.Context, actorId uint64) ([]models.ActorCreditItem, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT film.id, film.title, film.info, film.release_date, film.rating, film.votes, film.poster, "+
+		"actor_in_film.role, actor_in_film.character_name, actor_in_film.billing_order FROM actor_in_film "+
+		"JOIN film ON actor_in_film.id_film = film.id "+
+		"WHERE actor_in_film.id_actor = $1 AND film.deleted_at IS NULL "+
+		"ORDER BY film.release_date DESC, film.id DESC, actor_in_film.billing_order", actorId)
 	if err != nil {
 		return nil, fmt.Errorf("sql request error: %s", err.Error())
 	}
+	defer rows.Close()
 
-	var response []models.FilmItem
-
+	response := make([]models.ActorCreditItem, 0)
 	for rows.Next() {
-		var film models.FilmItem
+		var credit models.ActorCreditItem
 
-		err := rows.Scan(&film.Id, &film.Title, &film.Info, &film.ReleaseDate)
+		err := rows.Scan(&credit.Id, &credit.Title, &credit.Info, &credit.ReleaseDate, &credit.Rating, &credit.Votes,
+			imageColumn{images.DirFilms, &credit.Poster}, &credit.Role, &credit.Character, &credit.BillingOrder)
 		if err != nil {
 			return nil, fmt.Errorf("sql Scan error: %s", err.Error())
 		}
-		response = append(response, film)
+		response = append(response, credit)
 	}
 
 	return response, nil
 }
 
+func (repo *PsxRepo) GetFilm(ctx context.Context, filmId uint64) (*models.FilmItem, bool, error) {
+	film := &models.FilmItem{}
+
+	err := repo.db.QueryRowContext(ctx, "SELECT film.id, film.title, film.info, film.rating, film.votes, film.release_date, film.poster FROM film "+
+		"WHERE film.id = $1 AND film.deleted_at IS NULL", filmId).Scan(&film.Id, &film.Title, &film.Info, &film.Rating, &film.Votes, &film.ReleaseDate,
+		imageColumn{images.DirFilms, &film.Poster})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("get film error: %s", err.Error())
+	}
+
+	return film, true, nil
+}
+
+func (repo *PsxRepo) FindCreditsByFilm(ctx context.Context, filmId uint64) ([]models.FilmCreditItem, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT actor.id, actor.name, actor.gen, actor.birthdate, actor.photo, "+
+		"actor_in_film.role, actor_in_film.character_name, actor_in_film.billing_order FROM actor "+
+		"JOIN actor_in_film ON actor_in_film.id_actor = actor.id "+
+		"WHERE actor_in_film.id_film = $1 AND actor.deleted_at IS NULL "+
+		"ORDER BY array_position(ARRAY['director', 'writer', 'producer', 'composer', 'actor'], actor_in_film.role), "+
+		"actor_in_film.billing_order, actor.name, actor.id", filmId)
+	if err != nil {
+		return nil, fmt.Errorf("sql request find film credits error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	credits := make([]models.FilmCreditItem, 0)
+	for rows.Next() {
+		var credit models.FilmCreditItem
+
+		err := rows.Scan(&credit.Id, &credit.Name, &credit.Gender, &credit.Birthday, imageColumn{images.DirActors, &credit.Photo},
+			&credit.Role, &credit.Character, &credit.BillingOrder)
+		if err != nil {
+			return nil, fmt.Errorf("sql scan film credits error: %s", err.Error())
+		}
+		credits = append(credits, credit)
+	}
+
+	return credits, nil
+}
+
 func (repo *PsxRepo) GetRelationByFilmId(ctx context.Context, filmId uint64) ([]uint64, error) {
+	return relationsByFilm(ctx, repo.db, filmId)
+}
+
+// relationsByFilm returns the ids of the actors playing in the film.
+func relationsByFilm(ctx context.Context, q querier, filmId uint64) ([]uint64, error) {
 	var ids []uint64
 
-	rows, err := repo.db.QueryContext(ctx, `SELECT actor_in_film.id_actor FROM actor_in_film WHERE actor_in_film.id_film=$1`, filmId)
+	rows, err := q.QueryContext(ctx, `SELECT actor_in_film.id_actor FROM actor_in_film WHERE actor_in_film.id_film=$1 AND actor_in_film.role='actor'`, filmId)
 	if err != nil {
 		return nil, fmt.Errorf("sql request find relation actors error: %s", err.Error())
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var id uint64
@@ -301,16 +447,21 @@ func (repo *PsxRepo) GetRelationByFilmId(ctx context.Context, filmId uint64) ([]
 		ids = append(ids, id)
 	}
 
-	return ids, nil
+	return ids, rows.Err()
 }
 
 func (repo *PsxRepo) GetRelationByActorId(ctx context.Context, actorId uint64) ([]uint64, error) {
+	return relationsByActor(ctx, repo.db, actorId)
+}
+
+func relationsByActor(ctx context.Context, q querier, actorId uint64) ([]uint64, error) {
 	var ids []uint64
 
-	rows, err := repo.db.QueryContext(ctx, `SELECT actor_in_film.id_film FROM actor_in_film WHERE actor_in_film.id_actor=$1`, actorId)
+	rows, err := q.QueryContext(ctx, `SELECT actor_in_film.id_film FROM actor_in_film WHERE actor_in_film.id_actor=$1 AND actor_in_film.role='actor'`, actorId)
 	if err != nil {
 		return nil, fmt.Errorf("sql request find relation films error: %s", err.Error())
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var id uint64
@@ -322,30 +473,41 @@ func (repo *PsxRepo) GetRelationByActorId(ctx context.Context, actorId uint64) (
 		ids = append(ids, id)
 	}
 
-	return ids, nil
+	return ids, rows.Err()
 }
 
 func (repo *PsxRepo) DeleteRelation(ctx context.Context, filmId uint64, actorId uint64) error {
-	_, err := repo.db.QueryContext(ctx, `DELETE FROM actor_in_film WHERE id_actor=$1 AND id_film=$2`, actorId, filmId)
+	return deleteRelation(ctx, repo.db, filmId, actorId)
+}
+
+func deleteRelation(ctx context.Context, q querier, filmId uint64, actorId uint64) error {
+	_, err := q.ExecContext(ctx, `DELETE FROM actor_in_film WHERE id_actor=$1 AND id_film=$2 AND role='actor'`, actorId, filmId)
 	if err != nil {
 		return fmt.Errorf("sql delete relation error: %s", err.Error())
 	}
 
-	return err
+	return nil
 }
 
 func (repo *PsxRepo) InsertRelation(ctx context.Context, filmId uint64, actorId uint64) error {
-	result, err := repo.db.ExecContext(ctx, `INSERT INTO actor_in_film (id_actor, id_film) VALUES ($1, $2)`, actorId, filmId)
-	if err != nil && result != nil {
+	return insertRelation(ctx, repo.db, filmId, actorId)
+}
+
+// insertRelation casts the actor in the film, nothing is inserted when either
+// of them does not exist or the actor already plays there.
+func insertRelation(ctx context.Context, q querier, filmId uint64, actorId uint64) error {
+	_, err := q.ExecContext(ctx, `INSERT INTO actor_in_film (id_actor, id_film, role) SELECT actor.id, film.id, 'actor' `+
+		`FROM actor, film WHERE actor.id = $1 AND film.id = $2 ON CONFLICT DO NOTHING`, actorId, filmId)
+	if err != nil {
 		return fmt.Errorf("sql insert relation error: %s", err.Error())
 	}
 
-	return err
+	return nil
 }
 
-func (repo *PsxRepo) UpdateFilm(ctx context.Context, film *models.FilmRequest) error {
+func (repo *PsxRepo) UpdateFilm(ctx context.Context, film *models.FilmRequest) (bool, error) {
 	if film.Id == 0 {
-		return fmt.Errorf("film id missing")
+		return false, fmt.Errorf("film id missing")
 	}
 
 	var s strings.Builder
@@ -386,99 +548,164 @@ func (repo *PsxRepo) UpdateFilm(ctx context.Context, film *models.FilmRequest) e
 		count++
 		s.WriteString(" release_date = $" + strconv.Itoa(count))
 	}
-	if film.Rating != 0 {
-		if haveEq {
-			s.WriteString(",")
-		} else {
-			haveEq = true
-		}
-
-		params = append(params, film.Rating)
-		count++
-		s.WriteString(" rating = $" + strconv.Itoa(count))
-	}
 
 	params = append(params, film.Id)
 	count++
 	s.WriteString(" WHERE film.id = $" + strconv.Itoa(count))
 
-	if count < 2 {
-		return fmt.Errorf("not have params")
+	if count < 2 && film.Actors == nil && film.Credits == nil && film.Genres == nil {
+		return false, fmt.Errorf("not have params")
 	}
 
-	_, err := repo.db.QueryContext(ctx, s.String(), params...)
+	tx, err := repo.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("update film error: %s", err.Error())
+		return false, fmt.Errorf("update film begin error: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	// the film is locked first, so that a film in the trash is not changed
+	// even when only its genres or credits are given
+	err = tx.QueryRowContext(ctx, "SELECT film.id FROM film WHERE film.id = $1 AND film.deleted_at IS NULL FOR UPDATE",
+		film.Id).Scan(&film.Id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("update film lock error: %s", err.Error())
 	}
 
-	for range film.Actors {
-		existingActorIds, err := repo.GetRelationByFilmId(ctx, film.Id)
+	if count > 1 {
+		_, err := tx.ExecContext(ctx, s.String(), params...)
 		if err != nil {
-			return err
+			return false, fmt.Errorf("update film error: %s", err.Error())
+		}
+	}
+
+	if film.Genres != nil {
+		_, err := tx.ExecContext(ctx, "DELETE FROM genre_in_film WHERE id_film = $1", film.Id)
+		if err != nil {
+			return false, fmt.Errorf("sql delete film genres error: %s", err.Error())
+		}
+
+		err = addGenresForFilm(ctx, tx, film.Id, film.Genres)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// credits replace the whole cast and crew, the actors list only the actors
+	if film.Credits != nil {
+		_, err := tx.ExecContext(ctx, "DELETE FROM actor_in_film WHERE id_film = $1", film.Id)
+		if err != nil {
+			return false, fmt.Errorf("sql delete film credits error: %s", err.Error())
+		}
+
+		err = addCreditsForFilm(ctx, tx, film.Id, film.Credits)
+		if err != nil {
+			return false, err
+		}
+	} else if film.Actors != nil {
+		existingActorIds, err := relationsByFilm(ctx, tx, film.Id)
+		if err != nil {
+			return false, err
 		}
 
 		for _, existingActorId := range existingActorIds {
-			found := false
-			for _, actorId := range film.Actors {
-				if existingActorId == actorId {
-					found = true
-					break
-				}
-			}
-			if !found {
-				err := repo.DeleteRelation(ctx, existingActorId, film.Id)
+			if !slices.Contains(film.Actors, existingActorId) {
+				err := deleteRelation(ctx, tx, film.Id, existingActorId)
 				if err != nil {
-					return err
+					return false, err
 				}
 			}
 		}
 
 		for _, actorId := range film.Actors {
-			found := false
-			for _, existingActorId := range existingActorIds {
-				if existingActorId == actorId {
-					found = true
-					break
-				}
-			}
-			if !found {
-				err := repo.InsertRelation(ctx, film.Id, actorId)
+			if !slices.Contains(existingActorIds, actorId) {
+				err := insertRelation(ctx, tx, film.Id, actorId)
 				if err != nil {
-					return err
+					return false, err
 				}
 			}
 		}
 	}
 
-	return nil
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("update film commit error: %s", err.Error())
+	}
+
+	return true, nil
 }
 
+// DeleteFilm moves the film to the trash, its credits, ratings and reviews are
+// kept so that RestoreFilm brings it back as it was. Deleted is false when
+// there is no such film outside the trash.
 func (repo *PsxRepo) DeleteFilm(ctx context.Context, filmId uint64) (bool, error) {
-	_, err := repo.db.ExecContext(ctx, "DELETE FROM film "+
-		"WHERE film.id = $1", filmId)
+	result, err := repo.db.ExecContext(ctx, "UPDATE film SET deleted_at = now() "+
+		"WHERE film.id = $1 AND film.deleted_at IS NULL", filmId)
 	if err != nil {
-		return false, fmt.Errorf("remove favorite film error: %s", err.Error())
+		return false, fmt.Errorf("delete film error: %s", err.Error())
 	}
 
-	return true, nil
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("delete film error: %s", err.Error())
+	}
+
+	return deleted > 0, nil
 }
 
-func (repo *PsxRepo) DeleteActor(ctx context.Context, actorId uint64) error {
-	_, err := repo.db.ExecContext(ctx, "DELETE FROM actor WHERE actor.id = $1", actorId)
+// DeleteActor moves the actor to the trash keeping the credits, see DeleteFilm.
+func (repo *PsxRepo) DeleteActor(ctx context.Context, actorId uint64) (bool, error) {
+	result, err := repo.db.ExecContext(ctx, "UPDATE actor SET deleted_at = now() "+
+		"WHERE actor.id = $1 AND actor.deleted_at IS NULL", actorId)
 	if err != nil {
-		return fmt.Errorf("sql exec error: %s", err.Error())
+		return false, fmt.Errorf("sql exec error: %s", err.Error())
 	}
 
-	return nil
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("sql exec error: %s", err.Error())
+	}
+
+	return deleted > 0, nil
 }
 
-func (repo *PsxRepo) AddFilm(ctx context.Context, film *models.FilmRequest) (uint64, error) {
-	err := repo.db.QueryRowContext(ctx, "INSERT INTO film(title, info, release_date, rating) VALUES($1, $2, $3, $4) RETURNING id",
-		film.Title, film.Info, film.ReleaseDate, film.Rating).Scan(&film.Id)
+// AddFilm stores the film together with its actors, credits and genres. It
+// returns utils.ErrUnknownId when one of the actors or genres does not exist.
+func (repo *PsxRepo) AddFilm(ctx context.Context, film *models.FilmRequest, actors []uint64) (uint64, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("add film begin error: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, "INSERT INTO film(title, info, release_date) VALUES($1, $2, $3) RETURNING id",
+		film.Title, film.Info, film.ReleaseDate).Scan(&film.Id)
 	if err != nil {
 		return 0, fmt.Errorf("insert film err: %s", err.Error())
 	}
 
+	err = addActorsForFilm(ctx, tx, film.Id, actors)
+	if err != nil {
+		return 0, err
+	}
+
+	err = addCreditsForFilm(ctx, tx, film.Id, film.Credits)
+	if err != nil {
+		return 0, err
+	}
+
+	err = addGenresForFilm(ctx, tx, film.Id, film.Genres)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("add film commit error: %s", err.Error())
+	}
+
 	return film.Id, nil
 }
 
@@ -491,9 +718,11 @@ func (repo *PsxRepo) AddActor(ctx context.Context, actor *models.ActorItem) (uin
 	return actor.Id, nil
 }
 
-func (repo *PsxRepo) UpdateActor(ctx context.Context, actor *models.ActorRequest) error {
+// UpdateActor changes the actor, their acting credits are replaced only when
+// films are given. Found is false when there is no such actor outside the trash.
+func (repo *PsxRepo) UpdateActor(ctx context.Context, actor *models.ActorRequest) (bool, error) {
 	if actor.Id == 0 {
-		return fmt.Errorf("actor id missing")
+		return false, fmt.Errorf("actor id missing")
 	}
 
 	var s strings.Builder
@@ -539,62 +768,77 @@ func (repo *PsxRepo) UpdateActor(ctx context.Context, actor *models.ActorRequest
 	count++
 	s.WriteString(" WHERE actor.id = $" + strconv.Itoa(count))
 
-	if count < 2 {
-		return fmt.Errorf("not have params")
+	if count < 2 && actor.Films == nil {
+		return false, fmt.Errorf("not have params")
 	}
 
-	_, err := repo.db.Query(s.String(), params...)
+	tx, err := repo.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("update actor error: %s", err.Error())
+		return false, fmt.Errorf("update actor begin error: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	// the actor is locked first, see UpdateFilm
+	err = tx.QueryRowContext(ctx, "SELECT actor.id FROM actor WHERE actor.id = $1 AND actor.deleted_at IS NULL FOR UPDATE",
+		actor.Id).Scan(&actor.Id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("update actor lock error: %s", err.Error())
 	}
 
-	for range actor.Films {
-		existingFilmIds, err := repo.GetRelationByActorId(ctx, actor.Id)
+	if count > 1 {
+		_, err := tx.ExecContext(ctx, s.String(), params...)
 		if err != nil {
-			return err
+			return false, fmt.Errorf("update actor error: %s", err.Error())
+		}
+	}
+
+	if actor.Films != nil {
+		existingFilmIds, err := relationsByActor(ctx, tx, actor.Id)
+		if err != nil {
+			return false, err
 		}
 
 		for _, existingFilmId := range existingFilmIds {
-			found := false
-			for _, filmId := range actor.Films {
-				if existingFilmId == filmId {
-					found = true
-					break
-				}
-			}
-			if !found {
-				err := repo.DeleteRelation(ctx, existingFilmId, actor.Id)
+			if !slices.Contains(actor.Films, existingFilmId) {
+				err := deleteRelation(ctx, tx, existingFilmId, actor.Id)
 				if err != nil {
-					return err
+					return false, err
 				}
 			}
 		}
 
 		for _, filmId := range actor.Films {
-			found := false
-			for _, existingFilmId := range existingFilmIds {
-				if existingFilmId == filmId {
-					found = true
-					break
-				}
-			}
-			if !found {
-				err := repo.InsertRelation(ctx, filmId, actor.Id)
+			if !slices.Contains(existingFilmIds, filmId) {
+				err := insertRelation(ctx, tx, filmId, actor.Id)
 				if err != nil {
-					return err
+					return false, err
 				}
 			}
 		}
 	}
 
-	return nil
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("update actor commit error: %s", err.Error())
+	}
+
+	return true, nil
 }
 
-func (repo *PsxRepo) AddActorsForFilm(ctx context.Context, filmId uint64, actors []uint64) error {
+// addActorsForFilm casts the actors in the film, see knownIds for unknown ones.
+func addActorsForFilm(ctx context.Context, q querier, filmId uint64, actors []uint64) error {
 	if len(actors) == 0 {
 		return nil
 	}
 
+	err := knownIds(ctx, q, "actor", actors)
+	if err != nil {
+		return err
+	}
+
 	var s strings.Builder
 	var params []interface{}
 	params = append(params, filmId)
@@ -607,27 +851,141 @@ func (repo *PsxRepo) AddActorsForFilm(ctx context.Context, filmId uint64, actors
 		s.WriteString("($1, $" + strconv.Itoa(i+2) + ")")
 		params = append(params, actor)
 	}
+	s.WriteString(" ON CONFLICT DO NOTHING")
 
-	reasult, err := repo.db.ExecContext(ctx, s.String(), params...)
-	if err != nil && reasult != nil {
+	_, err = q.ExecContext(ctx, s.String(), params...)
+	if err != nil {
 		return fmt.Errorf("add film actors error: %w", err)
 	}
 	return nil
 }
 
-func (repo *PsxRepo) GetUser(ctx context.Context, login string, password []byte) (*models.UserItem, bool, error) {
-	post := &models.UserItem{}
+func addCreditsForFilm(ctx context.Context, q querier, filmId uint64, credits []models.CreditRequest) error {
+	if len(credits) == 0 {
+		return nil
+	}
+
+	actors := make([]uint64, 0, len(credits))
+	for _, credit := range credits {
+		actors = append(actors, credit.ActorId)
+	}
 
-	err := repo.db.QueryRowContext(ctx, "SELECT profile.id, profile.login, profile.role FROM profile "+
-		"WHERE profile.login = $1 AND profile.password = $2 ", login, password).Scan(&post.Id, &post.Login, &post.Role)
+	err := knownIds(ctx, q, "actor", actors)
+	if err != nil {
+		return err
+	}
+
+	var s strings.Builder
+	var args queryArgs
+	film := args.add(filmId)
+
+	s.WriteString("INSERT INTO actor_in_film(id_film, id_actor, role, character_name, billing_order) VALUES")
+	for i, credit := range credits {
+		if i != 0 {
+			s.WriteString(",")
+		}
+		s.WriteString("(" + film + ", " + args.add(credit.ActorId) + ", " + args.add(credit.Role) + ", " +
+			args.add(credit.Character) + ", " + args.add(credit.BillingOrder) + ")")
+	}
+	s.WriteString(" ON CONFLICT DO NOTHING")
+
+	_, err = q.ExecContext(ctx, s.String(), args.params...)
+	if err != nil {
+		return fmt.Errorf("add film credits error: %w", err)
+	}
+	return nil
+}
+
+func addGenresForFilm(ctx context.Context, q querier, filmId uint64, genres []uint64) error {
+	if len(genres) == 0 {
+		return nil
+	}
+
+	err := knownIds(ctx, q, "genre", genres)
+	if err != nil {
+		return err
+	}
+
+	var s strings.Builder
+	var params []interface{}
+	params = append(params, filmId)
+
+	s.WriteString("INSERT INTO genre_in_film(id_film, id_genre) VALUES")
+	for i, genre := range genres {
+		if i != 0 {
+			s.WriteString(",")
+		}
+		s.WriteString("($1, $" + strconv.Itoa(i+2) + ")")
+		params = append(params, genre)
+	}
+	s.WriteString(" ON CONFLICT DO NOTHING")
+
+	_, err = q.ExecContext(ctx, s.String(), params...)
+	if err != nil {
+		return fmt.Errorf("add film genres error: %w", err)
+	}
+	return nil
+}
+
+// knownIds returns utils.ErrUnknownId unless every id has a row in table, so
+// that a film is not linked to an actor or genre that does not exist.
+func knownIds(ctx context.Context, q querier, table string, ids []uint64) error {
+	distinct := slices.Clone(ids)
+	slices.Sort(distinct)
+	distinct = slices.Compact(distinct)
+
+	var args queryArgs
+	var count int
+	err := q.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table+" WHERE "+table+".id IN ("+args.addList(distinct)+")",
+		args.params...).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("sql count %s error: %s", table, err.Error())
+	}
+
+	if count != len(distinct) {
+		return utils.ErrUnknownId
+	}
+
+	return nil
+}
+
+func (repo *PsxRepo) FindGenresByFilm(ctx context.Context, filmId uint64) ([]models.GenreItem, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT genre.id, genre.name FROM genre "+
+		"JOIN genre_in_film ON genre_in_film.id_genre = genre.id "+
+		"WHERE genre_in_film.id_film = $1 ORDER BY genre.name", filmId)
+	if err != nil {
+		return nil, fmt.Errorf("sql request find film genres error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	genres := make([]models.GenreItem, 0)
+	for rows.Next() {
+		var genre models.GenreItem
+
+		err := rows.Scan(&genre.Id, &genre.Name)
+		if err != nil {
+			return nil, fmt.Errorf("sql scan film genres error: %s", err.Error())
+		}
+		genres = append(genres, genre)
+	}
+
+	return genres, nil
+}
+
+func (repo *PsxRepo) GetUserCredentials(ctx context.Context, login string) (*models.UserCredentials, bool, error) {
+	credentials := &models.UserCredentials{}
+
+	err := repo.db.QueryRowContext(ctx, "SELECT profile.id, profile.login, profile.role, profile.password, profile.password_algo "+
+		"FROM profile WHERE profile.login = $1", login).Scan(&credentials.User.Id, &credentials.User.Login,
+		&credentials.User.Role, &credentials.Hash, &credentials.Algo)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, false, nil
 		}
-		return nil, false, fmt.Errorf("get query user error: %s", err.Error())
+		return nil, false, fmt.Errorf("get query user credentials error: %s", err.Error())
 	}
 
-	return post, true, nil
+	return credentials, true, nil
 }
 
 func (repo *PsxRepo) FindUser(ctx context.Context, login string) (bool, error) {
@@ -646,9 +1004,10 @@ func (repo *PsxRepo) FindUser(ctx context.Context, login string) (bool, error) {
 	return true, nil
 }
 
-func (repo *PsxRepo) CreateUser(ctx context.Context, login string, password []byte) error {
+func (repo *PsxRepo) CreateUser(ctx context.Context, login string, hash []byte, algo string) error {
 	var userID uint64
-	err := repo.db.QueryRowContext(ctx, "INSERT INTO profile(login, role, password) VALUES($1, $2, $3) RETURNING id", login, "user", password).Scan(&userID)
+	err := repo.db.QueryRowContext(ctx, "INSERT INTO profile(login, role, password, password_algo) VALUES($1, $2, $3, $4) RETURNING id",
+		login, utils.RoleUser, hash, algo).Scan(&userID)
 	if err != nil {
 		return fmt.Errorf("create user error: %s", err.Error())
 	}
@@ -656,6 +1015,18 @@ func (repo *PsxRepo) CreateUser(ctx context.Context, login string, password []by
 	return nil
 }
 
+// UpdatePassword replaces the password hash of the user only while it is
+// still previous, so an upgrade racing a password change is dropped.
+func (repo *PsxRepo) UpdatePassword(ctx context.Context, userId uint64, hash []byte, algo string, previous []byte) error {
+	_, err := repo.db.ExecContext(ctx, "UPDATE profile SET password = $1, password_algo = $2 WHERE id = $3 AND password = $4",
+		hash, algo, userId, previous)
+	if err != nil {
+		return fmt.Errorf("update password error: %s", err.Error())
+	}
+
+	return nil
+}
+
 func (repo *PsxRepo) GetUserId(ctx context.Context, login string) (uint64, error) {
 	var userID uint64
 
@@ -681,3 +1052,46 @@ func (repo *PsxRepo) GetRole(ctx context.Context, userId uint64) (string, error)
 
 	return roleName, nil
 }
+
+// UpdateRole sets the role of the user and returns the profile along with the
+// role it had before. It returns utils.ErrLastAdmin rather than leave no admin.
+func (repo *PsxRepo) UpdateRole(ctx context.Context, userId uint64, role string) (*models.ProfileItem, string, bool, error) {
+	profile := &models.ProfileItem{}
+	var previous string
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("update role begin error: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	// The admins are locked, so two admins demoting each other at once
+	// cannot both pass the check.
+	var admins uint64
+	err = tx.QueryRowContext(ctx, "SELECT count(*) FROM (SELECT id FROM profile WHERE role = $1 FOR UPDATE) admin",
+		utils.RoleAdmin).Scan(&admins)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("update role count admins error: %s", err.Error())
+	}
+
+	err = tx.QueryRowContext(ctx, "UPDATE profile SET role = $1 FROM (SELECT id, role FROM profile WHERE id = $2 FOR UPDATE) old "+
+		"WHERE profile.id = old.id RETURNING profile.id, profile.login, profile.role, old.role", role, userId).
+		Scan(&profile.Id, &profile.Login, &profile.Role, &previous)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", false, nil
+		}
+		return nil, "", false, fmt.Errorf("update role error: %s", err.Error())
+	}
+
+	if previous == utils.RoleAdmin && role != utils.RoleAdmin && admins <= 1 {
+		return nil, "", true, utils.ErrLastAdmin
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, "", false, fmt.Errorf("update role commit error: %s", err.Error())
+	}
+
+	return profile, previous, true, nil
+}
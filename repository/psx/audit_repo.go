@@ -0,0 +1,93 @@
+package psx
+
+import (
+	"context"
+	"database/sql"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/models"
+	"fmt"
+	"strings"
+)
+
+func (repo *PsxRepo) AddAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	err := repo.db.QueryRowContext(ctx, "INSERT INTO audit_log(id_profile, action, entity, entity_id, before, after, ip, request_id) "+
+		"VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at",
+		nullId(entry.UserId), entry.Action, entry.Entity, nullId(entry.EntityId), nullJson(entry.Before), nullJson(entry.After),
+		entry.Ip, entry.RequestId).Scan(&entry.Id, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("add audit entry error: %s", err.Error())
+	}
+
+	return nil
+}
+
+// FindAuditEntries lists the audit log entries matching request, newest first.
+func (repo *PsxRepo) FindAuditEntries(ctx context.Context, request *models.FindAuditRequest) (*models.AuditResponse, error) {
+	response := &models.AuditResponse{Page: max(request.Page, 1), PerPage: request.PerPage,
+		Entries: make([]models.AuditEntry, 0, request.PerPage)}
+
+	var args queryArgs
+	var conditions []string
+	if request.UserId != 0 {
+		conditions = append(conditions, "audit_log.id_profile = "+args.add(request.UserId))
+	}
+	if request.Action != "" {
+		conditions = append(conditions, "audit_log.action = "+args.add(request.Action))
+	}
+	if request.Entity != "" {
+		conditions = append(conditions, "audit_log.entity = "+args.add(request.Entity))
+	}
+	if request.EntityId != 0 {
+		conditions = append(conditions, "audit_log.entity_id = "+args.add(request.EntityId))
+	}
+	if request.From != nil {
+		conditions = append(conditions, "audit_log.created_at >= "+args.add(*request.From))
+	}
+	if request.To != nil {
+		conditions = append(conditions, "audit_log.created_at < "+args.add(*request.To))
+	}
+
+	err := repo.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_log "+whereClause(conditions), args.params...).Scan(&response.Total)
+	if err != nil {
+		return nil, fmt.Errorf("count audit entries error: %s", err.Error())
+	}
+
+	var s strings.Builder
+	s.WriteString("SELECT audit_log.id, COALESCE(audit_log.id_profile, 0), audit_log.action, audit_log.entity, " +
+		"COALESCE(audit_log.entity_id, 0), audit_log.before, audit_log.after, audit_log.ip, audit_log.request_id, " +
+		"audit_log.created_at FROM audit_log ")
+	s.WriteString(whereClause(conditions))
+	s.WriteString("ORDER BY audit_log.created_at DESC, audit_log.id DESC ")
+	s.WriteString("OFFSET " + args.add(utils.PageOffset(request.Page, request.PerPage)) + " LIMIT " + args.add(request.PerPage))
+
+	rows, err := repo.db.QueryContext(ctx, s.String(), args.params...)
+	if err != nil {
+		return nil, fmt.Errorf("sql request find audit entries error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.AuditEntry
+		var before, after []byte
+
+		err := rows.Scan(&entry.Id, &entry.UserId, &entry.Action, &entry.Entity, &entry.EntityId, &before, &after,
+			&entry.Ip, &entry.RequestId, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("sql scan audit entries error: %s", err.Error())
+		}
+		entry.Before, entry.After = before, after
+		response.Entries = append(response.Entries, entry)
+	}
+
+	return response, nil
+}
+
+// nullId stores a zero id as NULL.
+func nullId(id uint64) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
+
+// nullJson stores an absent JSON document as NULL.
+func nullJson(document []byte) sql.NullString {
+	return sql.NullString{String: string(document), Valid: len(document) > 0}
+}
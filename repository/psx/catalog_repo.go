@@ -0,0 +1,278 @@
+package psx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmoteka/pkg/catalog"
+	"filmoteka/pkg/models"
+	"fmt"
+	"strconv"
+)
+
+// exportBatchSize is the number of rows fetched from an export cursor at once.
+const exportBatchSize = 500
+
+// ImportCatalog stores catalog in a single transaction. Films are matched to
+// existing ones by title and release date and actors by name and birthday, so
+// importing the same file twice creates nothing new. Links to films or actors
+// that can't be found are reported, and the transaction is committed only when
+// there are no such errors and dryRun is not set.
+func (repo *PsxRepo) ImportCatalog(ctx context.Context, catalog *models.Catalog, dryRun bool) (*models.ImportReport, error) {
+	report := &models.ImportReport{DryRun: dryRun, Errors: []models.ImportError{}}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("import begin error: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	for _, film := range catalog.Films {
+		_, created, err := importFilm(ctx, tx, &film)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", film.Source, err.Error())
+		}
+
+		if created {
+			report.FilmsCreated++
+		} else {
+			report.FilmsMatched++
+		}
+	}
+
+	for _, actor := range catalog.Actors {
+		_, created, err := importActor(ctx, tx, &actor)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", actor.Source, err.Error())
+		}
+
+		if created {
+			report.ActorsCreated++
+		} else {
+			report.ActorsMatched++
+		}
+	}
+
+	for _, link := range catalog.Links {
+		var filmId, actorId uint64
+
+		err := tx.QueryRowContext(ctx, "SELECT film.id FROM film WHERE film.title = $1 AND film.release_date = $2 AND film.deleted_at IS NULL "+
+			"ORDER BY film.id LIMIT 1", link.Title, link.ReleaseDate).Scan(&filmId)
+		if errors.Is(err, sql.ErrNoRows) {
+			report.Errors = append(report.Errors, models.ImportError{Source: link.Source, Message: "film not found"})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: find film error: %s", link.Source, err.Error())
+		}
+
+		err = tx.QueryRowContext(ctx, "SELECT actor.id FROM actor WHERE actor.name = $1 AND actor.birthdate = $2 AND actor.deleted_at IS NULL "+
+			"ORDER BY actor.id LIMIT 1", link.Name, link.Birthday).Scan(&actorId)
+		if errors.Is(err, sql.ErrNoRows) {
+			report.Errors = append(report.Errors, models.ImportError{Source: link.Source, Message: "actor not found"})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: find actor error: %s", link.Source, err.Error())
+		}
+
+		result, err := tx.ExecContext(ctx, "INSERT INTO actor_in_film(id_actor, id_film, role, character_name, billing_order) "+
+			"VALUES($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING", actorId, filmId, link.Role, link.Character, link.BillingOrder)
+		if err != nil {
+			return nil, fmt.Errorf("%s: add link error: %s", link.Source, err.Error())
+		}
+
+		created, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("%s: add link error: %s", link.Source, err.Error())
+		}
+
+		if created > 0 {
+			report.LinksCreated++
+		} else {
+			report.LinksExisting++
+		}
+	}
+
+	if dryRun || len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("import commit error: %s", err.Error())
+	}
+	report.Applied = true
+
+	return report, nil
+}
+
+// importFilm returns the id of the film with the title and release date of
+// record, adding it first if there is none.
+func importFilm(ctx context.Context, tx *sql.Tx, record *models.CatalogRecord) (uint64, bool, error) {
+	var filmId uint64
+
+	err := tx.QueryRowContext(ctx, "SELECT film.id FROM film WHERE film.title = $1 AND film.release_date = $2 AND film.deleted_at IS NULL "+
+		"ORDER BY film.id LIMIT 1", record.Title, record.ReleaseDate).Scan(&filmId)
+	if err == nil {
+		return filmId, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, fmt.Errorf("find film error: %s", err.Error())
+	}
+
+	err = tx.QueryRowContext(ctx, "INSERT INTO film(title, info, release_date) VALUES($1, $2, $3) RETURNING id",
+		record.Title, record.Info, record.ReleaseDate).Scan(&filmId)
+	if err != nil {
+		return 0, false, fmt.Errorf("add film error: %s", err.Error())
+	}
+
+	return filmId, true, nil
+}
+
+// importActor returns the id of the actor with the name and birthday of
+// record, adding it first if there is none.
+func importActor(ctx context.Context, tx *sql.Tx, record *models.CatalogRecord) (uint64, bool, error) {
+	var actorId uint64
+
+	err := tx.QueryRowContext(ctx, "SELECT actor.id FROM actor WHERE actor.name = $1 AND actor.birthdate = $2 AND actor.deleted_at IS NULL "+
+		"ORDER BY actor.id LIMIT 1", record.Name, record.Birthday).Scan(&actorId)
+	if err == nil {
+		return actorId, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, fmt.Errorf("find actor error: %s", err.Error())
+	}
+
+	err = tx.QueryRowContext(ctx, "INSERT INTO actor(name, gen, birthdate) VALUES($1, $2, $3) RETURNING id",
+		record.Name, record.Gender, record.Birthday).Scan(&actorId)
+	if err != nil {
+		return 0, false, fmt.Errorf("add actor error: %s", err.Error())
+	}
+
+	return actorId, true, nil
+}
+
+// ExportCatalog passes to write every film matching request, then the actors
+// credited in them and the links between the two, or the whole catalog when
+// request is nil. Rows are read through server-side cursors inside one
+// read-only transaction, so the export is a consistent snapshot and is never
+// held in memory as a whole.
+func (repo *PsxRepo) ExportCatalog(ctx context.Context, request *models.FindFilmRequest, write func(record *models.CatalogRecord) error) error {
+	tx, err := repo.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("export begin error: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	var filmArgs, actorArgs, linkArgs queryArgs
+	filmFilter := "WHERE film.deleted_at IS NULL "
+	actorFilter := "WHERE actor.deleted_at IS NULL "
+	linkFilter := "WHERE film.deleted_at IS NULL AND actor.deleted_at IS NULL "
+	if request != nil {
+		conditions, _ := filmConditions(request, &filmArgs)
+		filmFilter = whereClause(conditions)
+
+		conditions, _ = filmConditions(request, &actorArgs)
+		actorFilter = "WHERE actor.deleted_at IS NULL AND actor.id IN (SELECT actor_in_film.id_actor FROM actor_in_film " +
+			"JOIN film ON actor_in_film.id_film = film.id " + whereClause(conditions) + ") "
+
+		conditions, _ = filmConditions(request, &linkArgs)
+		linkFilter = whereClause(append(conditions, "actor.deleted_at IS NULL"))
+	}
+
+	err = fetchCursor(ctx, tx, "export_film", "SELECT film.title, film.info, film.release_date::text FROM film "+
+		filmFilter+"ORDER BY film.id", filmArgs.params, func(rows *sql.Rows) error {
+		record := models.CatalogRecord{Type: catalog.RecordFilm}
+
+		err := rows.Scan(&record.Title, &record.Info, &record.ReleaseDate)
+		if err != nil {
+			return fmt.Errorf("export film scan error: %s", err.Error())
+		}
+
+		return write(&record)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = fetchCursor(ctx, tx, "export_actor", "SELECT actor.name, actor.gen, actor.birthdate::text FROM actor "+
+		actorFilter+"ORDER BY actor.id", actorArgs.params, func(rows *sql.Rows) error {
+		record := models.CatalogRecord{Type: catalog.RecordActor}
+
+		err := rows.Scan(&record.Name, &record.Gender, &record.Birthday)
+		if err != nil {
+			return fmt.Errorf("export actor scan error: %s", err.Error())
+		}
+
+		return write(&record)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = fetchCursor(ctx, tx, "export_link", "SELECT film.title, film.release_date::text, actor.name, actor.birthdate::text, "+
+		"actor_in_film.role, actor_in_film.character_name, actor_in_film.billing_order FROM actor_in_film "+
+		"JOIN film ON actor_in_film.id_film = film.id "+
+		"JOIN actor ON actor_in_film.id_actor = actor.id "+
+		linkFilter+"ORDER BY film.id, actor_in_film.billing_order, actor.id, actor_in_film.role", linkArgs.params, func(rows *sql.Rows) error {
+		record := models.CatalogRecord{Type: catalog.RecordLink}
+
+		err := rows.Scan(&record.Title, &record.ReleaseDate, &record.Name, &record.Birthday,
+			&record.Role, &record.Character, &record.BillingOrder)
+		if err != nil {
+			return fmt.Errorf("export link scan error: %s", err.Error())
+		}
+
+		return write(&record)
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// fetchCursor declares a cursor named name for query in tx and hands its rows
+// to scan, fetching exportBatchSize of them at a time.
+func fetchCursor(ctx context.Context, tx *sql.Tx, name string, query string, args []any, scan func(rows *sql.Rows) error) error {
+	_, err := tx.ExecContext(ctx, "DECLARE "+name+" NO SCROLL CURSOR FOR "+query, args...)
+	if err != nil {
+		return fmt.Errorf("declare cursor %s error: %s", name, err.Error())
+	}
+
+	for {
+		rows, err := tx.QueryContext(ctx, "FETCH "+strconv.Itoa(exportBatchSize)+" FROM "+name)
+		if err != nil {
+			return fmt.Errorf("fetch cursor %s error: %s", name, err.Error())
+		}
+
+		fetched := 0
+		for rows.Next() {
+			fetched++
+
+			err = scan(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+		}
+
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("fetch cursor %s error: %s", name, err.Error())
+		}
+
+		if fetched < exportBatchSize {
+			break
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "CLOSE "+name)
+	if err != nil {
+		return fmt.Errorf("close cursor %s error: %s", name, err.Error())
+	}
+
+	return nil
+}
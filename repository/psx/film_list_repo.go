@@ -0,0 +1,115 @@
+package psx
+
+import (
+	"context"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/images"
+	"filmoteka/pkg/models"
+	"fmt"
+)
+
+// AddToList puts filmId into the list of userId, found is false when the film does not exist.
+func (repo *PsxRepo) AddToList(ctx context.Context, userId uint64, filmId uint64, list string) (bool, error) {
+	result, err := repo.db.ExecContext(ctx, "INSERT INTO film_list(id_profile, id_film, list) "+
+		"SELECT $1, film.id, $3 FROM film WHERE film.id = $2 AND film.deleted_at IS NULL ON CONFLICT DO NOTHING", userId, filmId, list)
+	if err != nil {
+		return false, fmt.Errorf("add film to list error: %s", err.Error())
+	}
+
+	added, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("add film to list error: %s", err.Error())
+	}
+
+	if added > 0 {
+		return true, nil
+	}
+
+	_, found, err := repo.GetFilm(ctx, filmId)
+	return found, err
+}
+
+func (repo *PsxRepo) DeleteFromList(ctx context.Context, userId uint64, filmId uint64, list string) (bool, error) {
+	result, err := repo.db.ExecContext(ctx, "DELETE FROM film_list WHERE id_profile = $1 AND id_film = $2 AND list = $3",
+		userId, filmId, list)
+	if err != nil {
+		return false, fmt.Errorf("delete film from list error: %s", err.Error())
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("delete film from list error: %s", err.Error())
+	}
+
+	return deleted > 0, nil
+}
+
+func (repo *PsxRepo) FindList(ctx context.Context, userId uint64, list string, page uint64, perPage uint64) (*models.FilmsResponse, error) {
+	var total int
+
+	err := repo.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM film_list "+
+		"JOIN film ON film_list.id_film = film.id "+
+		"WHERE film_list.id_profile = $1 AND film_list.list = $2 AND film.deleted_at IS NULL", userId, list).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("count list films error: %s", err.Error())
+	}
+
+	rows, err := repo.db.QueryContext(ctx, "SELECT film.id, film.title, film.info, film.rating, film.votes, film.release_date, film.poster FROM film "+
+		"JOIN film_list ON film_list.id_film = film.id "+
+		"WHERE film_list.id_profile = $1 AND film_list.list = $2 AND film.deleted_at IS NULL "+
+		"ORDER BY film_list.created_at DESC, film.id DESC OFFSET $3 LIMIT $4", userId, list, utils.PageOffset(page, perPage), perPage)
+	if err != nil {
+		return nil, fmt.Errorf("sql request find list films error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	films := make([]models.FilmItem, 0, perPage)
+	for rows.Next() {
+		post := models.FilmItem{}
+
+		err := rows.Scan(&post.Id, &post.Title, &post.Info, &post.Rating, &post.Votes, &post.ReleaseDate,
+			imageColumn{images.DirFilms, &post.Poster})
+		if err != nil {
+			return nil, fmt.Errorf("sql scan list films error: %s", err.Error())
+		}
+		films = append(films, post)
+	}
+
+	return &models.FilmsResponse{
+		Total:   total,
+		Page:    max(page, 1),
+		PerPage: perPage,
+		Films:   &films,
+	}, nil
+}
+
+// FindListsByFilms returns the lists of userId that contain each of filmIds.
+func (repo *PsxRepo) FindListsByFilms(ctx context.Context, userId uint64, filmIds []uint64) (map[uint64][]string, error) {
+	lists := make(map[uint64][]string)
+	if len(filmIds) == 0 {
+		return lists, nil
+	}
+
+	var args queryArgs
+	user := args.add(userId)
+
+	rows, err := repo.db.QueryContext(ctx, "SELECT film_list.id_film, film_list.list FROM film_list "+
+		"WHERE film_list.id_profile = "+user+" AND film_list.id_film IN ("+args.addList(filmIds)+")", args.params...)
+	if err != nil {
+		return nil, fmt.Errorf("sql request find film lists error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var filmId uint64
+		var list string
+
+		err := rows.Scan(&filmId, &list)
+		if err != nil {
+			return nil, fmt.Errorf("sql scan film lists error: %s", err.Error())
+		}
+		lists[filmId] = append(lists[filmId], list)
+	}
+
+	return lists, nil
+}
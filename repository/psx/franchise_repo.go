@@ -0,0 +1,406 @@
+package psx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/images"
+	"filmoteka/pkg/models"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// franchiseOrders are the orders of the films of a franchise: the story,
+// where films at the same position follow each other by release, and the
+// release dates alone.
+var franchiseOrders = map[string]string{
+	utils.FranchiseOrderChronology: "film_in_franchise.position, film.release_date, film.id",
+	utils.FranchiseOrderRelease:    "film.release_date, film.id",
+}
+
+// franchiseFilmsCount is the number of films of a franchise not in the trash.
+const franchiseFilmsCount = "(SELECT COUNT(*) FROM film_in_franchise JOIN film ON film_in_franchise.id_film = film.id " +
+	"WHERE film_in_franchise.id_franchise = franchise.id AND film.deleted_at IS NULL)"
+
+func (repo *PsxRepo) AddFranchise(ctx context.Context, franchise *models.FranchiseRequest) (uint64, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("add franchise begin error: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, "INSERT INTO franchise(name, info) VALUES($1, $2) RETURNING id",
+		franchise.Name, franchise.Info).Scan(&franchise.Id)
+	if err != nil {
+		return 0, fmt.Errorf("add franchise error: %s", err.Error())
+	}
+
+	err = addFranchiseFilms(ctx, tx, franchise.Id, franchise.Films)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("add franchise commit error: %s", err.Error())
+	}
+
+	return franchise.Id, nil
+}
+
+// UpdateFranchise changes the name and the description of the franchise and
+// replaces its films when they are given. Found is false when there is no
+// such franchise.
+func (repo *PsxRepo) UpdateFranchise(ctx context.Context, franchise *models.FranchiseRequest) (bool, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("update franchise begin error: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "UPDATE franchise SET name = $2, info = $3 WHERE franchise.id = $1",
+		franchise.Id, franchise.Name, franchise.Info)
+	if err != nil {
+		return false, fmt.Errorf("update franchise error: %s", err.Error())
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("update franchise error: %s", err.Error())
+	}
+
+	if updated == 0 {
+		return false, nil
+	}
+
+	if franchise.Films != nil {
+		_, err = tx.ExecContext(ctx, "DELETE FROM film_in_franchise WHERE film_in_franchise.id_franchise = $1", franchise.Id)
+		if err != nil {
+			return false, fmt.Errorf("delete franchise films error: %s", err.Error())
+		}
+
+		err = addFranchiseFilms(ctx, tx, franchise.Id, franchise.Films)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("update franchise commit error: %s", err.Error())
+	}
+
+	return true, nil
+}
+
+// addFranchiseFilms puts the films into the franchise, ids of films that do
+// not exist are skipped and a film listed twice keeps its first position.
+func addFranchiseFilms(ctx context.Context, tx *sql.Tx, franchiseId uint64, films []models.FranchiseFilmRequest) error {
+	if len(films) == 0 {
+		return nil
+	}
+
+	var args queryArgs
+	franchise := args.add(franchiseId)
+
+	listed := make(map[uint64]bool, len(films))
+	values := make([]string, 0, len(films))
+	for _, film := range films {
+		if listed[film.FilmId] {
+			continue
+		}
+		listed[film.FilmId] = true
+		values = append(values, "("+args.add(film.FilmId)+"::integer, "+args.add(film.Position)+"::integer)")
+	}
+
+	_, err := tx.ExecContext(ctx, "INSERT INTO film_in_franchise(id_franchise, id_film, position) "+
+		"SELECT "+franchise+", film.id, entry.position FROM (VALUES "+strings.Join(values, ", ")+") AS entry(id_film, position) "+
+		"JOIN film ON film.id = entry.id_film ON CONFLICT DO NOTHING", args.params...)
+	if err != nil {
+		return fmt.Errorf("add franchise films error: %s", err.Error())
+	}
+
+	return nil
+}
+
+// DeleteFranchise removes the franchise, the films stay. Deleted is false
+// when there is no such franchise.
+func (repo *PsxRepo) DeleteFranchise(ctx context.Context, franchiseId uint64) (bool, error) {
+	result, err := repo.db.ExecContext(ctx, "DELETE FROM franchise WHERE franchise.id = $1", franchiseId)
+	if err != nil {
+		return false, fmt.Errorf("delete franchise error: %s", err.Error())
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("delete franchise error: %s", err.Error())
+	}
+
+	return deleted > 0, nil
+}
+
+func (repo *PsxRepo) FindFranchise(ctx context.Context, name string) (bool, error) {
+	var id uint64
+
+	err := repo.db.QueryRowContext(ctx, "SELECT franchise.id FROM franchise WHERE franchise.name = $1", name).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("find franchise error: %s", err.Error())
+	}
+
+	return true, nil
+}
+
+// FindFranchises lists the franchises by name.
+func (repo *PsxRepo) FindFranchises(ctx context.Context, page uint64, perPage uint64) (*models.FranchisesResponse, error) {
+	response := &models.FranchisesResponse{Page: max(page, 1), PerPage: perPage, Franchises: make([]models.FranchiseItem, 0, perPage)}
+
+	err := repo.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM franchise").Scan(&response.Total)
+	if err != nil {
+		return nil, fmt.Errorf("count franchises error: %s", err.Error())
+	}
+
+	rows, err := repo.db.QueryContext(ctx, "SELECT franchise.id, franchise.name, franchise.info, "+franchiseFilmsCount+" FROM franchise "+
+		"ORDER BY franchise.name, franchise.id OFFSET $1 LIMIT $2", utils.PageOffset(page, perPage), perPage)
+	if err != nil {
+		return nil, fmt.Errorf("sql request find franchises error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var franchise models.FranchiseItem
+
+		err := rows.Scan(&franchise.Id, &franchise.Name, &franchise.Info, &franchise.FilmsCount)
+		if err != nil {
+			return nil, fmt.Errorf("sql scan franchises error: %s", err.Error())
+		}
+		response.Franchises = append(response.Franchises, franchise)
+	}
+
+	return response, nil
+}
+
+// GetFranchise returns the franchise with its films in order, one of
+// utils.FranchiseOrders. Found is false when there is no such franchise.
+func (repo *PsxRepo) GetFranchise(ctx context.Context, franchiseId uint64, order string) (*models.FranchiseResponse, bool, error) {
+	orderBy, found := franchiseOrders[order]
+	if !found {
+		return nil, false, fmt.Errorf("unknown franchise order %s", order)
+	}
+
+	franchise := &models.FranchiseResponse{Order: order, Films: make([]models.FranchiseFilmItem, 0)}
+
+	err := repo.db.QueryRowContext(ctx, "SELECT franchise.id, franchise.name, franchise.info FROM franchise "+
+		"WHERE franchise.id = $1", franchiseId).Scan(&franchise.Id, &franchise.Name, &franchise.Info)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("get franchise error: %s", err.Error())
+	}
+
+	rows, err := repo.db.QueryContext(ctx, "SELECT film.id, film.title, film.info, film.rating, film.votes, film.release_date, film.poster, "+
+		"film_in_franchise.position FROM film_in_franchise JOIN film ON film_in_franchise.id_film = film.id "+
+		"WHERE film_in_franchise.id_franchise = $1 AND film.deleted_at IS NULL ORDER BY "+orderBy, franchiseId)
+	if err != nil {
+		return nil, false, fmt.Errorf("sql request find franchise films error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var film models.FranchiseFilmItem
+
+		err := rows.Scan(&film.Id, &film.Title, &film.Info, &film.Rating, &film.Votes, &film.ReleaseDate,
+			imageColumn{images.DirFilms, &film.Poster}, &film.Position)
+		if err != nil {
+			return nil, false, fmt.Errorf("sql scan franchise films error: %s", err.Error())
+		}
+		franchise.Films = append(franchise.Films, film)
+	}
+
+	return franchise, true, nil
+}
+
+// FindFranchisesByFilm returns the franchises of the film with the films
+// before and after it in the story.
+func (repo *PsxRepo) FindFranchisesByFilm(ctx context.Context, filmId uint64) ([]models.FilmFranchiseItem, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT franchise.id, franchise.name, entry.position, entry.previous, entry.next "+
+		"FROM (SELECT film_in_franchise.id_franchise, film_in_franchise.id_film, film_in_franchise.position, "+
+		"LAG(film.id) OVER story AS previous, LEAD(film.id) OVER story AS next FROM film_in_franchise "+
+		"JOIN film ON film_in_franchise.id_film = film.id "+
+		"WHERE film.deleted_at IS NULL AND film_in_franchise.id_franchise IN "+
+		"(SELECT film_in_franchise.id_franchise FROM film_in_franchise WHERE film_in_franchise.id_film = $1) "+
+		"WINDOW story AS (PARTITION BY film_in_franchise.id_franchise ORDER BY "+franchiseOrders[utils.FranchiseOrderChronology]+")) AS entry "+
+		"JOIN franchise ON franchise.id = entry.id_franchise "+
+		"WHERE entry.id_film = $1 ORDER BY franchise.name, franchise.id", filmId)
+	if err != nil {
+		return nil, fmt.Errorf("sql request find film franchises error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	franchises := make([]models.FilmFranchiseItem, 0)
+	var previousIds, nextIds []sql.NullInt64
+	var filmIds []uint64
+	for rows.Next() {
+		var franchise models.FilmFranchiseItem
+		var previous, next sql.NullInt64
+
+		err := rows.Scan(&franchise.Id, &franchise.Name, &franchise.Position, &previous, &next)
+		if err != nil {
+			return nil, fmt.Errorf("sql scan film franchises error: %s", err.Error())
+		}
+
+		if previous.Valid {
+			filmIds = append(filmIds, uint64(previous.Int64))
+		}
+		if next.Valid {
+			filmIds = append(filmIds, uint64(next.Int64))
+		}
+		franchises = append(franchises, franchise)
+		previousIds = append(previousIds, previous)
+		nextIds = append(nextIds, next)
+	}
+
+	films, err := repo.filmItems(ctx, filmIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range franchises {
+		if previousIds[i].Valid {
+			franchises[i].Previous = films[uint64(previousIds[i].Int64)]
+		}
+		if nextIds[i].Valid {
+			franchises[i].Next = films[uint64(nextIds[i].Int64)]
+		}
+	}
+
+	return franchises, nil
+}
+
+// AddFilmRelation links the films, an existing link is kept as it is. Found
+// is false when either film does not exist.
+func (repo *PsxRepo) AddFilmRelation(ctx context.Context, relation *models.FilmRelationRequest) (bool, error) {
+	var count int
+
+	err := repo.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM film "+
+		"WHERE film.id IN ($1, $2) AND film.deleted_at IS NULL", relation.FilmId, relation.RelatedId).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("find related films error: %s", err.Error())
+	}
+
+	if count < 2 {
+		return false, nil
+	}
+
+	_, err = repo.db.ExecContext(ctx, "INSERT INTO film_relation(id_film, id_related, kind) VALUES($1, $2, $3) "+
+		"ON CONFLICT DO NOTHING", relation.FilmId, relation.RelatedId, relation.Kind)
+	if err != nil {
+		return false, fmt.Errorf("add film relation error: %s", err.Error())
+	}
+
+	return true, nil
+}
+
+// DeleteFilmRelation removes the link, deleted is false when there is none.
+func (repo *PsxRepo) DeleteFilmRelation(ctx context.Context, relation *models.FilmRelationRequest) (bool, error) {
+	result, err := repo.db.ExecContext(ctx, "DELETE FROM film_relation WHERE film_relation.id_film = $1 "+
+		"AND film_relation.id_related = $2 AND film_relation.kind = $3", relation.FilmId, relation.RelatedId, relation.Kind)
+	if err != nil {
+		return false, fmt.Errorf("delete film relation error: %s", err.Error())
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("delete film relation error: %s", err.Error())
+	}
+
+	return deleted > 0, nil
+}
+
+// FindRelationsByFilm returns the films linked to the film either way. For
+// links made from the other film the kind is turned around with
+// utils.FilmRelationInverse, so that it always tells what the related film
+// is to this one.
+func (repo *PsxRepo) FindRelationsByFilm(ctx context.Context, filmId uint64) ([]models.FilmRelationItem, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT film_relation.kind, film_relation.id_related, FALSE FROM film_relation "+
+		"WHERE film_relation.id_film = $1 "+
+		"UNION ALL SELECT film_relation.kind, film_relation.id_film, TRUE FROM film_relation "+
+		"WHERE film_relation.id_related = $1", filmId)
+	if err != nil {
+		return nil, fmt.Errorf("sql request find film relations error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	var kinds []string
+	var filmIds []uint64
+	for rows.Next() {
+		var kind string
+		var relatedId uint64
+		var inverse bool
+
+		err := rows.Scan(&kind, &relatedId, &inverse)
+		if err != nil {
+			return nil, fmt.Errorf("sql scan film relations error: %s", err.Error())
+		}
+
+		if inverse {
+			kind = utils.FilmRelationInverse[kind]
+		}
+		kinds = append(kinds, kind)
+		filmIds = append(filmIds, relatedId)
+	}
+
+	films, err := repo.filmItems(ctx, filmIds)
+	if err != nil {
+		return nil, err
+	}
+
+	relations := make([]models.FilmRelationItem, 0, len(filmIds))
+	for i, id := range filmIds {
+		film, found := films[id]
+		if found {
+			relations = append(relations, models.FilmRelationItem{Kind: kinds[i], Film: *film})
+		}
+	}
+
+	slices.SortStableFunc(relations, func(a, b models.FilmRelationItem) int {
+		return strings.Compare(a.Film.ReleaseDate, b.Film.ReleaseDate)
+	})
+
+	return relations, nil
+}
+
+// filmItems returns the films with the given ids that are not in the trash.
+func (repo *PsxRepo) filmItems(ctx context.Context, filmIds []uint64) (map[uint64]*models.FilmItem, error) {
+	films := make(map[uint64]*models.FilmItem, len(filmIds))
+	if len(filmIds) == 0 {
+		return films, nil
+	}
+
+	var args queryArgs
+
+	rows, err := repo.db.QueryContext(ctx, "SELECT film.id, film.title, film.info, film.rating, film.votes, film.release_date, film.poster "+
+		"FROM film WHERE film.id IN ("+args.addList(filmIds)+") AND film.deleted_at IS NULL", args.params...)
+	if err != nil {
+		return nil, fmt.Errorf("sql request find films by id error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		film := &models.FilmItem{}
+
+		err := rows.Scan(&film.Id, &film.Title, &film.Info, &film.Rating, &film.Votes, &film.ReleaseDate,
+			imageColumn{images.DirFilms, &film.Poster})
+		if err != nil {
+			return nil, fmt.Errorf("sql scan films by id error: %s", err.Error())
+		}
+		films[film.Id] = film
+	}
+
+	return films, nil
+}
@@ -0,0 +1,122 @@
+package psx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/models"
+	"fmt"
+)
+
+// AddGenre stores the genre, it returns utils.ErrGenreExists when there is a
+// genre with the name already.
+func (repo *PsxRepo) AddGenre(ctx context.Context, genre *models.GenreItem) (uint64, error) {
+	err := repo.db.QueryRowContext(ctx, "INSERT INTO genre(name) VALUES($1) ON CONFLICT (name) DO NOTHING RETURNING id",
+		genre.Name).Scan(&genre.Id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, utils.ErrGenreExists
+		}
+		return 0, fmt.Errorf("add genre error: %s", err.Error())
+	}
+
+	return genre.Id, nil
+}
+
+func (repo *PsxRepo) FindGenres(ctx context.Context) ([]models.GenreItem, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT genre.id, genre.name FROM genre ORDER BY genre.name")
+	if err != nil {
+		return nil, fmt.Errorf("sql request find genres error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	genres := make([]models.GenreItem, 0)
+	for rows.Next() {
+		var genre models.GenreItem
+
+		err := rows.Scan(&genre.Id, &genre.Name)
+		if err != nil {
+			return nil, fmt.Errorf("sql scan genres error: %s", err.Error())
+		}
+		genres = append(genres, genre)
+	}
+
+	return genres, nil
+}
+
+func (repo *PsxRepo) FindGenre(ctx context.Context, name string) (bool, error) {
+	var id uint64
+
+	err := repo.db.QueryRowContext(ctx, "SELECT genre.id FROM genre WHERE genre.name = $1", name).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("find genre error: %s", err.Error())
+	}
+
+	return true, nil
+}
+
+// GetGenre returns the genre with genreId, found is false when there is none.
+func (repo *PsxRepo) GetGenre(ctx context.Context, genreId uint64) (*models.GenreItem, bool, error) {
+	genre := &models.GenreItem{}
+
+	err := repo.db.QueryRowContext(ctx, "SELECT genre.id, genre.name FROM genre WHERE genre.id = $1", genreId).Scan(&genre.Id, &genre.Name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("get genre error: %s", err.Error())
+	}
+
+	return genre, true, nil
+}
+
+// UpdateGenre renames the genre, found is false when there is no such genre.
+// It returns utils.ErrGenreExists when another genre has the name already.
+func (repo *PsxRepo) UpdateGenre(ctx context.Context, genre *models.GenreItem) (bool, error) {
+	if genre.Id == 0 {
+		return false, fmt.Errorf("genre id missing")
+	}
+
+	var taken bool
+	err := repo.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM genre WHERE genre.name = $1 AND genre.id <> $2)",
+		genre.Name, genre.Id).Scan(&taken)
+	if err != nil {
+		return false, fmt.Errorf("find genre name error: %s", err.Error())
+	}
+
+	if taken {
+		return false, utils.ErrGenreExists
+	}
+
+	result, err := repo.db.ExecContext(ctx, "UPDATE genre SET name = $1 WHERE genre.id = $2", genre.Name, genre.Id)
+	if err != nil {
+		return false, fmt.Errorf("update genre error: %s", err.Error())
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("update genre error: %s", err.Error())
+	}
+
+	return updated > 0, nil
+}
+
+// DeleteGenre removes the genre from the catalog and its films, deleted is
+// false when there is no such genre.
+func (repo *PsxRepo) DeleteGenre(ctx context.Context, genreId uint64) (bool, error) {
+	result, err := repo.db.ExecContext(ctx, "DELETE FROM genre WHERE genre.id = $1", genreId)
+	if err != nil {
+		return false, fmt.Errorf("sql exec error: %s", err.Error())
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("sql exec error: %s", err.Error())
+	}
+
+	return deleted > 0, nil
+}
@@ -0,0 +1,85 @@
+package psx
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+	"fmt"
+)
+
+// FindCastLinks returns which actors are credited in which films, once per
+// actor and film whatever their roles. Actors and films in the trash are left
+// out.
+func (repo *PsxRepo) FindCastLinks(ctx context.Context) ([]models.CastLink, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT DISTINCT actor_in_film.id_actor, actor_in_film.id_film FROM actor_in_film "+
+		"JOIN actor ON actor.id = actor_in_film.id_actor JOIN film ON film.id = actor_in_film.id_film "+
+		"WHERE actor.deleted_at IS NULL AND film.deleted_at IS NULL")
+	if err != nil {
+		return nil, fmt.Errorf("sql request find cast links error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	links := make([]models.CastLink, 0)
+	for rows.Next() {
+		var link models.CastLink
+
+		err := rows.Scan(&link.ActorId, &link.FilmId)
+		if err != nil {
+			return nil, fmt.Errorf("sql scan cast links error: %s", err.Error())
+		}
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+// FindActorNames returns the names of the actors with the given ids that are
+// not in the trash.
+func (repo *PsxRepo) FindActorNames(ctx context.Context, actorIds []uint64) (map[uint64]string, error) {
+	names, err := repo.findNames(ctx, "SELECT actor.id, actor.name FROM actor WHERE actor.deleted_at IS NULL AND actor.id IN ", actorIds)
+	if err != nil {
+		return nil, fmt.Errorf("find actor names error: %s", err.Error())
+	}
+
+	return names, nil
+}
+
+// FindFilmTitles returns the titles of the films with the given ids that are
+// not in the trash.
+func (repo *PsxRepo) FindFilmTitles(ctx context.Context, filmIds []uint64) (map[uint64]string, error) {
+	titles, err := repo.findNames(ctx, "SELECT film.id, film.title FROM film WHERE film.deleted_at IS NULL AND film.id IN ", filmIds)
+	if err != nil {
+		return nil, fmt.Errorf("find film titles error: %s", err.Error())
+	}
+
+	return titles, nil
+}
+
+// findNames runs query, which selects ids and names and ends with the IN of
+// ids left to fill in.
+func (repo *PsxRepo) findNames(ctx context.Context, query string, ids []uint64) (map[uint64]string, error) {
+	names := make(map[uint64]string, len(ids))
+	if len(ids) == 0 {
+		return names, nil
+	}
+
+	var args queryArgs
+
+	rows, err := repo.db.QueryContext(ctx, query+"("+args.addList(ids)+")", args.params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uint64
+		var name string
+
+		err := rows.Scan(&id, &name)
+		if err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+
+	return names, nil
+}
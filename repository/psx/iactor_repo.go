@@ -7,7 +7,9 @@ import (
 
 type IActorRepo interface {
 	AddActor(ctx context.Context, actor *models.ActorItem) (uint64, error)
-	FindActors(ctx context.Context, page uint64, perPage uint64) ([]models.ActorResponse, error)
-	UpdateActor(ctx context.Context, actor *models.ActorRequest) error
-	DeleteActor(ctx context.Context, actorId uint64) error
+	FindActors(ctx context.Context, request *models.FindActorRequest) (*models.ActorsResponse, error)
+	GetActor(ctx context.Context, actorId uint64) (*models.ActorResponse, bool, error)
+	FindFilmsByActor(ctx context.Context, actorId uint64) ([]models.ActorCreditItem, error)
+	UpdateActor(ctx context.Context, actor *models.ActorRequest) (bool, error)
+	DeleteActor(ctx context.Context, actorId uint64) (bool, error)
 }
@@ -0,0 +1,11 @@
+package psx
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type IAuditRepo interface {
+	AddAuditEntry(ctx context.Context, entry *models.AuditEntry) error
+	FindAuditEntries(ctx context.Context, request *models.FindAuditRequest) (*models.AuditResponse, error)
+}
@@ -0,0 +1,11 @@
+package psx
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type ICatalogRepo interface {
+	ImportCatalog(ctx context.Context, catalog *models.Catalog, dryRun bool) (*models.ImportReport, error)
+	ExportCatalog(ctx context.Context, request *models.FindFilmRequest, write func(record *models.CatalogRecord) error) error
+}
@@ -0,0 +1,13 @@
+package psx
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type IFilmListRepo interface {
+	AddToList(ctx context.Context, userId uint64, filmId uint64, list string) (bool, error)
+	DeleteFromList(ctx context.Context, userId uint64, filmId uint64, list string) (bool, error)
+	FindList(ctx context.Context, userId uint64, list string, page uint64, perPage uint64) (*models.FilmsResponse, error)
+	FindListsByFilms(ctx context.Context, userId uint64, filmIds []uint64) (map[uint64][]string, error)
+}
@@ -6,10 +6,14 @@ import (
 )
 
 type IFilmRepo interface {
-	GetFilms(ctx context.Context, request *models.FindFilmRequest) (*[]models.FilmItem, error)
-	AddFilm(ctx context.Context, film *models.FilmRequest) (uint64, error)
-	AddActorsForFilm(ctx context.Context, filmId uint64, actors []uint64) error
-	SearchFilms(ctx context.Context, titleFilm string, nameActor string, page uint64, perPage uint64) ([]models.FilmItem, error)
-	UpdateFilm(ctx context.Context, film *models.FilmRequest) error
+	GetFilms(ctx context.Context, request *models.FindFilmRequest) (*models.FilmsResponse, error)
+	GetFilm(ctx context.Context, filmId uint64) (*models.FilmItem, bool, error)
+	FindCreditsByFilm(ctx context.Context, filmId uint64) ([]models.FilmCreditItem, error)
+	FindGenresByFilm(ctx context.Context, filmId uint64) ([]models.GenreItem, error)
+	FindFranchisesByFilm(ctx context.Context, filmId uint64) ([]models.FilmFranchiseItem, error)
+	FindRelationsByFilm(ctx context.Context, filmId uint64) ([]models.FilmRelationItem, error)
+	AddFilm(ctx context.Context, film *models.FilmRequest, actors []uint64) (uint64, error)
+	SearchFilms(ctx context.Context, request *models.FindFilmRequest) (*models.FilmsResponse, error)
+	UpdateFilm(ctx context.Context, film *models.FilmRequest) (bool, error)
 	DeleteFilm(ctx context.Context, filmId uint64) (bool, error)
 }
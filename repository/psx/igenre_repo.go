@@ -0,0 +1,15 @@
+package psx
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type IGenreRepo interface {
+	AddGenre(ctx context.Context, genre *models.GenreItem) (uint64, error)
+	FindGenres(ctx context.Context) ([]models.GenreItem, error)
+	FindGenre(ctx context.Context, name string) (bool, error)
+	GetGenre(ctx context.Context, genreId uint64) (*models.GenreItem, bool, error)
+	UpdateGenre(ctx context.Context, genre *models.GenreItem) (bool, error)
+	DeleteGenre(ctx context.Context, genreId uint64) (bool, error)
+}
@@ -0,0 +1,13 @@
+package psx
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type IGraphRepo interface {
+	FindCastLinks(ctx context.Context) ([]models.CastLink, error)
+	FindActorNames(ctx context.Context, actorIds []uint64) (map[uint64]string, error)
+	FindFilmTitles(ctx context.Context, filmIds []uint64) (map[uint64]string, error)
+	GetCatalogVersion(ctx context.Context, name string) (uint64, error)
+}
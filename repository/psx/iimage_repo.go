@@ -0,0 +1,10 @@
+package psx
+
+import (
+	"context"
+)
+
+type IImageRepo interface {
+	SetFilmPoster(ctx context.Context, filmId uint64, name string) (string, bool, error)
+	SetActorPhoto(ctx context.Context, actorId uint64, name string) (string, bool, error)
+}
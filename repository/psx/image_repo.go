@@ -0,0 +1,75 @@
+package psx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmoteka/pkg/images"
+	"filmoteka/pkg/models"
+	"fmt"
+)
+
+// imageColumn scans a poster or photo column into the URLs of the image in
+// dir, they stay nil when the column is NULL.
+type imageColumn struct {
+	dir  string
+	urls **models.ImageUrls
+}
+
+func (c imageColumn) Scan(value any) error {
+	switch name := value.(type) {
+	case nil:
+		*c.urls = nil
+	case string:
+		*c.urls = images.Urls(c.dir, name)
+	case []byte:
+		*c.urls = images.Urls(c.dir, string(name))
+	default:
+		return fmt.Errorf("scan image column error: unexpected %T", value)
+	}
+
+	return nil
+}
+
+// SetFilmPoster makes name the poster of the film and returns the name of the
+// poster it replaces, empty when there was none. Found is false when there is
+// no such film.
+func (repo *PsxRepo) SetFilmPoster(ctx context.Context, filmId uint64, name string) (string, bool, error) {
+	return repo.setImage(ctx, "film", "poster", filmId, name)
+}
+
+// SetActorPhoto makes name the photo of the actor, see SetFilmPoster.
+func (repo *PsxRepo) SetActorPhoto(ctx context.Context, actorId uint64, name string) (string, bool, error) {
+	return repo.setImage(ctx, "actor", "photo", actorId, name)
+}
+
+func (repo *PsxRepo) setImage(ctx context.Context, table string, column string, id uint64, name string) (string, bool, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", false, fmt.Errorf("set %s %s begin error: %s", table, column, err.Error())
+	}
+	defer tx.Rollback()
+
+	var previous sql.NullString
+
+	err = tx.QueryRowContext(ctx, "SELECT "+table+"."+column+" FROM "+table+" "+
+		"WHERE "+table+".id = $1 AND "+table+".deleted_at IS NULL FOR UPDATE", id).Scan(&previous)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("get %s %s error: %s", table, column, err.Error())
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE "+table+" SET "+column+" = $2 WHERE "+table+".id = $1", id, name)
+	if err != nil {
+		return "", false, fmt.Errorf("set %s %s error: %s", table, column, err.Error())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return "", false, fmt.Errorf("set %s %s commit error: %s", table, column, err.Error())
+	}
+
+	return previous.String, true, nil
+}
@@ -6,9 +6,11 @@ import (
 )
 
 type IProfileRepo interface {
-	GetUser(ctx context.Context, login string, password []byte) (*models.UserItem, bool, error)
+	GetUserCredentials(ctx context.Context, login string) (*models.UserCredentials, bool, error)
 	FindUser(ctx context.Context, login string) (bool, error)
-	CreateUser(ctx context.Context, login string, password []byte) error
+	CreateUser(ctx context.Context, login string, hash []byte, algo string) error
+	UpdatePassword(ctx context.Context, userId uint64, hash []byte, algo string, previous []byte) error
 	GetUserId(ctx context.Context, login string) (uint64, error)
 	GetRole(ctx context.Context, userId uint64) (string, error)
+	UpdateRole(ctx context.Context, userId uint64, role string) (*models.ProfileItem, string, bool, error)
 }
@@ -0,0 +1,12 @@
+package psx
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type IRatingRepo interface {
+	RateFilm(ctx context.Context, filmId uint64, userId uint64, rating int) error
+	DeleteRating(ctx context.Context, filmId uint64, userId uint64) (bool, error)
+	GetRating(ctx context.Context, filmId uint64, userId uint64) (*models.FilmRatingResponse, bool, error)
+}
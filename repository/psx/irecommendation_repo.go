@@ -0,0 +1,12 @@
+package psx
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type IRecommendationRepo interface {
+	RefreshFilmSimilarity(ctx context.Context, neighbours uint64, minRaters uint64) (int64, error)
+	FindRecommendedFilms(ctx context.Context, userId uint64, limit uint64) ([]models.RecommendedFilmItem, error)
+	FindPopularFilms(ctx context.Context, userId uint64, exclude []uint64, limit uint64) ([]models.RecommendedFilmItem, error)
+}
@@ -0,0 +1,14 @@
+package psx
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type IReviewRepo interface {
+	AddReview(ctx context.Context, filmId uint64, userId uint64, review *models.ReviewRequest) (uint64, bool, error)
+	FindReviews(ctx context.Context, filmId uint64, page uint64, perPage uint64) (*models.ReviewsResponse, error)
+	UpdateReview(ctx context.Context, userId uint64, review *models.ReviewRequest) (bool, error)
+	DeleteReview(ctx context.Context, userId uint64, reviewId uint64) (bool, error)
+	HideReview(ctx context.Context, reviewId uint64, hidden bool) (bool, error)
+}
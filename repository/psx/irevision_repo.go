@@ -0,0 +1,17 @@
+package psx
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type IRevisionRepo interface {
+	AddFilmRevision(ctx context.Context, filmId uint64, userId uint64) (*models.Revision, bool, error)
+	AddActorRevision(ctx context.Context, actorId uint64, userId uint64) (*models.Revision, bool, error)
+	FindFilmRevisions(ctx context.Context, filmId uint64, page uint64, perPage uint64) (*models.RevisionsResponse, bool, error)
+	FindActorRevisions(ctx context.Context, actorId uint64, page uint64, perPage uint64) (*models.RevisionsResponse, bool, error)
+	GetFilmRevision(ctx context.Context, filmId uint64, revision uint64) (*models.Revision, bool, error)
+	GetActorRevision(ctx context.Context, actorId uint64, revision uint64) (*models.Revision, bool, error)
+	RollbackFilm(ctx context.Context, filmId uint64, data []byte) (bool, error)
+	RollbackActor(ctx context.Context, actorId uint64, data []byte) (bool, error)
+}
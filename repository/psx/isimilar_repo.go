@@ -0,0 +1,11 @@
+package psx
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type ISimilarRepo interface {
+	FindSimilarFilms(ctx context.Context, filmId uint64, limit uint64) ([]models.SimilarFilmItem, bool, error)
+	GetCatalogVersion(ctx context.Context, name string) (uint64, error)
+}
@@ -0,0 +1,10 @@
+package psx
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type IStatsRepo interface {
+	GetStats(ctx context.Context, request *models.StatsRequest) (*models.StatsResponse, error)
+}
@@ -0,0 +1,13 @@
+package psx
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type ITokenRepo interface {
+	AddToken(ctx context.Context, userId uint64, name string, hash []byte, scopes []string) (*models.AccessTokenItem, error)
+	FindTokens(ctx context.Context, userId uint64) ([]models.AccessTokenItem, error)
+	RevokeToken(ctx context.Context, userId uint64, tokenId uint64) (*models.AccessTokenItem, bool, error)
+	FindTokenByHash(ctx context.Context, hash []byte) (*models.AccessToken, bool, error)
+}
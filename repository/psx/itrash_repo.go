@@ -0,0 +1,15 @@
+package psx
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+	"time"
+)
+
+type ITrashRepo interface {
+	FindDeletedFilms(ctx context.Context, page uint64, perPage uint64) (*models.FilmsResponse, error)
+	FindDeletedActors(ctx context.Context, page uint64, perPage uint64) (*models.ActorsResponse, error)
+	RestoreFilm(ctx context.Context, filmId uint64) (time.Time, bool, error)
+	RestoreActor(ctx context.Context, actorId uint64) (time.Time, bool, error)
+	PurgeDeleted(ctx context.Context, before time.Time) (*models.PurgeReport, error)
+}
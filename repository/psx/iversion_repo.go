@@ -0,0 +1,9 @@
+package psx
+
+import (
+	"context"
+)
+
+type IVersionRepo interface {
+	BumpCatalogVersions(ctx context.Context, names []string) error
+}
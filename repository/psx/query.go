@@ -0,0 +1,226 @@
+package psx
+
+import (
+	"context"
+	"database/sql"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/models"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// queryArgs collects positional parameters for a dynamically built query.
+type queryArgs struct {
+	params []any
+}
+
+// add appends value to the parameter list and returns its placeholder.
+func (q *queryArgs) add(value any) string {
+	q.params = append(q.params, value)
+	return "$" + strconv.Itoa(len(q.params))
+}
+
+// addList appends every id and returns a comma separated list of placeholders.
+func (q *queryArgs) addList(ids []uint64) string {
+	placeholders := make([]string, 0, len(ids))
+	for _, id := range ids {
+		placeholders = append(placeholders, q.add(id))
+	}
+
+	return strings.Join(placeholders, ", ")
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND ") + " "
+}
+
+// ftsQuery builds a tsquery matching the search text in placeholder in both
+// the Russian and English configurations used by film.fts.
+func ftsQuery(placeholder string) string {
+	return "(websearch_to_tsquery('russian', " + placeholder + ") || websearch_to_tsquery('english', " + placeholder + "))"
+}
+
+// filmConditions translates the filters of request into WHERE conditions on the
+// film table. The full-text query of the title filter is returned as well, so
+// that callers can rank and highlight by it; it is empty without a title.
+func filmConditions(request *models.FindFilmRequest, args *queryArgs) ([]string, string) {
+	conditions := []string{"film.deleted_at IS NULL"}
+	var query string
+
+	if request.Title != "" {
+		query = ftsQuery(args.add(request.Title))
+		conditions = append(conditions, "film.fts @@ "+query)
+	}
+	if request.Actor != "" {
+		conditions = append(conditions, "film.id IN (SELECT actor_in_film.id_film FROM actor_in_film "+
+			"JOIN actor ON actor_in_film.id_actor = actor.id "+
+			"WHERE actor_in_film.role = 'actor' AND actor.deleted_at IS NULL AND actor.name ILIKE '%' || "+args.add(request.Actor)+" || '%')")
+	}
+	if request.Director != "" {
+		conditions = append(conditions, "film.id IN (SELECT actor_in_film.id_film FROM actor_in_film "+
+			"JOIN actor ON actor_in_film.id_actor = actor.id "+
+			"WHERE actor_in_film.role = 'director' AND actor.deleted_at IS NULL AND actor.name ILIKE '%' || "+args.add(request.Director)+" || '%')")
+	}
+	if request.ReleaseDateFrom != "" {
+		conditions = append(conditions, "film.release_date >= "+args.add(request.ReleaseDateFrom))
+	}
+	if request.ReleaseDateTo != "" {
+		conditions = append(conditions, "film.release_date <= "+args.add(request.ReleaseDateTo))
+	}
+	if len(request.Genres) > 0 {
+		conditions = append(conditions, "film.id IN (SELECT genre_in_film.id_film FROM genre_in_film "+
+			"WHERE genre_in_film.id_genre IN ("+args.addList(request.Genres)+"))")
+	}
+
+	conditions = append(conditions, "film.rating >= "+args.add(request.RatingFrom), "film.rating <= "+args.add(request.RatingTo))
+
+	return conditions, query
+}
+
+// querier runs queries on its own or within a transaction, it is either
+// *sql.DB or *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// sortColumn is one expression of a keyset sort key together with the type
+// its text form in a cursor is cast back to, one of the utils.Cursor types.
+type sortColumn struct {
+	expr string
+	cast string
+}
+
+// sortKey is an order usable for keyset pagination. All columns are sorted
+// in the same direction and the last one is unique, so every row has its own
+// position in the order.
+type sortKey struct {
+	name    string
+	columns []sortColumn
+	desc    bool
+}
+
+// selectList returns the key columns as text, to be selected after the row.
+func (k sortKey) selectList() string {
+	list := make([]string, 0, len(k.columns))
+	for _, column := range k.columns {
+		list = append(list, "("+column.expr+")::text")
+	}
+
+	return strings.Join(list, ", ")
+}
+
+// orderBy returns the ORDER BY clause, reversed when reading a page backwards.
+func (k sortKey) orderBy(backward bool) string {
+	direction := " ASC"
+	if k.desc != backward {
+		direction = " DESC"
+	}
+
+	list := make([]string, 0, len(k.columns))
+	for _, column := range k.columns {
+		list = append(list, column.expr+direction)
+	}
+
+	return "ORDER BY " + strings.Join(list, ", ") + " "
+}
+
+// seek returns the condition selecting the rows that follow the cursor in
+// this order, or precede it for a backward cursor.
+func (k sortKey) seek(cursor *models.Cursor, args *queryArgs) (string, error) {
+	if cursor.Sort != k.name || len(cursor.Values) != len(k.columns) {
+		return "", fmt.Errorf("cursor does not match order %s", k.name)
+	}
+
+	columns := make([]string, 0, len(k.columns))
+	values := make([]string, 0, len(k.columns))
+	for i, column := range k.columns {
+		columns = append(columns, column.expr)
+		values = append(values, args.add(cursor.Values[i])+"::"+column.cast)
+	}
+
+	operator := " > "
+	if k.desc != cursor.Backward {
+		operator = " < "
+	}
+
+	return "(" + strings.Join(columns, ", ") + ")" + operator + "(" + strings.Join(values, ", ") + ")", nil
+}
+
+// pageClause limits a keyset query to one page, located by cursor or by the
+// page number when there is none. One extra row is fetched to tell whether
+// the query goes on past the page.
+func pageClause(page uint64, perPage uint64, cursor *models.Cursor, args *queryArgs) string {
+	if cursor != nil {
+		return "LIMIT " + args.add(perPage+1)
+	}
+
+	return "OFFSET " + args.add(utils.PageOffset(page, perPage)) + " LIMIT " + args.add(perPage+1)
+}
+
+// paginate cuts the extra row fetched by pageClause, restores the order of a
+// backward page and returns cursors to the next and previous pages. keys hold
+// the selectList values of items.
+func paginate[T any](key sortKey, page uint64, perPage uint64, cursor *models.Cursor, items []T, keys [][]string) ([]T, string, string) {
+	hasMore := uint64(len(items)) > perPage
+	if hasMore {
+		items, keys = items[:perPage], keys[:perPage]
+	}
+
+	backward := cursor != nil && cursor.Backward
+	if backward {
+		slices.Reverse(items)
+		slices.Reverse(keys)
+	}
+
+	if len(items) == 0 {
+		return items, "", ""
+	}
+
+	hasNext, hasPrev := hasMore, cursor != nil || page > 1
+	if backward {
+		hasNext, hasPrev = true, hasMore
+	}
+
+	var next, prev string
+	if hasNext {
+		next = utils.EncodeCursor(&models.Cursor{Sort: key.name, Values: keys[len(keys)-1]})
+	}
+	if hasPrev {
+		prev = utils.EncodeCursor(&models.Cursor{Sort: key.name, Values: keys[0], Backward: true})
+	}
+
+	return items, next, prev
+}
+
+// actorConditions translates the filters of request into WHERE conditions on
+// the actor table.
+func actorConditions(request *models.FindActorRequest, args *queryArgs) []string {
+	conditions := []string{"actor.deleted_at IS NULL"}
+
+	if request.Name != "" {
+		conditions = append(conditions, "actor.name ILIKE '%' || "+args.add(request.Name)+" || '%'")
+	}
+	if request.Gender != "" {
+		conditions = append(conditions, "actor.gen = "+args.add(request.Gender))
+	}
+	if request.BirthdateFrom != "" {
+		conditions = append(conditions, "actor.birthdate >= "+args.add(request.BirthdateFrom))
+	}
+	if request.BirthdateTo != "" {
+		conditions = append(conditions, "actor.birthdate <= "+args.add(request.BirthdateTo))
+	}
+	if request.FilmId != 0 {
+		conditions = append(conditions, "actor.id IN (SELECT actor_in_film.id_actor FROM actor_in_film "+
+			"WHERE actor_in_film.id_film = "+args.add(request.FilmId)+")")
+	}
+
+	return conditions
+}
@@ -0,0 +1,36 @@
+package psx
+
+import (
+	utils "filmoteka/pkg"
+	"slices"
+	"testing"
+)
+
+// TestSortKeyCursorTypes checks that the cursors the handlers let through are
+// the ones the sort keys read back.
+func TestSortKeyCursorTypes(t *testing.T) {
+	casts := func(key sortKey) []string {
+		list := make([]string, 0, len(key.columns))
+		for _, column := range key.columns {
+			list = append(list, column.cast)
+		}
+
+		return list
+	}
+
+	for sort, types := range utils.FilmCursorTypes {
+		key := filmSortKey(sort, "$1")
+		if key.name != sort || !slices.Equal(casts(key), types) {
+			t.Errorf("film sort %s: key %s with %v, want %v", sort, key.name, casts(key), types)
+		}
+	}
+
+	for sort, types := range utils.ActorCursorTypes {
+		for _, desc := range []bool{false, true} {
+			key := actorSortKey(sort, desc)
+			if !slices.Equal(casts(key), types) {
+				t.Errorf("actor sort %s: %v, want %v", key.name, casts(key), types)
+			}
+		}
+	}
+}
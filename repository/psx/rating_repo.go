@@ -0,0 +1,50 @@
+package psx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmoteka/pkg/models"
+	"fmt"
+)
+
+func (repo *PsxRepo) RateFilm(ctx context.Context, filmId uint64, userId uint64, rating int) error {
+	_, err := repo.db.ExecContext(ctx, "INSERT INTO film_rating(id_film, id_profile, rating) VALUES($1, $2, $3) "+
+		"ON CONFLICT (id_film, id_profile) DO UPDATE SET rating = EXCLUDED.rating, updated_at = now()", filmId, userId, rating)
+	if err != nil {
+		return fmt.Errorf("rate film error: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (repo *PsxRepo) DeleteRating(ctx context.Context, filmId uint64, userId uint64) (bool, error) {
+	result, err := repo.db.ExecContext(ctx, "DELETE FROM film_rating WHERE id_film = $1 AND id_profile = $2", filmId, userId)
+	if err != nil {
+		return false, fmt.Errorf("delete rating error: %s", err.Error())
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("delete rating error: %s", err.Error())
+	}
+
+	return deleted > 0, nil
+}
+
+// GetRating returns the aggregate rating of the film together with the vote of userId, which is 0 if the user has not voted.
+func (repo *PsxRepo) GetRating(ctx context.Context, filmId uint64, userId uint64) (*models.FilmRatingResponse, bool, error) {
+	rating := &models.FilmRatingResponse{}
+
+	err := repo.db.QueryRowContext(ctx, "SELECT film.id, film.rating, film.votes, COALESCE(film_rating.rating, 0) FROM film "+
+		"LEFT JOIN film_rating ON film_rating.id_film = film.id AND film_rating.id_profile = $2 "+
+		"WHERE film.id = $1 AND film.deleted_at IS NULL", filmId, userId).Scan(&rating.FilmId, &rating.Rating, &rating.Votes, &rating.UserRating)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("get rating error: %s", err.Error())
+	}
+
+	return rating, true, nil
+}
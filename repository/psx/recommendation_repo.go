@@ -0,0 +1,133 @@
+package psx
+
+import (
+	"context"
+	"database/sql"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/images"
+	"filmoteka/pkg/models"
+	"fmt"
+)
+
+// recommendationShrinkage is added to the sum of similarities a prediction
+// is divided by, so that films close to a single rated film score lower than
+// films backed by many.
+const recommendationShrinkage = 1.0
+
+// popularMinVotes is the number of votes at which the weighted rating of a
+// popular film is half its own rating and half the mean of all films.
+const popularMinVotes = 5
+
+// RefreshFilmSimilarity rebuilds film_similarity from the current ratings.
+// Each rating is centred on the mean rating of its user, pairs of films rated
+// by fewer than minRaters users in common are skipped and every film keeps
+// its neighbours most similar films with a positive score. Films in the trash
+// are left out. It returns the number of pairs stored.
+func (repo *PsxRepo) RefreshFilmSimilarity(ctx context.Context, neighbours uint64, minRaters uint64) (int64, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("refresh film similarity begin error: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM film_similarity")
+	if err != nil {
+		return 0, fmt.Errorf("clear film similarity error: %s", err.Error())
+	}
+
+	result, err := tx.ExecContext(ctx, "WITH centred AS (SELECT film_rating.id_profile, film_rating.id_film, "+
+		"film_rating.rating - AVG(film_rating.rating) OVER (PARTITION BY film_rating.id_profile) AS rating "+
+		"FROM film_rating JOIN film ON film.id = film_rating.id_film WHERE film.deleted_at IS NULL), "+
+		"norm AS (SELECT centred.id_film, SQRT(SUM(centred.rating * centred.rating)) AS norm FROM centred GROUP BY centred.id_film), "+
+		"pair AS (SELECT own.id_film, other.id_film AS id_similar, SUM(own.rating * other.rating) AS product, COUNT(*) AS raters "+
+		"FROM centred own JOIN centred other ON other.id_profile = own.id_profile AND other.id_film <> own.id_film "+
+		"GROUP BY own.id_film, other.id_film HAVING COUNT(*) >= $1), "+
+		"scored AS (SELECT pair.id_film, pair.id_similar, pair.raters, pair.product / (own.norm * other.norm) AS score, "+
+		"ROW_NUMBER() OVER (PARTITION BY pair.id_film ORDER BY pair.product / (own.norm * other.norm) DESC, pair.id_similar) AS place "+
+		"FROM pair JOIN norm own ON own.id_film = pair.id_film JOIN norm other ON other.id_film = pair.id_similar "+
+		"WHERE own.norm > 0 AND other.norm > 0 AND pair.product > 0) "+
+		"INSERT INTO film_similarity(id_film, id_similar, score, raters) "+
+		"SELECT scored.id_film, scored.id_similar, scored.score, scored.raters FROM scored WHERE scored.place <= $2",
+		minRaters, neighbours)
+	if err != nil {
+		return 0, fmt.Errorf("refresh film similarity error: %s", err.Error())
+	}
+
+	pairs, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("refresh film similarity error: %s", err.Error())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("refresh film similarity commit error: %s", err.Error())
+	}
+
+	return pairs, nil
+}
+
+// FindRecommendedFilms predicts how much the user would rate films above
+// their mean rating from the films they rated and film_similarity, and
+// returns the films with a positive prediction, best first. Films the user
+// rated and films in the trash are left out.
+func (repo *PsxRepo) FindRecommendedFilms(ctx context.Context, userId uint64, limit uint64) ([]models.RecommendedFilmItem, error) {
+	rows, err := repo.db.QueryContext(ctx, "WITH own AS (SELECT film_rating.id_film, "+
+		"film_rating.rating - AVG(film_rating.rating) OVER () AS rating FROM film_rating WHERE film_rating.id_profile = $1), "+
+		"candidate AS (SELECT film_similarity.id_similar AS id_film, "+
+		"SUM(film_similarity.score * own.rating) / (SUM(film_similarity.score) + $3::float8) AS score "+
+		"FROM own JOIN film_similarity ON film_similarity.id_film = own.id_film "+
+		"WHERE film_similarity.id_similar NOT IN (SELECT own.id_film FROM own) "+
+		"GROUP BY film_similarity.id_similar HAVING SUM(film_similarity.score * own.rating) > 0) "+
+		"SELECT film.id, film.title, film.info, film.rating, film.votes, film.release_date, film.poster, candidate.score "+
+		"FROM candidate JOIN film ON film.id = candidate.id_film WHERE film.deleted_at IS NULL "+
+		"ORDER BY candidate.score DESC, film.id LIMIT $2", userId, limit, recommendationShrinkage)
+	if err != nil {
+		return nil, fmt.Errorf("sql request find recommended films error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	return scanRecommendedFilms(rows, utils.RecommendationSourceRatings, limit)
+}
+
+// FindPopularFilms returns the films with the best rating weighted by the
+// number of votes that the user has not rated, apart from the excluded ones.
+func (repo *PsxRepo) FindPopularFilms(ctx context.Context, userId uint64, exclude []uint64, limit uint64) ([]models.RecommendedFilmItem, error) {
+	var args queryArgs
+	user := args.add(userId)
+	minVotes := args.add(popularMinVotes)
+
+	excluded := ""
+	if len(exclude) > 0 {
+		excluded = " AND film.id NOT IN (" + args.addList(exclude) + ")"
+	}
+
+	rows, err := repo.db.QueryContext(ctx, "WITH overall AS (SELECT COALESCE(AVG(film.rating), 0) AS rating FROM film "+
+		"WHERE film.votes > 0 AND film.deleted_at IS NULL) "+
+		"SELECT film.id, film.title, film.info, film.rating, film.votes, film.release_date, film.poster, "+
+		"(film.votes * film.rating + "+minVotes+"::float8 * overall.rating) / (film.votes + "+minVotes+"::float8) AS score "+
+		"FROM film CROSS JOIN overall WHERE film.votes > 0 AND film.deleted_at IS NULL"+excluded+" "+
+		"AND NOT EXISTS (SELECT 1 FROM film_rating WHERE film_rating.id_film = film.id AND film_rating.id_profile = "+user+") "+
+		"ORDER BY score DESC, film.votes DESC, film.id LIMIT "+args.add(limit), args.params...)
+	if err != nil {
+		return nil, fmt.Errorf("sql request find popular films error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	return scanRecommendedFilms(rows, utils.RecommendationSourcePopular, limit)
+}
+
+func scanRecommendedFilms(rows *sql.Rows, source string, limit uint64) ([]models.RecommendedFilmItem, error) {
+	films := make([]models.RecommendedFilmItem, 0, limit)
+	for rows.Next() {
+		film := models.RecommendedFilmItem{Source: source}
+
+		err := rows.Scan(&film.Id, &film.Title, &film.Info, &film.Rating, &film.Votes, &film.ReleaseDate,
+			imageColumn{images.DirFilms, &film.Poster}, &film.Score)
+		if err != nil {
+			return nil, fmt.Errorf("sql scan recommended films error: %s", err.Error())
+		}
+		films = append(films, film)
+	}
+
+	return films, nil
+}
@@ -0,0 +1,106 @@
+package psx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/models"
+	"fmt"
+)
+
+// AddReview stores the review of userId for filmId, found is false when the film does not exist.
+func (repo *PsxRepo) AddReview(ctx context.Context, filmId uint64, userId uint64, review *models.ReviewRequest) (uint64, bool, error) {
+	err := repo.db.QueryRowContext(ctx, "INSERT INTO review(id_film, id_profile, title, body, spoiler) "+
+		"SELECT film.id, $2, $3, $4, $5 FROM film WHERE film.id = $1 AND film.deleted_at IS NULL RETURNING id",
+		filmId, userId, review.Title, review.Body, review.Spoiler).Scan(&review.Id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("add review error: %s", err.Error())
+	}
+
+	return review.Id, true, nil
+}
+
+func (repo *PsxRepo) FindReviews(ctx context.Context, filmId uint64, page uint64, perPage uint64) (*models.ReviewsResponse, error) {
+	response := &models.ReviewsResponse{Page: max(page, 1), PerPage: perPage, Reviews: make([]models.ReviewItem, 0, perPage)}
+
+	err := repo.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM review WHERE review.id_film = $1 AND NOT review.hidden",
+		filmId).Scan(&response.Total)
+	if err != nil {
+		return nil, fmt.Errorf("count reviews error: %s", err.Error())
+	}
+
+	rows, err := repo.db.QueryContext(ctx, "SELECT review.id, review.id_film, review.id_profile, profile.login, review.title, "+
+		"review.body, review.spoiler, review.hidden, review.created_at, review.edited_at FROM review "+
+		"JOIN profile ON profile.id = review.id_profile "+
+		"WHERE review.id_film = $1 AND NOT review.hidden "+
+		"ORDER BY review.created_at DESC, review.id DESC OFFSET $2 LIMIT $3", filmId, utils.PageOffset(page, perPage), perPage)
+	if err != nil {
+		return nil, fmt.Errorf("sql request find reviews error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var review models.ReviewItem
+		var editedAt sql.NullTime
+
+		err := rows.Scan(&review.Id, &review.FilmId, &review.AuthorId, &review.Author, &review.Title,
+			&review.Body, &review.Spoiler, &review.Hidden, &review.CreatedAt, &editedAt)
+		if err != nil {
+			return nil, fmt.Errorf("sql scan reviews error: %s", err.Error())
+		}
+		if editedAt.Valid {
+			review.EditedAt = &editedAt.Time
+		}
+		response.Reviews = append(response.Reviews, review)
+	}
+
+	return response, nil
+}
+
+// UpdateReview changes a review written by userId, updated is false when there is no such review.
+func (repo *PsxRepo) UpdateReview(ctx context.Context, userId uint64, review *models.ReviewRequest) (bool, error) {
+	result, err := repo.db.ExecContext(ctx, "UPDATE review SET title = $1, body = $2, spoiler = $3, edited_at = now() "+
+		"WHERE review.id = $4 AND review.id_profile = $5", review.Title, review.Body, review.Spoiler, review.Id, userId)
+	if err != nil {
+		return false, fmt.Errorf("update review error: %s", err.Error())
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("update review error: %s", err.Error())
+	}
+
+	return updated > 0, nil
+}
+
+func (repo *PsxRepo) DeleteReview(ctx context.Context, userId uint64, reviewId uint64) (bool, error) {
+	result, err := repo.db.ExecContext(ctx, "DELETE FROM review WHERE review.id = $1 AND review.id_profile = $2", reviewId, userId)
+	if err != nil {
+		return false, fmt.Errorf("delete review error: %s", err.Error())
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("delete review error: %s", err.Error())
+	}
+
+	return deleted > 0, nil
+}
+
+func (repo *PsxRepo) HideReview(ctx context.Context, reviewId uint64, hidden bool) (bool, error) {
+	result, err := repo.db.ExecContext(ctx, "UPDATE review SET hidden = $1 WHERE review.id = $2", hidden, reviewId)
+	if err != nil {
+		return false, fmt.Errorf("hide review error: %s", err.Error())
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("hide review error: %s", err.Error())
+	}
+
+	return updated > 0, nil
+}
@@ -0,0 +1,282 @@
+package psx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/models"
+	"fmt"
+)
+
+// revisionSource describes where the revisions of one kind of record are kept
+// and how the state of such a record is turned into a revision document.
+type revisionSource struct {
+	entity string
+	table  string
+	column string
+	data   string
+}
+
+var filmRevisions = revisionSource{
+	entity: "film",
+	table:  "film_revision",
+	column: "id_film",
+	data: "jsonb_build_object('title', film.title, 'info', film.info, 'release_date', film.release_date::text, " +
+		"'genres', COALESCE((SELECT jsonb_agg(genre_in_film.id_genre ORDER BY genre_in_film.id_genre) FROM genre_in_film " +
+		"WHERE genre_in_film.id_film = film.id), '[]'::jsonb), " +
+		"'credits', COALESCE((SELECT jsonb_agg(jsonb_build_object('actor_id', actor_in_film.id_actor, 'role', actor_in_film.role, " +
+		"'character', actor_in_film.character_name, 'billing_order', actor_in_film.billing_order) " +
+		"ORDER BY actor_in_film.role, actor_in_film.billing_order, actor_in_film.id_actor) FROM actor_in_film " +
+		"WHERE actor_in_film.id_film = film.id), '[]'::jsonb))",
+}
+
+var actorRevisions = revisionSource{
+	entity: "actor",
+	table:  "actor_revision",
+	column: "id_actor",
+	data: "jsonb_build_object('name', actor.name, 'gen', actor.gen, 'birthday', actor.birthdate::text, " +
+		"'credits', COALESCE((SELECT jsonb_agg(jsonb_build_object('film_id', actor_in_film.id_film, 'role', actor_in_film.role, " +
+		"'character', actor_in_film.character_name, 'billing_order', actor_in_film.billing_order) " +
+		"ORDER BY actor_in_film.id_film, actor_in_film.role) FROM actor_in_film " +
+		"WHERE actor_in_film.id_actor = actor.id), '[]'::jsonb))",
+}
+
+func (repo *PsxRepo) AddFilmRevision(ctx context.Context, filmId uint64, userId uint64) (*models.Revision, bool, error) {
+	return repo.addRevision(ctx, filmRevisions, filmId, userId)
+}
+
+func (repo *PsxRepo) AddActorRevision(ctx context.Context, actorId uint64, userId uint64) (*models.Revision, bool, error) {
+	return repo.addRevision(ctx, actorRevisions, actorId, userId)
+}
+
+// addRevision saves the current state of the record as its next revision by
+// userId, unless it is the same as the latest revision. It returns the latest
+// revision afterwards, with the previous document when a revision was added,
+// and nil when there is no such record.
+func (repo *PsxRepo) addRevision(ctx context.Context, source revisionSource, id uint64, userId uint64) (*models.Revision, bool, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("add %s revision begin error: %s", source.entity, err.Error())
+	}
+	defer tx.Rollback()
+
+	revision := &models.Revision{}
+	var data, previous []byte
+	var latest sql.NullInt64
+	var changed bool
+
+	err = tx.QueryRowContext(ctx, "SELECT snapshot.data, latest.data, latest.revision, snapshot.data IS DISTINCT FROM latest.data "+
+		"FROM "+source.entity+" CROSS JOIN LATERAL (SELECT "+source.data+" AS data) AS snapshot "+
+		"LEFT JOIN LATERAL (SELECT "+source.table+".data, "+source.table+".revision FROM "+source.table+" "+
+		"WHERE "+source.table+"."+source.column+" = "+source.entity+".id ORDER BY "+source.table+".revision DESC LIMIT 1) AS latest ON true "+
+		"WHERE "+source.entity+".id = $1 FOR UPDATE OF "+source.entity, id).Scan(&data, &previous, &latest, &changed)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("get %s state error: %s", source.entity, err.Error())
+	}
+
+	revision.Revision = uint64(latest.Int64)
+	if !changed {
+		revision.Data = previous
+		return revision, false, nil
+	}
+
+	revision.Revision++
+	revision.UserId = userId
+	revision.Data, revision.Previous = data, previous
+
+	err = tx.QueryRowContext(ctx, "INSERT INTO "+source.table+"("+source.column+", revision, id_profile, data) "+
+		"VALUES($1, $2, $3, $4::jsonb) RETURNING created_at", id, revision.Revision, nullId(userId), string(data)).Scan(&revision.CreatedAt)
+	if err != nil {
+		return nil, false, fmt.Errorf("add %s revision error: %s", source.entity, err.Error())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, false, fmt.Errorf("add %s revision commit error: %s", source.entity, err.Error())
+	}
+
+	return revision, true, nil
+}
+
+func (repo *PsxRepo) FindFilmRevisions(ctx context.Context, filmId uint64, page uint64, perPage uint64) (*models.RevisionsResponse, bool, error) {
+	return repo.findRevisions(ctx, filmRevisions, filmId, page, perPage)
+}
+
+func (repo *PsxRepo) FindActorRevisions(ctx context.Context, actorId uint64, page uint64, perPage uint64) (*models.RevisionsResponse, bool, error) {
+	return repo.findRevisions(ctx, actorRevisions, actorId, page, perPage)
+}
+
+// findRevisions lists the revisions of a record outside the trash, newest
+// first, each with the document of the revision before it.
+func (repo *PsxRepo) findRevisions(ctx context.Context, source revisionSource, id uint64, page uint64, perPage uint64) (*models.RevisionsResponse, bool, error) {
+	response := &models.RevisionsResponse{Page: max(page, 1), PerPage: perPage, Revisions: make([]models.Revision, 0, perPage)}
+
+	var found bool
+	err := repo.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM "+source.entity+" WHERE "+source.entity+".id = $1 "+
+		"AND "+source.entity+".deleted_at IS NULL), (SELECT COUNT(*) FROM "+source.table+" WHERE "+source.table+"."+source.column+" = $1)",
+		id).Scan(&found, &response.Total)
+	if err != nil {
+		return nil, false, fmt.Errorf("count %s revisions error: %s", source.entity, err.Error())
+	}
+
+	if !found {
+		return nil, false, nil
+	}
+
+	rows, err := repo.db.QueryContext(ctx, "SELECT "+source.table+".revision, COALESCE("+source.table+".id_profile, 0), "+
+		source.table+".created_at, "+source.table+".data, LAG("+source.table+".data) OVER (ORDER BY "+source.table+".revision) "+
+		"FROM "+source.table+" WHERE "+source.table+"."+source.column+" = $1 "+
+		"ORDER BY "+source.table+".revision DESC OFFSET $2 LIMIT $3", id, utils.PageOffset(page, perPage), perPage)
+	if err != nil {
+		return nil, false, fmt.Errorf("sql request find %s revisions error: %s", source.entity, err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var revision models.Revision
+		var data, previous []byte
+
+		err := rows.Scan(&revision.Revision, &revision.UserId, &revision.CreatedAt, &data, &previous)
+		if err != nil {
+			return nil, false, fmt.Errorf("sql scan %s revisions error: %s", source.entity, err.Error())
+		}
+		revision.Data, revision.Previous = data, previous
+		response.Revisions = append(response.Revisions, revision)
+	}
+
+	return response, true, nil
+}
+
+func (repo *PsxRepo) GetFilmRevision(ctx context.Context, filmId uint64, revision uint64) (*models.Revision, bool, error) {
+	return repo.getRevision(ctx, filmRevisions, filmId, revision)
+}
+
+func (repo *PsxRepo) GetActorRevision(ctx context.Context, actorId uint64, revision uint64) (*models.Revision, bool, error) {
+	return repo.getRevision(ctx, actorRevisions, actorId, revision)
+}
+
+func (repo *PsxRepo) getRevision(ctx context.Context, source revisionSource, id uint64, number uint64) (*models.Revision, bool, error) {
+	revision := &models.Revision{}
+	var data []byte
+
+	err := repo.db.QueryRowContext(ctx, "SELECT "+source.table+".revision, COALESCE("+source.table+".id_profile, 0), "+
+		source.table+".created_at, "+source.table+".data FROM "+source.table+" "+
+		"WHERE "+source.table+"."+source.column+" = $1 AND "+source.table+".revision = $2", id, number).
+		Scan(&revision.Revision, &revision.UserId, &revision.CreatedAt, &data)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("get %s revision error: %s", source.entity, err.Error())
+	}
+	revision.Data = data
+
+	return revision, true, nil
+}
+
+// RollbackFilm brings the film back to the state kept in data, replacing its
+// genres and credits. Genres and actors removed since are left out. Found is
+// false when the film does not exist or is in the trash.
+func (repo *PsxRepo) RollbackFilm(ctx context.Context, filmId uint64, data []byte) (bool, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("rollback film begin error: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "UPDATE film SET title = $2::jsonb->>'title', info = $2::jsonb->>'info', "+
+		"release_date = ($2::jsonb->>'release_date')::date WHERE film.id = $1 AND film.deleted_at IS NULL", filmId, string(data))
+	if err != nil {
+		return false, fmt.Errorf("rollback film error: %s", err.Error())
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rollback film error: %s", err.Error())
+	}
+
+	if updated == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM genre_in_film WHERE id_film = $1", filmId)
+	if err != nil {
+		return false, fmt.Errorf("rollback film genres error: %s", err.Error())
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO genre_in_film(id_film, id_genre) SELECT $1, genre.id "+
+		"FROM jsonb_array_elements_text($2::jsonb->'genres') AS revision_genre(id) "+
+		"JOIN genre ON genre.id = revision_genre.id::integer", filmId, string(data))
+	if err != nil {
+		return false, fmt.Errorf("rollback film genres error: %s", err.Error())
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM actor_in_film WHERE id_film = $1", filmId)
+	if err != nil {
+		return false, fmt.Errorf("rollback film credits error: %s", err.Error())
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO actor_in_film(id_film, id_actor, role, character_name, billing_order) "+
+		"SELECT $1, actor.id, credit.role, credit.\"character\", credit.billing_order "+
+		"FROM jsonb_to_recordset($2::jsonb->'credits') AS credit(actor_id integer, role text, \"character\" text, billing_order integer) "+
+		"JOIN actor ON actor.id = credit.actor_id", filmId, string(data))
+	if err != nil {
+		return false, fmt.Errorf("rollback film credits error: %s", err.Error())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("rollback film commit error: %s", err.Error())
+	}
+
+	return true, nil
+}
+
+// RollbackActor brings the actor back to the state kept in data, replacing
+// their credits, see RollbackFilm.
+func (repo *PsxRepo) RollbackActor(ctx context.Context, actorId uint64, data []byte) (bool, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("rollback actor begin error: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "UPDATE actor SET name = $2::jsonb->>'name', gen = $2::jsonb->>'gen', "+
+		"birthdate = ($2::jsonb->>'birthday')::date WHERE actor.id = $1 AND actor.deleted_at IS NULL", actorId, string(data))
+	if err != nil {
+		return false, fmt.Errorf("rollback actor error: %s", err.Error())
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rollback actor error: %s", err.Error())
+	}
+
+	if updated == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM actor_in_film WHERE id_actor = $1", actorId)
+	if err != nil {
+		return false, fmt.Errorf("rollback actor credits error: %s", err.Error())
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO actor_in_film(id_film, id_actor, role, character_name, billing_order) "+
+		"SELECT film.id, $1, credit.role, credit.\"character\", credit.billing_order "+
+		"FROM jsonb_to_recordset($2::jsonb->'credits') AS credit(film_id integer, role text, \"character\" text, billing_order integer) "+
+		"JOIN film ON film.id = credit.film_id", actorId, string(data))
+	if err != nil {
+		return false, fmt.Errorf("rollback actor credits error: %s", err.Error())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("rollback actor commit error: %s", err.Error())
+	}
+
+	return true, nil
+}
@@ -0,0 +1,78 @@
+package psx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmoteka/pkg/images"
+	"filmoteka/pkg/models"
+	"fmt"
+)
+
+// Weights of the similar films score. Every shared cast or crew member
+// counts the most, then every shared genre, and films released closer in
+// time and rated closer to the film are preferred among the rest.
+const (
+	similarActorWeight   = 3.0
+	similarGenreWeight   = 1.0
+	similarReleaseWeight = 1.0
+	similarRatingWeight  = 0.5
+)
+
+// similarReleaseYears is the gap in years between release dates at which
+// the release part of the score drops to half.
+const similarReleaseYears = 5.0
+
+// FindSimilarFilms ranks the films sharing cast, crew or genres with the
+// film, people and films in the trash are left out. Found is false when
+// there is no such film.
+func (repo *PsxRepo) FindSimilarFilms(ctx context.Context, filmId uint64, limit uint64) ([]models.SimilarFilmItem, bool, error) {
+	var id uint64
+
+	err := repo.db.QueryRowContext(ctx, "SELECT film.id FROM film WHERE film.id = $1 AND film.deleted_at IS NULL", filmId).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("get film error: %s", err.Error())
+	}
+
+	rows, err := repo.db.QueryContext(ctx, "WITH target AS (SELECT film.release_date, film.rating FROM film WHERE film.id = $1), "+
+		"shared_cast AS (SELECT other.id_film, COUNT(DISTINCT other.id_actor) AS actors FROM actor_in_film own "+
+		"JOIN actor_in_film other ON other.id_actor = own.id_actor AND other.id_film <> own.id_film "+
+		"JOIN actor ON actor.id = own.id_actor AND actor.deleted_at IS NULL "+
+		"WHERE own.id_film = $1 GROUP BY other.id_film), "+
+		"shared_genres AS (SELECT other.id_film, COUNT(*) AS genres FROM genre_in_film own "+
+		"JOIN genre_in_film other ON other.id_genre = own.id_genre AND other.id_film <> own.id_film "+
+		"WHERE own.id_film = $1 GROUP BY other.id_film), "+
+		"candidate AS (SELECT film.id, film.title, film.info, film.rating, film.votes, film.release_date, film.poster, "+
+		"COALESCE(shared_cast.actors, 0) AS actors, COALESCE(shared_genres.genres, 0) AS genres, "+
+		"COALESCE(shared_cast.actors, 0) * $3::float8 + COALESCE(shared_genres.genres, 0) * $4::float8 + "+
+		"$5::float8 / (1 + ABS(film.release_date - target.release_date) / (365.25 * $7::float8)) + "+
+		"$6::float8 / (1 + ABS(film.rating - target.rating)) AS score "+
+		"FROM film CROSS JOIN target "+
+		"LEFT JOIN shared_cast ON shared_cast.id_film = film.id LEFT JOIN shared_genres ON shared_genres.id_film = film.id "+
+		"WHERE film.id <> $1 AND film.deleted_at IS NULL AND (shared_cast.id_film IS NOT NULL OR shared_genres.id_film IS NOT NULL)) "+
+		"SELECT candidate.id, candidate.title, candidate.info, candidate.rating, candidate.votes, candidate.release_date, "+
+		"candidate.poster, candidate.actors, candidate.genres, candidate.score FROM candidate "+
+		"ORDER BY candidate.score DESC, candidate.id LIMIT $2",
+		filmId, limit, similarActorWeight, similarGenreWeight, similarReleaseWeight, similarRatingWeight, similarReleaseYears)
+	if err != nil {
+		return nil, false, fmt.Errorf("sql request find similar films error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	films := make([]models.SimilarFilmItem, 0, limit)
+	for rows.Next() {
+		var film models.SimilarFilmItem
+
+		err := rows.Scan(&film.Id, &film.Title, &film.Info, &film.Rating, &film.Votes, &film.ReleaseDate,
+			imageColumn{images.DirFilms, &film.Poster}, &film.SharedActors, &film.SharedGenres, &film.Score)
+		if err != nil {
+			return nil, false, fmt.Errorf("sql scan similar films error: %s", err.Error())
+		}
+		films = append(films, film)
+	}
+
+	return films, true, nil
+}
@@ -0,0 +1,263 @@
+package psx
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+	"fmt"
+	"time"
+)
+
+// statsFilmConditions selects the films of request that are not in the trash.
+// The bounds of when films were added are left out when added is false.
+func statsFilmConditions(request *models.StatsRequest, added bool, args *queryArgs) []string {
+	conditions := []string{"film.deleted_at IS NULL"}
+	if added && request.From != nil {
+		conditions = append(conditions, "film.created_at >= "+args.add(*request.From))
+	}
+	if added && request.To != nil {
+		conditions = append(conditions, "film.created_at < "+args.add(*request.To))
+	}
+	if request.ReleaseFrom != nil {
+		conditions = append(conditions, "film.release_date >= "+args.add(*request.ReleaseFrom)+"::date")
+	}
+	if request.ReleaseTo != nil {
+		conditions = append(conditions, "film.release_date < "+args.add(*request.ReleaseTo)+"::date")
+	}
+
+	return conditions
+}
+
+// statsActorConditions selects the actors of request that are not in the
+// trash. The bounds of when actors were added are left out when added is false.
+func statsActorConditions(request *models.StatsRequest, added bool, args *queryArgs) []string {
+	conditions := []string{"actor.deleted_at IS NULL"}
+	if added && request.From != nil {
+		conditions = append(conditions, "actor.created_at >= "+args.add(*request.From))
+	}
+	if added && request.To != nil {
+		conditions = append(conditions, "actor.created_at < "+args.add(*request.To))
+	}
+
+	return conditions
+}
+
+// GetStats aggregates the catalog statistics of request.
+func (repo *PsxRepo) GetStats(ctx context.Context, request *models.StatsRequest) (*models.StatsResponse, error) {
+	stats := &models.StatsResponse{}
+
+	var args queryArgs
+	films := whereClause(statsFilmConditions(request, true, &args))
+	actors := whereClause(statsActorConditions(request, true, &args))
+
+	err := repo.db.QueryRowContext(ctx, "SELECT (SELECT COUNT(*) FROM film "+films+"), (SELECT COUNT(*) FROM actor "+actors+")",
+		args.params...).Scan(&stats.Films, &stats.Actors)
+	if err != nil {
+		return nil, fmt.Errorf("count films and actors error: %s", err.Error())
+	}
+
+	stats.FilmsByYear, err = repo.filmsByYear(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.FilmsByRating, err = repo.filmsByRating(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.TopRatedActors, err = repo.actorStats(ctx, request,
+		"HAVING COUNT(*) FILTER (WHERE credit.votes > 0) > 0 ORDER BY 4 DESC, 3 DESC, actor.id ")
+	if err != nil {
+		return nil, err
+	}
+
+	stats.ProlificActors, err = repo.actorStats(ctx, request, "ORDER BY 3 DESC, actor.name, actor.id ")
+	if err != nil {
+		return nil, err
+	}
+
+	args = queryArgs{}
+	conditions := append(statsActorConditions(request, true, &args), "NOT EXISTS (SELECT 1 FROM actor_in_film "+
+		"JOIN film ON film.id = actor_in_film.id_film WHERE actor_in_film.id_actor = actor.id AND film.deleted_at IS NULL)")
+	stats.ActorsWithoutFilms, err = repo.entityStats(ctx, "SELECT actor.id, actor.name, COUNT(*) OVER () FROM actor "+
+		whereClause(conditions)+"ORDER BY actor.name, actor.id LIMIT "+args.add(request.Limit), args.params)
+	if err != nil {
+		return nil, fmt.Errorf("find actors without films error: %s", err.Error())
+	}
+
+	args = queryArgs{}
+	conditions = append(statsFilmConditions(request, true, &args), "NOT EXISTS (SELECT 1 FROM actor_in_film "+
+		"JOIN actor ON actor.id = actor_in_film.id_actor WHERE actor_in_film.id_film = film.id AND actor.deleted_at IS NULL)")
+	stats.FilmsWithoutCast, err = repo.entityStats(ctx, "SELECT film.id, film.title, COUNT(*) OVER () FROM film "+
+		whereClause(conditions)+"ORDER BY film.title, film.id LIMIT "+args.add(request.Limit), args.params)
+	if err != nil {
+		return nil, fmt.Errorf("find films without cast error: %s", err.Error())
+	}
+
+	stats.Growth, err = repo.growth(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (repo *PsxRepo) filmsByYear(ctx context.Context, request *models.StatsRequest) ([]models.YearStatsItem, error) {
+	var args queryArgs
+
+	rows, err := repo.db.QueryContext(ctx, "SELECT EXTRACT(YEAR FROM film.release_date)::integer AS year, COUNT(*) FROM film "+
+		whereClause(statsFilmConditions(request, true, &args))+"GROUP BY year ORDER BY year", args.params...)
+	if err != nil {
+		return nil, fmt.Errorf("sql request films by year error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	years := make([]models.YearStatsItem, 0)
+	for rows.Next() {
+		var year models.YearStatsItem
+
+		err := rows.Scan(&year.Year, &year.Films)
+		if err != nil {
+			return nil, fmt.Errorf("sql scan films by year error: %s", err.Error())
+		}
+		years = append(years, year)
+	}
+
+	return years, nil
+}
+
+// filmsByRating counts the films by whole rating points, films without votes
+// go into a bucket of their own.
+func (repo *PsxRepo) filmsByRating(ctx context.Context, request *models.StatsRequest) ([]models.RatingStatsItem, error) {
+	var args queryArgs
+
+	rows, err := repo.db.QueryContext(ctx, "SELECT CASE WHEN film.votes = 0 THEN 0 ELSE LEAST(FLOOR(film.rating), 9)::integer END AS bucket, "+
+		"COUNT(*) FROM film "+whereClause(statsFilmConditions(request, true, &args))+"GROUP BY bucket ORDER BY bucket", args.params...)
+	if err != nil {
+		return nil, fmt.Errorf("sql request films by rating error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	buckets := make([]models.RatingStatsItem, 0)
+	for rows.Next() {
+		var bucket models.RatingStatsItem
+
+		err := rows.Scan(&bucket.Min, &bucket.Films)
+		if err != nil {
+			return nil, fmt.Errorf("sql scan films by rating error: %s", err.Error())
+		}
+		if bucket.Min > 0 {
+			bucket.Max = bucket.Min + 1
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets, nil
+}
+
+// actorStats lists the actors of request with the number and the average
+// rating of their films of request, ordered by tail, which may start with a
+// HAVING clause on the films aliased credit.
+func (repo *PsxRepo) actorStats(ctx context.Context, request *models.StatsRequest, tail string) ([]models.ActorStatsItem, error) {
+	var args queryArgs
+	films := whereClause(statsFilmConditions(request, false, &args))
+	actors := whereClause(statsActorConditions(request, true, &args))
+
+	// an actor may have several roles in a film, every film counts once
+	rows, err := repo.db.QueryContext(ctx, "WITH credit AS (SELECT DISTINCT actor_in_film.id_actor, film.id, film.rating, film.votes "+
+		"FROM actor_in_film JOIN film ON film.id = actor_in_film.id_film "+films+") "+
+		"SELECT actor.id, actor.name, COUNT(*), COALESCE(AVG(credit.rating) FILTER (WHERE credit.votes > 0), 0) "+
+		"FROM actor JOIN credit ON credit.id_actor = actor.id "+actors+"GROUP BY actor.id "+tail+"LIMIT "+args.add(request.Limit),
+		args.params...)
+	if err != nil {
+		return nil, fmt.Errorf("sql request actor stats error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	items := make([]models.ActorStatsItem, 0, request.Limit)
+	for rows.Next() {
+		var item models.ActorStatsItem
+
+		err := rows.Scan(&item.Id, &item.Name, &item.Films, &item.AverageRating)
+		if err != nil {
+			return nil, fmt.Errorf("sql scan actor stats error: %s", err.Error())
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// entityStats runs query, which selects an id, a name and the number of
+// rows it matched before its limit.
+func (repo *PsxRepo) entityStats(ctx context.Context, query string, params []any) (models.EntityStatsList, error) {
+	list := models.EntityStatsList{Items: make([]models.EntityStatsItem, 0)}
+
+	rows, err := repo.db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return list, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.EntityStatsItem
+
+		err := rows.Scan(&item.Id, &item.Name, &list.Total)
+		if err != nil {
+			return list, err
+		}
+		list.Items = append(list.Items, item)
+	}
+
+	return list, nil
+}
+
+// growth counts the films and actors added per interval of request. The
+// totals start from those added before request.From.
+func (repo *PsxRepo) growth(ctx context.Context, request *models.StatsRequest) ([]models.GrowthStatsItem, error) {
+	var filmsTotal, actorsTotal int
+
+	if request.From != nil {
+		var args queryArgs
+		films := whereClause(append(statsFilmConditions(request, false, &args), "film.created_at < "+args.add(*request.From)))
+		actors := whereClause(append(statsActorConditions(request, false, &args), "actor.created_at < "+args.add(*request.From)))
+
+		err := repo.db.QueryRowContext(ctx, "SELECT (SELECT COUNT(*) FROM film "+films+"), (SELECT COUNT(*) FROM actor "+actors+")",
+			args.params...).Scan(&filmsTotal, &actorsTotal)
+		if err != nil {
+			return nil, fmt.Errorf("count films and actors added before error: %s", err.Error())
+		}
+	}
+
+	var args queryArgs
+	interval := args.add(request.Interval)
+	films := whereClause(statsFilmConditions(request, true, &args))
+	actors := whereClause(statsActorConditions(request, true, &args))
+
+	rows, err := repo.db.QueryContext(ctx, "SELECT added.period, SUM(added.films)::integer, SUM(added.actors)::integer FROM ("+
+		"SELECT date_trunc("+interval+", film.created_at, 'UTC') AS period, 1 AS films, 0 AS actors FROM film "+films+
+		"UNION ALL SELECT date_trunc("+interval+", actor.created_at, 'UTC'), 0, 1 FROM actor "+actors+
+		") AS added GROUP BY added.period ORDER BY added.period", args.params...)
+	if err != nil {
+		return nil, fmt.Errorf("sql request growth error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	growth := make([]models.GrowthStatsItem, 0)
+	for rows.Next() {
+		var item models.GrowthStatsItem
+
+		err := rows.Scan(&item.Period, &item.Films, &item.Actors)
+		if err != nil {
+			return nil, fmt.Errorf("sql scan growth error: %s", err.Error())
+		}
+
+		filmsTotal += item.Films
+		actorsTotal += item.Actors
+		item.Period = item.Period.In(time.UTC)
+		item.FilmsTotal, item.ActorsTotal = filmsTotal, actorsTotal
+		growth = append(growth, item)
+	}
+
+	return growth, nil
+}
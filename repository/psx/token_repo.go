@@ -0,0 +1,113 @@
+package psx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmoteka/pkg/models"
+	"fmt"
+	"strings"
+)
+
+// tokenTouchInterval is how stale last_used_at may get before a request with
+// the token writes it again.
+const tokenTouchInterval = "1 minute"
+
+func (repo *PsxRepo) AddToken(ctx context.Context, userId uint64, name string, hash []byte, scopes []string) (*models.AccessTokenItem, error) {
+	token := &models.AccessTokenItem{Name: name, Scopes: scopes}
+
+	err := repo.db.QueryRowContext(ctx, "INSERT INTO access_token(id_profile, name, token_hash, scopes) VALUES($1, $2, $3, $4) "+
+		"RETURNING id, created_at", userId, name, hash, strings.Join(scopes, " ")).Scan(&token.Id, &token.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("add token error: %s", err.Error())
+	}
+
+	return token, nil
+}
+
+func (repo *PsxRepo) FindTokens(ctx context.Context, userId uint64) ([]models.AccessTokenItem, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT id, name, scopes, created_at, last_used_at FROM access_token "+
+		"WHERE id_profile = $1 AND revoked_at IS NULL ORDER BY created_at DESC, id DESC", userId)
+	if err != nil {
+		return nil, fmt.Errorf("find tokens error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	tokens := make([]models.AccessTokenItem, 0)
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("find tokens scan error: %s", err.Error())
+		}
+		tokens = append(tokens, *token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("find tokens rows error: %s", err.Error())
+	}
+
+	return tokens, nil
+}
+
+// RevokeToken marks the token of the user revoked and returns it, found is
+// false when the user has no such token that is still valid.
+func (repo *PsxRepo) RevokeToken(ctx context.Context, userId uint64, tokenId uint64) (*models.AccessTokenItem, bool, error) {
+	token, err := scanToken(repo.db.QueryRowContext(ctx, "UPDATE access_token SET revoked_at = now() "+
+		"WHERE id = $1 AND id_profile = $2 AND revoked_at IS NULL RETURNING id, name, scopes, created_at, last_used_at", tokenId, userId))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("revoke token error: %s", err.Error())
+	}
+
+	return token, true, nil
+}
+
+// FindTokenByHash returns the user of a valid token along with its scopes and
+// records the use of the token, at most once per tokenTouchInterval.
+func (repo *PsxRepo) FindTokenByHash(ctx context.Context, hash []byte) (*models.AccessToken, bool, error) {
+	token := &models.AccessToken{}
+	var scopes string
+
+	err := repo.db.QueryRowContext(ctx, "WITH token AS ("+
+		"SELECT access_token.id, access_token.id_profile, profile.login, profile.role, access_token.scopes, access_token.last_used_at "+
+		"FROM access_token JOIN profile ON profile.id = access_token.id_profile "+
+		"WHERE access_token.token_hash = $1 AND access_token.revoked_at IS NULL), "+
+		"touched AS (UPDATE access_token SET last_used_at = now() FROM token WHERE access_token.id = token.id "+
+		"AND (token.last_used_at IS NULL OR token.last_used_at < now() - interval '"+tokenTouchInterval+"')) "+
+		"SELECT id, id_profile, login, role, scopes FROM token", hash).
+		Scan(&token.Id, &token.UserId, &token.Login, &token.Role, &scopes)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("find token error: %s", err.Error())
+	}
+	token.Scopes = strings.Fields(scopes)
+
+	return token, true, nil
+}
+
+// rowScanner is either *sql.Row or *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanToken(row rowScanner) (*models.AccessTokenItem, error) {
+	token := &models.AccessTokenItem{}
+	var scopes string
+	var lastUsedAt sql.NullTime
+
+	err := row.Scan(&token.Id, &token.Name, &scopes, &token.CreatedAt, &lastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Scopes = strings.Fields(scopes)
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return token, nil
+}
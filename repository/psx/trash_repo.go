@@ -0,0 +1,206 @@
+package psx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/images"
+	"filmoteka/pkg/models"
+	"fmt"
+	"time"
+)
+
+// FindDeletedFilms lists the films in the trash, most recently deleted first.
+func (repo *PsxRepo) FindDeletedFilms(ctx context.Context, page uint64, perPage uint64) (*models.FilmsResponse, error) {
+	var total int
+
+	err := repo.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM film WHERE film.deleted_at IS NOT NULL").Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("count deleted films error: %s", err.Error())
+	}
+
+	rows, err := repo.db.QueryContext(ctx, "SELECT film.id, film.title, film.info, film.rating, film.votes, film.release_date, film.poster, "+
+		"film.deleted_at FROM film WHERE film.deleted_at IS NOT NULL "+
+		"ORDER BY film.deleted_at DESC, film.id DESC OFFSET $1 LIMIT $2", utils.PageOffset(page, perPage), perPage)
+	if err != nil {
+		return nil, fmt.Errorf("sql request find deleted films error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	films := make([]models.FilmItem, 0, perPage)
+	for rows.Next() {
+		film := models.FilmItem{}
+		var deletedAt time.Time
+
+		err := rows.Scan(&film.Id, &film.Title, &film.Info, &film.Rating, &film.Votes, &film.ReleaseDate,
+			imageColumn{images.DirFilms, &film.Poster}, &deletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("sql scan deleted films error: %s", err.Error())
+		}
+		film.DeletedAt = &deletedAt
+		films = append(films, film)
+	}
+
+	return &models.FilmsResponse{
+		Total:   total,
+		Page:    max(page, 1),
+		PerPage: perPage,
+		Films:   &films,
+	}, nil
+}
+
+// FindDeletedActors lists the actors in the trash, most recently deleted first.
+func (repo *PsxRepo) FindDeletedActors(ctx context.Context, page uint64, perPage uint64) (*models.ActorsResponse, error) {
+	response := &models.ActorsResponse{Page: max(page, 1), PerPage: perPage, Actors: make([]models.ActorResponse, 0, perPage)}
+
+	err := repo.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM actor WHERE actor.deleted_at IS NOT NULL").Scan(&response.Total)
+	if err != nil {
+		return nil, fmt.Errorf("count deleted actors error: %s", err.Error())
+	}
+
+	rows, err := repo.db.QueryContext(ctx, "SELECT actor.id, actor.name, actor.gen, actor.birthdate, actor.photo, "+actorAge+", "+actorFilmsCount+", "+
+		"actor.deleted_at FROM actor WHERE actor.deleted_at IS NOT NULL "+
+		"ORDER BY actor.deleted_at DESC, actor.id DESC OFFSET $1 LIMIT $2", utils.PageOffset(page, perPage), perPage)
+	if err != nil {
+		return nil, fmt.Errorf("sql request find deleted actors error: %s", err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		actor := models.ActorResponse{Films: make([]models.ActorCreditItem, 0)}
+		var deletedAt time.Time
+
+		err := rows.Scan(&actor.Id, &actor.Name, &actor.Gender, &actor.Birthday, imageColumn{images.DirActors, &actor.Photo},
+			&actor.Age, &actor.FilmsCount, &deletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("sql scan deleted actors error: %s", err.Error())
+		}
+		actor.DeletedAt = &deletedAt
+		response.Actors = append(response.Actors, actor)
+	}
+
+	return response, nil
+}
+
+// RestoreFilm takes the film out of the trash together with the credits,
+// ratings, reviews and lists that were kept while it was there, and returns
+// when it was deleted. Restored is false when the film is not in the trash.
+func (repo *PsxRepo) RestoreFilm(ctx context.Context, filmId uint64) (time.Time, bool, error) {
+	return restore(ctx, repo.db, "UPDATE film SET deleted_at = NULL FROM film trashed "+
+		"WHERE film.id = $1 AND trashed.id = film.id AND film.deleted_at IS NOT NULL RETURNING trashed.deleted_at", filmId)
+}
+
+// RestoreActor takes the actor out of the trash together with the credits, see RestoreFilm.
+func (repo *PsxRepo) RestoreActor(ctx context.Context, actorId uint64) (time.Time, bool, error) {
+	return restore(ctx, repo.db, "UPDATE actor SET deleted_at = NULL FROM actor trashed "+
+		"WHERE actor.id = $1 AND trashed.id = actor.id AND actor.deleted_at IS NOT NULL RETURNING trashed.deleted_at", actorId)
+}
+
+// restore runs an UPDATE query clearing deleted_at of a row and returning the
+// value it had.
+func restore(ctx context.Context, db *sql.DB, query string, id uint64) (time.Time, bool, error) {
+	var deletedAt time.Time
+
+	err := db.QueryRowContext(ctx, query, id).Scan(&deletedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, false, nil
+		}
+
+		return time.Time{}, false, fmt.Errorf("restore error: %s", err.Error())
+	}
+
+	return deletedAt, true, nil
+}
+
+// PurgeDeleted permanently removes the films and actors deleted before the
+// given time, their relations go with them through ON DELETE CASCADE. The
+// report lists the removed records as they were in the trash and the images
+// they left behind.
+func (repo *PsxRepo) PurgeDeleted(ctx context.Context, before time.Time) (*models.PurgeReport, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("purge begin error: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	report := &models.PurgeReport{Images: make(map[string][]string)}
+
+	err = purgeFilms(ctx, tx, before, report)
+	if err != nil {
+		return nil, fmt.Errorf("purge films error: %s", err.Error())
+	}
+
+	err = purgeActors(ctx, tx, before, report)
+	if err != nil {
+		return nil, fmt.Errorf("purge actors error: %s", err.Error())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("purge commit error: %s", err.Error())
+	}
+
+	return report, nil
+}
+
+func purgeFilms(ctx context.Context, tx *sql.Tx, before time.Time, report *models.PurgeReport) error {
+	rows, err := tx.QueryContext(ctx, "DELETE FROM film WHERE film.deleted_at < $1 "+
+		"RETURNING film.id, film.title, film.info, film.rating, film.votes, film.release_date, film.poster, film.deleted_at", before)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		film := models.FilmItem{}
+		var poster sql.NullString
+		var deletedAt time.Time
+
+		err := rows.Scan(&film.Id, &film.Title, &film.Info, &film.Rating, &film.Votes, &film.ReleaseDate, &poster, &deletedAt)
+		if err != nil {
+			return err
+		}
+
+		film.DeletedAt = &deletedAt
+		if poster.Valid {
+			film.Poster = images.Urls(images.DirFilms, poster.String)
+			report.Images[images.DirFilms] = append(report.Images[images.DirFilms], poster.String)
+		}
+		report.PurgedFilms = append(report.PurgedFilms, film)
+	}
+	report.Films = int64(len(report.PurgedFilms))
+
+	return rows.Err()
+}
+
+func purgeActors(ctx context.Context, tx *sql.Tx, before time.Time, report *models.PurgeReport) error {
+	rows, err := tx.QueryContext(ctx, "DELETE FROM actor WHERE actor.deleted_at < $1 "+
+		"RETURNING actor.id, actor.name, actor.gen, actor.birthdate, actor.photo, actor.deleted_at", before)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		actor := models.ActorResponse{Films: make([]models.ActorCreditItem, 0)}
+		var photo sql.NullString
+		var deletedAt time.Time
+
+		err := rows.Scan(&actor.Id, &actor.Name, &actor.Gender, &actor.Birthday, &photo, &deletedAt)
+		if err != nil {
+			return err
+		}
+
+		actor.DeletedAt = &deletedAt
+		if photo.Valid {
+			actor.Photo = images.Urls(images.DirActors, photo.String)
+			report.Images[images.DirActors] = append(report.Images[images.DirActors], photo.String)
+		}
+		report.PurgedActors = append(report.PurgedActors, actor)
+	}
+	report.Actors = int64(len(report.PurgedActors))
+
+	return rows.Err()
+}
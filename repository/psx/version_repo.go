@@ -0,0 +1,41 @@
+package psx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// GetCatalogVersion returns the catalog_version counter name, one of the
+// utils.CatalogVersion names, which moves on with every change of the data
+// it stands for.
+func (repo *PsxRepo) GetCatalogVersion(ctx context.Context, name string) (uint64, error) {
+	var version uint64
+
+	err := repo.db.QueryRowContext(ctx, "SELECT catalog_version.version FROM catalog_version WHERE catalog_version.name = $1", name).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("get catalog version %s error: %s", name, err.Error())
+	}
+
+	return version, nil
+}
+
+// BumpCatalogVersions moves on the catalog_version counters names. It is run
+// on its own once a change is committed rather than inside the transaction
+// of the change, so writers do not queue on the counter rows.
+func (repo *PsxRepo) BumpCatalogVersions(ctx context.Context, names []string) error {
+	var args queryArgs
+
+	placeholders := make([]string, 0, len(names))
+	for _, name := range names {
+		placeholders = append(placeholders, args.add(name))
+	}
+
+	_, err := repo.db.ExecContext(ctx, "UPDATE catalog_version SET version = version + 1 "+
+		"WHERE catalog_version.name IN ("+strings.Join(placeholders, ", ")+")", args.params...)
+	if err != nil {
+		return fmt.Errorf("bump catalog versions %s error: %s", strings.Join(names, ", "), err.Error())
+	}
+
+	return nil
+}
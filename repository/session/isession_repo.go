@@ -7,8 +7,12 @@ import (
 )
 
 type ISessionRepo interface {
-	AddSession(ctx context.Context, active models.Session, log *logrus.Logger) (bool, error)
-	CheckActiveSession(ctx context.Context, sid string, lg *logrus.Logger) (bool, error)
-	GetUserLogin(ctx context.Context, sid string, lg *logrus.Logger) (string, error)
+	AddSession(ctx context.Context, active models.Session, log *logrus.Logger) error
+	GetSessionsVersion(ctx context.Context, userId uint64, lg *logrus.Logger) (uint64, error)
+	GetSession(ctx context.Context, sid string, lg *logrus.Logger) (*models.Session, bool, error)
+	TouchSession(ctx context.Context, active models.Session, lg *logrus.Logger) error
+	FindUserSessions(ctx context.Context, userId uint64, lg *logrus.Logger) ([]models.Session, error)
 	DeleteSession(ctx context.Context, sid string, lg *logrus.Logger) (bool, error)
+	DeleteSessions(ctx context.Context, userId uint64, sids []string, lg *logrus.Logger) error
+	DeleteUserSessions(ctx context.Context, userId uint64, lg *logrus.Logger) error
 }
@@ -2,8 +2,10 @@ package session
 
 import (
 	"context"
+	"encoding/json"
 	"filmoteka/configs"
 	"filmoteka/pkg/models"
+	"fmt"
 	"github.com/go-redis/redis/v8"
 	"github.com/sirupsen/logrus"
 	"time"
@@ -30,45 +32,185 @@ func GetAuthRepo(cfg *configs.DbRedisCfg, log *logrus.Logger) (ISessionRepo, err
 	return &SessionRepo{DB: redisClient}, nil
 }
 
-func (repo *SessionRepo) AddSession(ctx context.Context, active models.Session, log *logrus.Logger) (bool, error) {
+// sessionKey is the key of the session record, userSessionsKey the key of the
+// set of session ids of a user, which lets all of them be found and revoked.
+func sessionKey(sid string) string {
+	return "session:" + sid
+}
+
+func userSessionsKey(userId uint64) string {
+	return fmt.Sprintf("user_sessions:%d", userId)
+}
+
+// sessionsVersionKey is the key of the session version of a user. Sessions
+// are made with the version current at signin and are void once it is
+// incremented, which revokes all of them at once. It never expires, or a void
+// session would become valid again.
+func sessionsVersionKey(userId uint64) string {
+	return fmt.Sprintf("user_sessions_version:%d", userId)
+}
 
-	repo.DB.Set(ctx, active.SID, active.Login, 24*time.Hour)
+func (repo *SessionRepo) GetSessionsVersion(ctx context.Context, userId uint64, lg *logrus.Logger) (uint64, error) {
+	version, err := repo.DB.Get(ctx, sessionsVersionKey(userId)).Uint64()
+	if err == redis.Nil {
+		return 0, nil
+	}
 
-	added, err := repo.CheckActiveSession(ctx, active.SID, log)
 	if err != nil {
-		return false, err
+		lg.Error("Get sessions version request could not be completed: ", err)
+		return 0, err
 	}
 
-	return added, nil
+	return version, nil
 }
 
-func (repo *SessionRepo) CheckActiveSession(ctx context.Context, sid string, lg *logrus.Logger) (bool, error) {
-	_, err := repo.DB.Get(ctx, sid).Result()
+func (repo *SessionRepo) AddSession(ctx context.Context, active models.Session, log *logrus.Logger) error {
+	data, err := json.Marshal(active)
+	if err != nil {
+		log.Error("Marshal session error: ", err)
+		return err
+	}
+
+	ttl := time.Until(active.ExpiresAt)
+	_, err = repo.DB.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, sessionKey(active.SID), data, ttl)
+		pipe.SAdd(ctx, userSessionsKey(active.UserId), active.SID)
+		pipe.Expire(ctx, userSessionsKey(active.UserId), ttl)
+		return nil
+	})
+	if err != nil {
+		log.Error("Add session request could not be completed: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (repo *SessionRepo) GetSession(ctx context.Context, sid string, lg *logrus.Logger) (*models.Session, bool, error) {
+	data, err := repo.DB.Get(ctx, sessionKey(sid)).Bytes()
 	if err == redis.Nil {
-		lg.Error("Key " + sid + " not found")
-		return false, nil
+		return nil, false, nil
 	}
 
 	if err != nil {
 		lg.Error("Get request could not be completed ", err)
-		return false, err
+		return nil, false, err
 	}
 
-	return true, err
+	active := &models.Session{}
+	err = json.Unmarshal(data, active)
+	if err != nil {
+		lg.Error("Unmarshal session error: ", err)
+		return nil, false, err
+	}
+	active.SID = sid
+
+	version, err := repo.GetSessionsVersion(ctx, active.UserId, lg)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if active.Version < version {
+		err = repo.DeleteSessions(ctx, active.UserId, []string{sid}, lg)
+		if err != nil {
+			lg.Error("Delete void session request could not be completed: ", err)
+		}
+		return nil, false, nil
+	}
+
+	return active, true, nil
 }
 
-func (repo *SessionRepo) GetUserLogin(ctx context.Context, sid string, lg *logrus.Logger) (string, error) {
-	value, err := repo.DB.Get(ctx, sid).Result()
+// TouchSession rewrites the session record, keeping its expiry. A session
+// that has expired or been deleted in the meantime is not brought back.
+func (repo *SessionRepo) TouchSession(ctx context.Context, active models.Session, lg *logrus.Logger) error {
+	data, err := json.Marshal(active)
+	if err != nil {
+		lg.Error("Marshal session error: ", err)
+		return err
+	}
+
+	_, err = repo.DB.SetXX(ctx, sessionKey(active.SID), data, redis.KeepTTL).Result()
 	if err != nil {
-		lg.Error("Error, cannot find session " + sid)
-		return "", err
+		lg.Error("Touch session request could not be completed: ", err)
+		return err
 	}
 
-	return value, nil
+	return nil
+}
+
+// FindUserSessions returns the live sessions of the user and drops expired
+// and void ones.
+func (repo *SessionRepo) FindUserSessions(ctx context.Context, userId uint64, lg *logrus.Logger) ([]models.Session, error) {
+	sids, err := repo.DB.SMembers(ctx, userSessionsKey(userId)).Result()
+	if err != nil {
+		lg.Error("Get user sessions request could not be completed: ", err)
+		return nil, err
+	}
+	if len(sids) == 0 {
+		return []models.Session{}, nil
+	}
+
+	keys := make([]string, 0, len(sids))
+	for _, sid := range sids {
+		keys = append(keys, sessionKey(sid))
+	}
+
+	values, err := repo.DB.MGet(ctx, keys...).Result()
+	if err != nil {
+		lg.Error("Get user sessions request could not be completed: ", err)
+		return nil, err
+	}
+
+	version, err := repo.GetSessionsVersion(ctx, userId, lg)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]models.Session, 0, len(sids))
+	expired := make([]string, 0)
+	for i, value := range values {
+		data, isString := value.(string)
+		if !isString {
+			expired = append(expired, sids[i])
+			continue
+		}
+
+		var active models.Session
+		err = json.Unmarshal([]byte(data), &active)
+		if err != nil {
+			lg.Error("Unmarshal session error: ", err)
+			return nil, err
+		}
+		active.SID = sids[i]
+
+		if active.Version < version {
+			expired = append(expired, sids[i])
+			continue
+		}
+
+		sessions = append(sessions, active)
+	}
+
+	err = repo.DeleteSessions(ctx, userId, expired, lg)
+	if err != nil {
+		lg.Error("Remove expired sessions request could not be completed: ", err)
+	}
+
+	return sessions, nil
 }
 
 func (repo *SessionRepo) DeleteSession(ctx context.Context, sid string, lg *logrus.Logger) (bool, error) {
-	_, err := repo.DB.Del(ctx, sid).Result()
+	active, found, err := repo.GetSession(ctx, sid, lg)
+	if err != nil || !found {
+		return false, err
+	}
+
+	_, err = repo.DB.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, sessionKey(sid))
+		pipe.SRem(ctx, userSessionsKey(active.UserId), sid)
+		return nil
+	})
 	if err != nil {
 		lg.Error("Delete request could not be completed:", err)
 		return false, err
@@ -76,3 +218,42 @@ func (repo *SessionRepo) DeleteSession(ctx context.Context, sid string, lg *logr
 
 	return true, nil
 }
+
+func (repo *SessionRepo) DeleteSessions(ctx context.Context, userId uint64, sids []string, lg *logrus.Logger) error {
+	if len(sids) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(sids))
+	members := make([]interface{}, 0, len(sids))
+	for _, sid := range sids {
+		keys = append(keys, sessionKey(sid))
+		members = append(members, sid)
+	}
+
+	_, err := repo.DB.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, keys...)
+		pipe.SRem(ctx, userSessionsKey(userId), members...)
+		return nil
+	})
+	if err != nil {
+		lg.Error("Delete sessions request could not be completed: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteUserSessions voids every session of the user by incrementing the
+// session version in one atomic step, so a session added at the same time is
+// void as well unless it was made with the new version. The records are
+// removed when they are next read.
+func (repo *SessionRepo) DeleteUserSessions(ctx context.Context, userId uint64, lg *logrus.Logger) error {
+	_, err := repo.DB.Incr(ctx, sessionsVersionKey(userId)).Result()
+	if err != nil {
+		lg.Error("Delete user sessions request could not be completed: ", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package storage
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+// IStorage keeps files by slash separated keys such as "films/1f0c.../small.jpg".
+type IStorage interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Open(ctx context.Context, key string) (*models.StoredFile, bool, error)
+	Delete(ctx context.Context, key string) error
+}
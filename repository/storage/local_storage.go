@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"filmoteka/configs"
+	"filmoteka/pkg/models"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// LocalStorage keeps files under a directory of the local file system.
+type LocalStorage struct {
+	root string
+}
+
+func GetLocalStorage(cfg *configs.StorageCfg, log *logrus.Logger) (IStorage, error) {
+	root, err := filepath.Abs(cfg.Dir)
+	if err != nil {
+		log.Error("Storage dir error: ", err)
+		return nil, err
+	}
+
+	err = os.MkdirAll(root, 0o755)
+	if err != nil {
+		log.Error("Create storage dir error: ", err)
+		return nil, err
+	}
+
+	log.Info("Local storage created successful in ", root)
+	return &LocalStorage{root: root}, nil
+}
+
+// path returns the file of key, keys leaving the root are rejected.
+func (s *LocalStorage) path(key string) (string, error) {
+	name := filepath.FromSlash(key)
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("storage key %q is not local", key)
+	}
+
+	return filepath.Join(s.root, name), nil
+}
+
+// Put writes data under key through a temporary file, so that readers never
+// see a partly written file.
+func (s *LocalStorage) Put(ctx context.Context, key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return fmt.Errorf("put %s error: %s", key, err.Error())
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("put %s error: %s", key, err.Error())
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(data)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("put %s error: %s", key, err.Error())
+	}
+
+	err = file.Close()
+	if err != nil {
+		return fmt.Errorf("put %s error: %s", key, err.Error())
+	}
+
+	err = os.Chmod(file.Name(), 0o644)
+	if err != nil {
+		return fmt.Errorf("put %s error: %s", key, err.Error())
+	}
+
+	err = os.Rename(file.Name(), path)
+	if err != nil {
+		return fmt.Errorf("put %s error: %s", key, err.Error())
+	}
+
+	return nil
+}
+
+// Open returns the file under key, found is false when there is none.
+func (s *LocalStorage) Open(ctx context.Context, key string) (*models.StoredFile, bool, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("open %s error: %s", key, err.Error())
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, false, fmt.Errorf("open %s error: %s", key, err.Error())
+	}
+
+	if info.IsDir() {
+		file.Close()
+		return nil, false, nil
+	}
+
+	return &models.StoredFile{ReadSeekCloser: file, ModTime: info.ModTime()}, true, nil
+}
+
+// Delete removes the file under key, a missing file is not an error. The
+// directory of the file is removed as well once it is empty.
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("delete %s error: %s", key, err.Error())
+	}
+
+	// fails while other files are left in the directory
+	if filepath.Dir(path) != s.root {
+		_ = os.Remove(filepath.Dir(path))
+	}
+
+	return nil
+}
@@ -3,18 +3,35 @@ CREATE TABLE IF NOT EXISTS actor (
                                      id          SERIAL NOT NULL PRIMARY KEY,
                                      name        TEXT NOT NULL DEFAULT '',
                                      gen         TEXT NOT NULL DEFAULT '',
-                                     birthdate   DATE NOT NULL DEFAULT CURRENT_DATE
+                                     birthdate   DATE NOT NULL DEFAULT CURRENT_DATE,
+                                     photo       TEXT,
+                                     created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+                                     deleted_at  TIMESTAMPTZ
 );
 
+CREATE INDEX IF NOT EXISTS actor_name_idx ON actor(name, id);
+CREATE INDEX IF NOT EXISTS actor_birthdate_idx ON actor(birthdate, id);
+CREATE INDEX IF NOT EXISTS actor_created_at_idx ON actor(created_at);
+CREATE INDEX IF NOT EXISTS actor_deleted_at_idx ON actor(deleted_at) WHERE deleted_at IS NOT NULL;
+
 DROP TABLE IF EXISTS film CASCADE;
 CREATE TABLE IF NOT EXISTS film (
                                     id              SERIAL NOT NULL PRIMARY KEY,
                                     title           TEXT   NOT NULL DEFAULT '',
                                     info            TEXT   NOT NULL DEFAULT '',
                                     release_date    DATE NOT NULL DEFAULT CURRENT_DATE,
-                                    rating          FLOAT NOT NULL DEFAULT 0
+                                    rating          FLOAT NOT NULL DEFAULT 0,
+                                    votes           INTEGER NOT NULL DEFAULT 0,
+                                    fts             TSVECTOR NOT NULL DEFAULT '',
+                                    poster          TEXT,
+                                    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+                                    deleted_at      TIMESTAMPTZ
 );
 
+CREATE INDEX IF NOT EXISTS film_fts_idx ON film USING GIN(fts);
+CREATE INDEX IF NOT EXISTS film_created_at_idx ON film(created_at);
+CREATE INDEX IF NOT EXISTS film_deleted_at_idx ON film(deleted_at) WHERE deleted_at IS NOT NULL;
+
 DROP TABLE IF EXISTS actor_in_film CASCADE;
 CREATE TABLE IF NOT EXISTS actor_in_film(
                                             id_film SERIAL NOT NULL REFERENCES film(id)
@@ -23,16 +40,345 @@ CREATE TABLE IF NOT EXISTS actor_in_film(
     id_actor SERIAL NOT NULL REFERENCES actor(id)
     ON DELETE CASCADE
     ON UPDATE CASCADE,
+    role            TEXT NOT NULL DEFAULT 'actor'
+    CHECK (role IN ('actor', 'director', 'writer', 'producer', 'composer')),
+    character_name  TEXT NOT NULL DEFAULT '',
+    billing_order   INTEGER NOT NULL DEFAULT 0,
+
+    PRIMARY KEY(id_actor, id_film, role)
+    );
+
+CREATE INDEX IF NOT EXISTS actor_in_film_id_film_idx ON actor_in_film(id_film, role);
+
+-- film.fts indexes the title, the description and the cast names of a film
+-- in both Russian and English, the triggers below keep it up to date.
+-- Actors in the trash are left out until they are restored.
+CREATE OR REPLACE FUNCTION film_fts_document(film_id INTEGER, film_title TEXT, film_info TEXT) RETURNS TSVECTOR AS $$
+DECLARE
+    names TEXT;
+BEGIN
+    SELECT COALESCE(string_agg(actor.name, ' '), '') INTO names FROM actor
+        JOIN actor_in_film ON actor_in_film.id_actor = actor.id
+        WHERE actor_in_film.id_film = film_id AND actor.deleted_at IS NULL;
+
+    RETURN setweight(to_tsvector('russian', film_title), 'A') ||
+           setweight(to_tsvector('english', film_title), 'A') ||
+           setweight(to_tsvector('russian', film_info), 'B') ||
+           setweight(to_tsvector('english', film_info), 'B') ||
+           setweight(to_tsvector('russian', names), 'C') ||
+           setweight(to_tsvector('english', names), 'C');
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+CREATE OR REPLACE FUNCTION film_fts_refresh() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.fts := film_fts_document(NEW.id, NEW.title, NEW.info);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS film_fts_refresh ON film;
+CREATE TRIGGER film_fts_refresh
+    BEFORE INSERT OR UPDATE OF title, info ON film
+    FOR EACH ROW EXECUTE FUNCTION film_fts_refresh();
+
+CREATE OR REPLACE FUNCTION film_fts_refresh_cast() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE film SET fts = film_fts_document(film.id, film.title, film.info) WHERE film.id = OLD.id_film;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE film SET fts = film_fts_document(film.id, film.title, film.info) WHERE film.id = NEW.id_film;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS film_fts_refresh_cast ON actor_in_film;
+CREATE TRIGGER film_fts_refresh_cast
+    AFTER INSERT OR UPDATE OR DELETE ON actor_in_film
+    FOR EACH ROW EXECUTE FUNCTION film_fts_refresh_cast();
+
+CREATE OR REPLACE FUNCTION film_fts_refresh_actor() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE film SET fts = film_fts_document(film.id, film.title, film.info)
+        WHERE film.id IN (SELECT actor_in_film.id_film FROM actor_in_film WHERE actor_in_film.id_actor = NEW.id);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS film_fts_refresh_actor ON actor;
+CREATE TRIGGER film_fts_refresh_actor
+    AFTER UPDATE OF name, deleted_at ON actor
+    FOR EACH ROW EXECUTE FUNCTION film_fts_refresh_actor();
+
+DROP TABLE IF EXISTS genre CASCADE;
+CREATE TABLE IF NOT EXISTS genre (
+                                     id      SERIAL NOT NULL PRIMARY KEY,
+                                     name    TEXT NOT NULL UNIQUE DEFAULT ''
+);
+
+DROP TABLE IF EXISTS genre_in_film CASCADE;
+CREATE TABLE IF NOT EXISTS genre_in_film(
+                                            id_film INTEGER NOT NULL REFERENCES film(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+    id_genre INTEGER NOT NULL REFERENCES genre(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
 
-    PRIMARY KEY(id_actor, id_film)
+    PRIMARY KEY(id_film, id_genre)
     );
 
+CREATE INDEX IF NOT EXISTS genre_in_film_id_genre_idx ON genre_in_film(id_genre);
+
+-- password is an argon2id hash in the PHC string format. Rows from before it
+-- hold an unsalted SHA-512 digest, which is replaced at the next signin.
 DROP TABLE IF EXISTS profile CASCADE;
 CREATE TABLE IF NOT EXISTS profile (
                                        id SERIAL NOT NULL PRIMARY KEY,
                                        login TEXT NOT NULL UNIQUE DEFAULT '',
                                        password bytea NOT NULL DEFAULT '',
+                                       password_algo TEXT NOT NULL DEFAULT 'sha512' CHECK (password_algo IN ('argon2id', 'sha512')),
                                        role TEXT NOT NULL DEFAULT 'user'
 );
 
-INSERT INTO profile(login, password, role) VALUES ('admin', '\xc7ad44cbad762a5da0a452f9e854fdc1e0e7a52a38015f23f3eab1d80b931dd472634dfac71cd34ebc35d16ab7fb8a90c81f975113d6c7538dc69dd8de9077ec', 'admin');
\ No newline at end of file
+-- Personal access tokens. Only the SHA-256 of a token is kept, the token itself
+-- is shown once when it is created. scopes is space separated as in OAuth.
+DROP TABLE IF EXISTS access_token CASCADE;
+CREATE TABLE IF NOT EXISTS access_token (
+                                            id           SERIAL NOT NULL PRIMARY KEY,
+                                            id_profile   INTEGER NOT NULL REFERENCES profile(id)
+                                                ON DELETE CASCADE
+                                                ON UPDATE CASCADE,
+                                            name         TEXT NOT NULL,
+                                            token_hash   bytea NOT NULL UNIQUE,
+                                            scopes       TEXT NOT NULL CHECK (scopes <> ''),
+                                            created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+                                            last_used_at TIMESTAMPTZ,
+                                            revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS access_token_id_profile_idx ON access_token(id_profile);
+
+DROP TABLE IF EXISTS film_rating CASCADE;
+CREATE TABLE IF NOT EXISTS film_rating(
+                                          id_film INTEGER NOT NULL REFERENCES film(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+    id_profile INTEGER NOT NULL REFERENCES profile(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+    rating      SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 10),
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    PRIMARY KEY(id_film, id_profile)
+    );
+
+CREATE INDEX IF NOT EXISTS film_rating_id_profile_idx ON film_rating(id_profile);
+
+-- film.rating and film.votes are the aggregate of film_rating and are kept in sync here.
+CREATE OR REPLACE FUNCTION film_rating_refresh() RETURNS TRIGGER AS $$
+DECLARE
+    film_id INTEGER;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        film_id := OLD.id_film;
+    ELSE
+        film_id := NEW.id_film;
+    END IF;
+
+    UPDATE film SET
+        rating = COALESCE((SELECT AVG(film_rating.rating) FROM film_rating WHERE film_rating.id_film = film_id), 0),
+        votes = (SELECT COUNT(*) FROM film_rating WHERE film_rating.id_film = film_id)
+    WHERE film.id = film_id;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS film_rating_refresh ON film_rating;
+CREATE TRIGGER film_rating_refresh
+    AFTER INSERT OR UPDATE OR DELETE ON film_rating
+    FOR EACH ROW EXECUTE FUNCTION film_rating_refresh();
+
+DROP TABLE IF EXISTS review CASCADE;
+CREATE TABLE IF NOT EXISTS review (
+                                      id          SERIAL NOT NULL PRIMARY KEY,
+                                      id_film     INTEGER NOT NULL REFERENCES film(id)
+                                          ON DELETE CASCADE
+                                          ON UPDATE CASCADE,
+                                      id_profile  INTEGER NOT NULL REFERENCES profile(id)
+                                          ON DELETE CASCADE
+                                          ON UPDATE CASCADE,
+                                      title       TEXT NOT NULL DEFAULT '',
+                                      body        TEXT NOT NULL DEFAULT '',
+                                      spoiler     BOOLEAN NOT NULL DEFAULT false,
+                                      hidden      BOOLEAN NOT NULL DEFAULT false,
+                                      created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+                                      edited_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS review_id_film_idx ON review(id_film, created_at DESC);
+
+DROP TABLE IF EXISTS film_list CASCADE;
+CREATE TABLE IF NOT EXISTS film_list(
+                                        id_profile INTEGER NOT NULL REFERENCES profile(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+    id_film INTEGER NOT NULL REFERENCES film(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+    list        TEXT NOT NULL CHECK (list IN ('favorite', 'watchlist')),
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    PRIMARY KEY(id_profile, list, id_film)
+    );
+
+CREATE INDEX IF NOT EXISTS film_list_id_film_idx ON film_list(id_film);
+
+DROP TABLE IF EXISTS franchise CASCADE;
+CREATE TABLE IF NOT EXISTS franchise (
+                                         id      SERIAL NOT NULL PRIMARY KEY,
+                                         name    TEXT NOT NULL UNIQUE DEFAULT '',
+                                         info    TEXT NOT NULL DEFAULT ''
+);
+
+-- position is the place of a film in the story of the franchise, films with
+-- the same position follow each other by release date.
+DROP TABLE IF EXISTS film_in_franchise CASCADE;
+CREATE TABLE IF NOT EXISTS film_in_franchise(
+                                                id_franchise INTEGER NOT NULL REFERENCES franchise(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+    id_film INTEGER NOT NULL REFERENCES film(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+    position    INTEGER NOT NULL DEFAULT 0,
+
+    PRIMARY KEY(id_franchise, id_film)
+    );
+
+CREATE INDEX IF NOT EXISTS film_in_franchise_id_film_idx ON film_in_franchise(id_film);
+
+-- film_relation reads as "id_related is a <kind> of id_film".
+DROP TABLE IF EXISTS film_relation CASCADE;
+CREATE TABLE IF NOT EXISTS film_relation(
+                                            id_film INTEGER NOT NULL REFERENCES film(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+    id_related INTEGER NOT NULL REFERENCES film(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+    kind        TEXT NOT NULL CHECK (kind IN ('sequel', 'prequel', 'remake', 'spin_off')),
+
+    PRIMARY KEY(id_film, id_related, kind),
+    CHECK (id_film <> id_related)
+    );
+
+CREATE INDEX IF NOT EXISTS film_relation_id_related_idx ON film_relation(id_related);
+
+-- film_similarity holds the item-item collaborative filtering model: for
+-- every film the films rated most alike by the same users, with the cosine
+-- of their mean-centred ratings. It is rebuilt by a background job.
+DROP TABLE IF EXISTS film_similarity CASCADE;
+CREATE TABLE IF NOT EXISTS film_similarity(
+                                              id_film INTEGER NOT NULL REFERENCES film(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+    id_similar INTEGER NOT NULL REFERENCES film(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+    score      FLOAT NOT NULL,
+    raters     INTEGER NOT NULL,
+
+    PRIMARY KEY(id_film, id_similar)
+    );
+
+-- catalog_version counts the changes behind data derived from the catalog
+-- and cached, by name: similar_films moves on with every change of the
+-- casts, the genres or the fields of films the similar films are ranked and
+-- shown by, cast_graph with every change of who played in which films.
+-- Caches are keyed by or checked against it, so they go stale at once. The
+-- service bumps the counters once a change is committed, not from triggers
+-- inside the writing transactions, which would all queue on these rows.
+DROP TABLE IF EXISTS catalog_version CASCADE;
+CREATE TABLE IF NOT EXISTS catalog_version(
+                                            name    TEXT NOT NULL PRIMARY KEY,
+                                            version BIGINT NOT NULL DEFAULT 0
+);
+
+INSERT INTO catalog_version(name) VALUES ('similar_films'), ('cast_graph') ON CONFLICT DO NOTHING;
+
+-- film_revision and actor_revision keep every saved state of a film or an
+-- actor, cast included, as a JSON document numbered from 1 per record.
+DROP TABLE IF EXISTS film_revision CASCADE;
+CREATE TABLE IF NOT EXISTS film_revision(
+                                            id_film INTEGER NOT NULL REFERENCES film(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+    revision    INTEGER NOT NULL,
+    id_profile  INTEGER REFERENCES profile(id)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE,
+    data        JSONB NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    PRIMARY KEY(id_film, revision)
+    );
+
+DROP TABLE IF EXISTS actor_revision CASCADE;
+CREATE TABLE IF NOT EXISTS actor_revision(
+                                             id_actor INTEGER NOT NULL REFERENCES actor(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+    revision    INTEGER NOT NULL,
+    id_profile  INTEGER REFERENCES profile(id)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE,
+    data        JSONB NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    PRIMARY KEY(id_actor, revision)
+    );
+
+DROP TABLE IF EXISTS audit_log CASCADE;
+CREATE TABLE IF NOT EXISTS audit_log(
+                                        id          BIGSERIAL NOT NULL PRIMARY KEY,
+                                        id_profile  INTEGER,
+                                        action      TEXT NOT NULL,
+                                        entity      TEXT NOT NULL,
+                                        entity_id   INTEGER,
+                                        before      JSONB,
+                                        after       JSONB,
+                                        ip          TEXT NOT NULL DEFAULT '',
+                                        request_id  TEXT NOT NULL DEFAULT '',
+                                        created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON audit_log(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS audit_log_id_profile_idx ON audit_log(id_profile, created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log(entity, entity_id, created_at DESC);
+
+-- audit_log is append-only: id_profile is not a foreign key so that entries
+-- outlive their authors, and rows can be neither changed nor removed.
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+DROP TRIGGER IF EXISTS audit_log_no_truncate ON audit_log;
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+INSERT INTO profile(login, password, password_algo, role) VALUES ('admin', convert_to('$argon2id$v=19$m=65536,t=3,p=2$lgX7V242K0mMdYYUguPO/Q$vmz84FoZ7L1ydIHIsPJErxBDjrsXIcDKE/zlEbk7PEs', 'UTF8'), 'argon2id', 'admin');
\ No newline at end of file
@@ -2,21 +2,33 @@ package core
 
 import (
 	"context"
+	utils "filmoteka/pkg"
 	"filmoteka/pkg/models"
 	"filmoteka/repository/psx"
+	core_audit "filmoteka/usecase/audit"
+	core_revisions "filmoteka/usecase/revisions"
+	core_versions "filmoteka/usecase/versions"
 	"fmt"
 	"github.com/sirupsen/logrus"
+	"slices"
 )
 
 type Actors struct {
-	log    *logrus.Logger
-	actors psx.IActorRepo
+	log       *logrus.Logger
+	actors    psx.IActorRepo
+	revisions core_revisions.IRevisions
+	audit     core_audit.IAudit
+	versions  core_versions.IVersions
 }
 
-func NewCoreActors(actors psx.IActorRepo, log *logrus.Logger) *Actors {
+func NewCoreActors(actors psx.IActorRepo, revisions core_revisions.IRevisions, audit core_audit.IAudit, versions core_versions.IVersions,
+	log *logrus.Logger) *Actors {
 	return &Actors{
-		log:    log,
-		actors: actors,
+		log:       log,
+		actors:    actors,
+		revisions: revisions,
+		audit:     audit,
+		versions:  versions,
 	}
 }
 
@@ -27,11 +39,24 @@ func (c *Actors) AddActor(ctx context.Context, actor *models.ActorItem) (uint64,
 		return 0, fmt.Errorf("add actor error: %s", err.Error())
 	}
 
+	c.versions.Bump(ctx, utils.CatalogVersionSimilarFilms, utils.CatalogVersionCastGraph)
+
+	c.revisions.RecordActor(ctx, actorId)
+	err = c.audit.Record(ctx, utils.AuditActionAdd, utils.AuditEntityActor, actorId, nil, c.snapshot(ctx, actorId))
+	if err != nil {
+		return 0, err
+	}
+
 	return actorId, nil
 }
 
-func (c *Actors) FindActors(ctx context.Context, page uint64, perPage uint64) ([]models.ActorResponse, error) {
-	actors, err := c.actors.FindActors(ctx, page, perPage)
+func (c *Actors) FindActors(ctx context.Context, request *models.FindActorRequest) (*models.ActorsResponse, error) {
+	if !slices.Contains(utils.ActorSorts, request.Sort) {
+		c.log.Error(utils.ActorSortError)
+		return nil, fmt.Errorf(utils.ActorSortError)
+	}
+
+	actors, err := c.actors.FindActors(ctx, request)
 	if err != nil {
 		c.log.Errorf("find actors error: %s", err.Error())
 		return nil, fmt.Errorf("find actors error: %s", err.Error())
@@ -40,22 +65,83 @@ func (c *Actors) FindActors(ctx context.Context, page uint64, perPage uint64) ([
 	return actors, nil
 }
 
-func (c *Actors) UpdateActor(ctx context.Context, actor *models.ActorRequest) error {
-	err := c.actors.UpdateActor(ctx, actor)
+// GetActor returns the actor with actorId and their filmography, found is
+// false when there is no such actor.
+func (c *Actors) GetActor(ctx context.Context, actorId uint64) (*models.ActorResponse, bool, error) {
+	actor, found, err := c.actors.GetActor(ctx, actorId)
+	if err != nil {
+		c.log.Errorf("get actor error: %s", err.Error())
+		return nil, false, fmt.Errorf("get actor error: %s", err.Error())
+	}
+
+	if !found {
+		return nil, false, nil
+	}
+
+	actor.Films, err = c.actors.FindFilmsByActor(ctx, actorId)
+	if err != nil {
+		c.log.Errorf("find actor films error: %s", err.Error())
+		return nil, false, fmt.Errorf("find actor films error: %s", err.Error())
+	}
+
+	return actor, true, nil
+}
+
+// UpdateActor changes the actor, their films are replaced only when they are
+// given. Found is false when there is no such actor outside the trash.
+func (c *Actors) UpdateActor(ctx context.Context, actor *models.ActorRequest) (bool, error) {
+	before := c.snapshot(ctx, actor.Id)
+	// actors saved before the history was kept get their first revision here
+	c.revisions.RecordActor(ctx, actor.Id)
+
+	found, err := c.actors.UpdateActor(ctx, actor)
 	if err != nil {
 		c.log.Errorf("change actor error: %s", err.Error())
-		return fmt.Errorf("change actor error: %s", err.Error())
+		return false, fmt.Errorf("change actor error: %s", err.Error())
+	}
+
+	if !found {
+		return false, nil
+	}
+
+	c.versions.Bump(ctx, utils.CatalogVersionSimilarFilms, utils.CatalogVersionCastGraph)
+
+	c.revisions.RecordActor(ctx, actor.Id)
+	err = c.audit.Record(ctx, utils.AuditActionUpdate, utils.AuditEntityActor, actor.Id, before, c.snapshot(ctx, actor.Id))
+	if err != nil {
+		return false, err
 	}
 
-	return nil
+	return true, nil
 }
 
-func (c *Actors) DeleteActor(ctx context.Context, actorId uint64) error {
-	err := c.actors.DeleteActor(ctx, actorId)
+func (c *Actors) DeleteActor(ctx context.Context, actorId uint64) (bool, error) {
+	before := c.snapshot(ctx, actorId)
+
+	deleted, err := c.actors.DeleteActor(ctx, actorId)
 	if err != nil {
 		c.log.Errorf("delete actor error: %s", err.Error())
-		return fmt.Errorf("delete actor error: %s", err.Error())
+		return false, fmt.Errorf("delete actor error: %s", err.Error())
+	}
+
+	if deleted {
+		c.versions.Bump(ctx, utils.CatalogVersionSimilarFilms, utils.CatalogVersionCastGraph)
+		err = c.audit.Record(ctx, utils.AuditActionDelete, utils.AuditEntityActor, actorId, before, nil)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return deleted, nil
+}
+
+// snapshot returns the actor with the filmography as it is stored in the
+// audit log, or nil when it cannot be read.
+func (c *Actors) snapshot(ctx context.Context, actorId uint64) any {
+	actor, found, err := c.GetActor(ctx, actorId)
+	if err != nil || !found {
+		return nil
 	}
 
-	return nil
+	return actor
 }
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/models"
+	"filmoteka/repository/psx"
+	"fmt"
+	"github.com/sirupsen/logrus"
+)
+
+type Audit struct {
+	log   *logrus.Logger
+	audit psx.IAuditRepo
+}
+
+func NewCoreAudit(audit psx.IAuditRepo, log *logrus.Logger) *Audit {
+	return &Audit{
+		log:   log,
+		audit: audit,
+	}
+}
+
+// Record appends to the audit log that the user of ctx applied action to the
+// entity, with its state before and after the change; either may be nil. The
+// change is already applied when Record is called, so the entry is written
+// even if the request is cancelled meanwhile. A failed write is returned, so
+// that the request fails rather than reports an unaudited change as done.
+func (c *Audit) Record(ctx context.Context, action string, entity string, entityId uint64, before any, after any) error {
+	entry := &models.AuditEntry{Action: action, Entity: entity, EntityId: entityId}
+	entry.UserId, _ = ctx.Value(utils.UserIDKey).(uint64)
+	entry.Ip, _ = ctx.Value(utils.ClientIPKey).(string)
+	entry.RequestId, _ = ctx.Value(utils.RequestIDKey).(string)
+
+	var err error
+	if before != nil {
+		entry.Before, err = json.Marshal(before)
+		if err != nil {
+			c.log.Errorf("audit %s %s %d marshal error: %s", action, entity, entityId, err.Error())
+			return fmt.Errorf("audit %s %s %d marshal error: %s", action, entity, entityId, err.Error())
+		}
+	}
+	if after != nil {
+		entry.After, err = json.Marshal(after)
+		if err != nil {
+			c.log.Errorf("audit %s %s %d marshal error: %s", action, entity, entityId, err.Error())
+			return fmt.Errorf("audit %s %s %d marshal error: %s", action, entity, entityId, err.Error())
+		}
+	}
+
+	err = c.audit.AddAuditEntry(context.WithoutCancel(ctx), entry)
+	if err != nil {
+		c.log.Errorf("audit %s %s %d error: %s", action, entity, entityId, err.Error())
+		return fmt.Errorf("audit %s %s %d error: %s", action, entity, entityId, err.Error())
+	}
+
+	return nil
+}
+
+func (c *Audit) FindAudit(ctx context.Context, request *models.FindAuditRequest) (*models.AuditResponse, error) {
+	entries, err := c.audit.FindAuditEntries(ctx, request)
+	if err != nil {
+		c.log.Errorf("find audit entries error: %s", err.Error())
+		return nil, fmt.Errorf("find audit entries error: %s", err.Error())
+	}
+
+	return entries, nil
+}
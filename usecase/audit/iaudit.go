@@ -0,0 +1,11 @@
+package core
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type IAudit interface {
+	Record(ctx context.Context, action string, entity string, entityId uint64, before any, after any) error
+	FindAudit(ctx context.Context, request *models.FindAuditRequest) (*models.AuditResponse, error)
+}
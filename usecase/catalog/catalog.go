@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/catalog"
+	"filmoteka/pkg/models"
+	"filmoteka/repository/psx"
+	core_audit "filmoteka/usecase/audit"
+	core_versions "filmoteka/usecase/versions"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"io"
+	"slices"
+	"time"
+)
+
+type Catalog struct {
+	log      *logrus.Logger
+	catalog  psx.ICatalogRepo
+	audit    core_audit.IAudit
+	versions core_versions.IVersions
+}
+
+func NewCoreCatalog(catalog psx.ICatalogRepo, audit core_audit.IAudit, versions core_versions.IVersions, log *logrus.Logger) *Catalog {
+	return &Catalog{
+		log:      log,
+		catalog:  catalog,
+		audit:    audit,
+		versions: versions,
+	}
+}
+
+// ImportCatalog validates every record of catalog and stores them all, or
+// nothing when dryRun is set. Invalid records are reported without touching
+// the database.
+func (c *Catalog) ImportCatalog(ctx context.Context, catalog *models.Catalog, dryRun bool) (*models.ImportReport, error) {
+	errs := c.validateCatalog(catalog)
+	if len(errs) > 0 {
+		return &models.ImportReport{DryRun: dryRun, Errors: errs}, nil
+	}
+
+	report, err := c.catalog.ImportCatalog(ctx, catalog, dryRun)
+	if err != nil {
+		c.log.Errorf("import catalog error: %s", err.Error())
+		return nil, fmt.Errorf("import catalog error: %s", err.Error())
+	}
+
+	if report.Applied {
+		c.versions.Bump(ctx, utils.CatalogVersionSimilarFilms, utils.CatalogVersionCastGraph)
+		err = c.audit.Record(ctx, utils.AuditActionImport, utils.AuditEntityCatalog, 0, nil, report)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// ExportCatalog writes the films matching request with their actors and
+// links to w in format, or the whole catalog when request is nil. The output
+// can be imported back with ImportCatalog.
+func (c *Catalog) ExportCatalog(ctx context.Context, request *models.FindFilmRequest, format string, w io.Writer) error {
+	encoder, err := catalog.NewEncoder(format, w)
+	if err != nil {
+		c.log.Errorf("export catalog error: %s", err.Error())
+		return fmt.Errorf("export catalog error: %s", err.Error())
+	}
+
+	err = c.catalog.ExportCatalog(ctx, request, encoder.Encode)
+	if err != nil {
+		c.log.Errorf("export catalog error: %s", err.Error())
+		return fmt.Errorf("export catalog error: %s", err.Error())
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		c.log.Errorf("export catalog error: %s", err.Error())
+		return fmt.Errorf("export catalog error: %s", err.Error())
+	}
+
+	return nil
+}
+
+// validateCatalog applies the rules of single film and actor edits to every
+// record, treating an empty link role as an acting credit.
+func (c *Catalog) validateCatalog(catalog *models.Catalog) []models.ImportError {
+	var errs []models.ImportError
+	report := func(source string, err error) {
+		if err != nil {
+			errs = append(errs, models.ImportError{Source: source, Message: err.Error()})
+		}
+	}
+
+	for _, film := range catalog.Films {
+		report(film.Source, utils.ValidateStringSize(film.Title, utils.FilmTitleBegin, utils.FilmTitleEnd, utils.TitleSizeError, c.log))
+		report(film.Source, utils.ValidateStringSize(film.Info, utils.FilmDescriptionBegin, utils.FilmDescriptionEnd, utils.DescriptionSizeError, c.log))
+		report(film.Source, c.validateDate(film.ReleaseDate))
+	}
+
+	for _, actor := range catalog.Actors {
+		report(actor.Source, utils.ValidateStringSize(actor.Name, utils.ActorNameBegin, utils.ActorNameEnd, utils.ActorNameSizeError, c.log))
+		report(actor.Source, c.validateDate(actor.Birthday))
+	}
+
+	for i := range catalog.Links {
+		link := &catalog.Links[i]
+		if link.Role == "" {
+			link.Role = utils.CreditRoleActor
+		}
+
+		report(link.Source, utils.ValidateStringSize(link.Title, utils.FilmTitleBegin, utils.FilmTitleEnd, utils.TitleSizeError, c.log))
+		report(link.Source, c.validateDate(link.ReleaseDate))
+		report(link.Source, utils.ValidateStringSize(link.Name, utils.ActorNameBegin, utils.ActorNameEnd, utils.ActorNameSizeError, c.log))
+		report(link.Source, c.validateDate(link.Birthday))
+		if !slices.Contains(utils.CreditRoles, link.Role) {
+			report(link.Source, fmt.Errorf(utils.CreditRoleError))
+		}
+	}
+
+	return errs
+}
+
+func (c *Catalog) validateDate(date string) error {
+	_, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		c.log.Error(utils.DateFormatError)
+		return fmt.Errorf(utils.DateFormatError)
+	}
+
+	return nil
+}
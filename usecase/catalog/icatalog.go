@@ -0,0 +1,12 @@
+package core
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+	"io"
+)
+
+type ICatalog interface {
+	ImportCatalog(ctx context.Context, catalog *models.Catalog, dryRun bool) (*models.ImportReport, error)
+	ExportCatalog(ctx context.Context, request *models.FindFilmRequest, format string, w io.Writer) error
+}
@@ -2,24 +2,57 @@ package usecase
 
 import (
 	"filmoteka/configs"
+	"filmoteka/repository/cache"
 	"filmoteka/repository/psx"
 	"filmoteka/repository/session"
+	"filmoteka/repository/storage"
 	core_actor "filmoteka/usecase/actors"
+	core_audit "filmoteka/usecase/audit"
+	core_catalog "filmoteka/usecase/catalog"
 	core_films "filmoteka/usecase/films"
+	core_franchises "filmoteka/usecase/franchises"
+	core_genres "filmoteka/usecase/genres"
+	core_graph "filmoteka/usecase/graph"
+	core_images "filmoteka/usecase/images"
+	core_lists "filmoteka/usecase/lists"
 	core_profiles "filmoteka/usecase/profiles"
+	core_ratings "filmoteka/usecase/ratings"
+	core_recommendations "filmoteka/usecase/recommendations"
+	core_reviews "filmoteka/usecase/reviews"
+	core_revisions "filmoteka/usecase/revisions"
 	core_sessions "filmoteka/usecase/sessions"
+	core_similar "filmoteka/usecase/similar"
+	core_stats "filmoteka/usecase/stats"
+	core_tokens "filmoteka/usecase/tokens"
+	core_trash "filmoteka/usecase/trash"
+	core_versions "filmoteka/usecase/versions"
 	"github.com/sirupsen/logrus"
 )
 
 type Core struct {
-	log      *logrus.Logger
-	Films    core_films.IFilms
-	Actors   core_actor.IActors
-	Profiles core_profiles.IProfiles
-	Sessions core_sessions.ISessions
+	log             *logrus.Logger
+	Films           core_films.IFilms
+	Franchises      core_franchises.IFranchises
+	Genres          core_genres.IGenres
+	Graph           core_graph.IGraph
+	Images          core_images.IImages
+	Lists           core_lists.ILists
+	Actors          core_actor.IActors
+	Audit           core_audit.IAudit
+	Catalog         core_catalog.ICatalog
+	Profiles        core_profiles.IProfiles
+	Ratings         core_ratings.IRatings
+	Recommendations core_recommendations.IRecommendations
+	Reviews         core_reviews.IReviews
+	Revisions       core_revisions.IRevisions
+	Sessions        core_sessions.ISessions
+	Similar         core_similar.ISimilar
+	Stats           core_stats.IStats
+	Tokens          core_tokens.ITokens
+	Trash           core_trash.ITrash
 }
 
-func GetCore(psxCfg *configs.DbPsxConfig, redisCfg *configs.DbRedisCfg, log *logrus.Logger) (*Core, error) {
+func GetCore(psxCfg *configs.DbPsxConfig, redisCfg *configs.DbRedisCfg, trashCfg *configs.TrashCfg, storageCfg *configs.StorageCfg, cacheCfg *configs.CacheCfg, recommendationsCfg *configs.RecommendationsCfg, log *logrus.Logger) (*Core, error) {
 	filmRepo, err := psx.GetFilmRepo(psxCfg, log)
 	if err != nil {
 		log.Error("Get GetFilmRepo error: ", err)
@@ -32,11 +65,45 @@ func GetCore(psxCfg *configs.DbPsxConfig, redisCfg *configs.DbRedisCfg, log *log
 		return nil, err
 	}
 
+	cacheRepo, err := cache.GetCacheRepo(redisCfg, log)
+	if err != nil {
+		log.Error("Get GetCacheRepo error: ", err)
+		return nil, err
+	}
+
+	imageStorage, err := storage.GetLocalStorage(storageCfg, log)
+	if err != nil {
+		log.Error("Get GetLocalStorage error: ", err)
+		return nil, err
+	}
+
+	audit := core_audit.NewCoreAudit(filmRepo, log)
+	versions := core_versions.NewCoreVersions(filmRepo, log)
+	images := core_images.NewCoreImages(filmRepo, imageStorage, audit, versions, log)
+	revisions := core_revisions.NewCoreRevisions(filmRepo, audit, versions, log)
+	films := core_films.NewCoreFilms(filmRepo, revisions, audit, versions, log)
+	actors := core_actor.NewCoreActors(filmRepo, revisions, audit, versions, log)
+
 	return &Core{
-		log:      log,
-		Films:    core_films.NewCoreFilms(filmRepo, log),
-		Actors:   core_actor.NewCoreActors(filmRepo, log),
-		Profiles: core_profiles.NewCoreProfiles(filmRepo, authRepo, log),
-		Sessions: core_sessions.NewCoreSessions(filmRepo, authRepo, log),
+		log:             log,
+		Films:           films,
+		Franchises:      core_franchises.NewCoreFranchises(filmRepo, audit, log),
+		Genres:          core_genres.NewCoreGenres(filmRepo, audit, versions, log),
+		Graph:           core_graph.NewCoreGraph(filmRepo, log),
+		Images:          images,
+		Lists:           core_lists.NewCoreLists(filmRepo, log),
+		Actors:          actors,
+		Audit:           audit,
+		Catalog:         core_catalog.NewCoreCatalog(filmRepo, audit, versions, log),
+		Profiles:        core_profiles.NewCoreProfiles(filmRepo, authRepo, audit, log),
+		Ratings:         core_ratings.NewCoreRatings(filmRepo, log),
+		Recommendations: core_recommendations.NewCoreRecommendations(filmRepo, recommendationsCfg.Neighbours, recommendationsCfg.MinRaters, log),
+		Reviews:         core_reviews.NewCoreReviews(filmRepo, filmRepo, log),
+		Revisions:       revisions,
+		Sessions:        core_sessions.NewCoreSessions(filmRepo, authRepo, log),
+		Similar:         core_similar.NewCoreSimilar(filmRepo, cacheRepo, cacheCfg.SimilarFilmsTtl, log),
+		Stats:           core_stats.NewCoreStats(filmRepo, log),
+		Tokens:          core_tokens.NewCoreTokens(filmRepo, audit, log),
+		Trash:           core_trash.NewCoreTrash(filmRepo, films, actors, images, audit, versions, trashCfg.Retention, log),
 	}, nil
 }
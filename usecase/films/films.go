@@ -5,23 +5,34 @@ import (
 	utils "filmoteka/pkg"
 	"filmoteka/pkg/models"
 	"filmoteka/repository/psx"
+	core_audit "filmoteka/usecase/audit"
+	core_revisions "filmoteka/usecase/revisions"
+	core_versions "filmoteka/usecase/versions"
 	"fmt"
 	"github.com/sirupsen/logrus"
+	"slices"
 )
 
 type Films struct {
-	log   *logrus.Logger
-	films psx.IFilmRepo
+	log       *logrus.Logger
+	films     psx.IFilmRepo
+	revisions core_revisions.IRevisions
+	audit     core_audit.IAudit
+	versions  core_versions.IVersions
 }
 
-func NewCoreFilms(films psx.IFilmRepo, log *logrus.Logger) *Films {
+func NewCoreFilms(films psx.IFilmRepo, revisions core_revisions.IRevisions, audit core_audit.IAudit, versions core_versions.IVersions,
+	log *logrus.Logger) *Films {
 	return &Films{
-		log:   log,
-		films: films,
+		log:       log,
+		films:     films,
+		revisions: revisions,
+		audit:     audit,
+		versions:  versions,
 	}
 }
 
-func (c *Films) GetFilms(ctx context.Context, request *models.FindFilmRequest) (*[]models.FilmItem, error) {
+func (c *Films) GetFilms(ctx context.Context, request *models.FindFilmRequest) (*models.FilmsResponse, error) {
 	films, err := c.films.GetFilms(ctx, request)
 	if err != nil {
 		c.log.Errorf("get films error: %s", err.Error())
@@ -31,12 +42,57 @@ func (c *Films) GetFilms(ctx context.Context, request *models.FindFilmRequest) (
 	return films, nil
 }
 
-func (c *Films) AddFilm(ctx context.Context, film *models.FilmRequest, actors []uint64) (uint64, error) {
-	if film.Rating < utils.FilmRatingBegin || film.Rating > utils.FilmRatingEnd {
-		c.log.Error(utils.RatingSizeError)
-		return 0, fmt.Errorf(utils.RatingSizeError)
+func (c *Films) GetFilm(ctx context.Context, filmId uint64) (*models.FilmResponse, bool, error) {
+	film, found, err := c.films.GetFilm(ctx, filmId)
+	if err != nil {
+		c.log.Errorf("get film error: %s", err.Error())
+		return nil, false, fmt.Errorf("get film error: %s", err.Error())
+	}
+
+	if !found {
+		return nil, false, nil
+	}
+
+	credits, err := c.films.FindCreditsByFilm(ctx, filmId)
+	if err != nil {
+		c.log.Errorf("find film credits error: %s", err.Error())
+		return nil, false, fmt.Errorf("find film credits error: %s", err.Error())
+	}
+
+	genres, err := c.films.FindGenresByFilm(ctx, filmId)
+	if err != nil {
+		c.log.Errorf("find film genres error: %s", err.Error())
+		return nil, false, fmt.Errorf("find film genres error: %s", err.Error())
+	}
+
+	franchises, err := c.films.FindFranchisesByFilm(ctx, filmId)
+	if err != nil {
+		c.log.Errorf("find film franchises error: %s", err.Error())
+		return nil, false, fmt.Errorf("find film franchises error: %s", err.Error())
 	}
 
+	relations, err := c.films.FindRelationsByFilm(ctx, filmId)
+	if err != nil {
+		c.log.Errorf("find film relations error: %s", err.Error())
+		return nil, false, fmt.Errorf("find film relations error: %s", err.Error())
+	}
+
+	return &models.FilmResponse{
+		Id:          film.Id,
+		Title:       film.Title,
+		Info:        film.Info,
+		Rating:      film.Rating,
+		Votes:       film.Votes,
+		ReleaseDate: film.ReleaseDate,
+		Poster:      film.Poster,
+		Genres:      genres,
+		Credits:     credits,
+		Franchises:  franchises,
+		Relations:   relations,
+	}, true, nil
+}
+
+func (c *Films) AddFilm(ctx context.Context, film *models.FilmRequest, actors []uint64) (uint64, error) {
 	err := utils.ValidateStringSize(film.Title, utils.FilmTitleBegin, utils.FilmTitleEnd, utils.TitleSizeError, c.log)
 	if err != nil {
 		return 0, err
@@ -47,23 +103,29 @@ func (c *Films) AddFilm(ctx context.Context, film *models.FilmRequest, actors []
 		return 0, err
 	}
 
-	filmId, err := c.films.AddFilm(ctx, film)
+	err = c.validateCredits(film.Credits)
+	if err != nil {
+		return 0, err
+	}
+
+	filmId, err := c.films.AddFilm(ctx, film, actors)
 	if err != nil {
 		c.log.Error("add film error: ", err)
 		return 0, fmt.Errorf("add film error: %w", err)
 	}
+	c.versions.Bump(ctx, utils.CatalogVersionSimilarFilms, utils.CatalogVersionCastGraph)
 
-	err = c.films.AddActorsForFilm(ctx, filmId, actors)
+	c.revisions.RecordFilm(ctx, filmId)
+	err = c.audit.Record(ctx, utils.AuditActionAdd, utils.AuditEntityFilm, filmId, nil, c.snapshot(ctx, filmId))
 	if err != nil {
-		c.log.Error("AddActorsForFilm error: ", err.Error())
-		return 0, fmt.Errorf("AddActorsForFilm error: %w", err)
+		return 0, err
 	}
 
 	return filmId, nil
 }
 
-func (c *Films) SearchFilms(ctx context.Context, titleFilm string, nameActor string, page uint64, perPage uint64) ([]models.FilmItem, error) {
-	films, err := c.films.SearchFilms(ctx, titleFilm, nameActor, page, perPage)
+func (c *Films) SearchFilms(ctx context.Context, request *models.FindFilmRequest) (*models.FilmsResponse, error) {
+	films, err := c.films.SearchFilms(ctx, request)
 	if err != nil {
 		c.log.Errorf("SearchFilms error: %s", err.Error())
 		return nil, fmt.Errorf("SearchFilms error: %s", err.Error())
@@ -72,22 +134,88 @@ func (c *Films) SearchFilms(ctx context.Context, titleFilm string, nameActor str
 	return films, nil
 }
 
-func (c *Films) UpdateFilm(ctx context.Context, film *models.FilmRequest) error {
-	err := c.films.UpdateFilm(ctx, film)
+// UpdateFilm changes the film, its genres and credits are replaced only when
+// they are given. Found is false when there is no such film outside the trash.
+func (c *Films) UpdateFilm(ctx context.Context, film *models.FilmRequest) (bool, error) {
+	err := c.validateCredits(film.Credits)
+	if err != nil {
+		return false, err
+	}
+
+	before := c.snapshot(ctx, film.Id)
+	// films saved before the history was kept get their first revision here
+	c.revisions.RecordFilm(ctx, film.Id)
+
+	found, err := c.films.UpdateFilm(ctx, film)
 	if err != nil {
 		c.log.Errorf("change film error: %s", err.Error())
-		return fmt.Errorf("change film error: %s", err.Error())
+		return false, fmt.Errorf("change film error: %w", err)
 	}
 
-	return nil
+	if !found {
+		return false, nil
+	}
+
+	if film.Credits != nil || film.Actors != nil {
+		c.versions.Bump(ctx, utils.CatalogVersionSimilarFilms, utils.CatalogVersionCastGraph)
+	} else {
+		c.versions.Bump(ctx, utils.CatalogVersionSimilarFilms)
+	}
+
+	c.revisions.RecordFilm(ctx, film.Id)
+	err = c.audit.Record(ctx, utils.AuditActionUpdate, utils.AuditEntityFilm, film.Id, before, c.snapshot(ctx, film.Id))
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
 }
 
 func (c *Films) DeleteFilm(ctx context.Context, filmId uint64) (bool, error) {
-	_, err := c.films.DeleteFilm(ctx, filmId)
+	before := c.snapshot(ctx, filmId)
+
+	deleted, err := c.films.DeleteFilm(ctx, filmId)
 	if err != nil {
 		c.log.Errorf("delete film error: %s", err.Error())
 		return false, fmt.Errorf("delete film error: %s", err.Error())
 	}
 
-	return true, nil
+	if deleted {
+		c.versions.Bump(ctx, utils.CatalogVersionSimilarFilms, utils.CatalogVersionCastGraph)
+		err = c.audit.Record(ctx, utils.AuditActionDelete, utils.AuditEntityFilm, filmId, before, nil)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return deleted, nil
+}
+
+// snapshot returns the film with its credits and genres as it is stored in
+// the audit log, or nil when it cannot be read. Franchises and relations
+// are left out, their changes are logged on their own.
+func (c *Films) snapshot(ctx context.Context, filmId uint64) any {
+	film, found, err := c.GetFilm(ctx, filmId)
+	if err != nil || !found {
+		return nil
+	}
+
+	film.Franchises, film.Relations = nil, nil
+	return film
+}
+
+// validateCredits checks credit roles, treating an empty role as an acting credit.
+func (c *Films) validateCredits(credits []models.CreditRequest) error {
+	for i := range credits {
+		if credits[i].Role == "" {
+			credits[i].Role = utils.CreditRoleActor
+		}
+
+		if !slices.Contains(utils.CreditRoles, credits[i].Role) {
+			c.log.Error(utils.CreditRoleError)
+			return fmt.Errorf(utils.CreditRoleError)
+		}
+	}
+
+	return nil
 }
@@ -6,9 +6,10 @@ import (
 )
 
 type IFilms interface {
-	GetFilms(ctx context.Context, request *models.FindFilmRequest) (*[]models.FilmItem, error)
+	GetFilms(ctx context.Context, request *models.FindFilmRequest) (*models.FilmsResponse, error)
+	GetFilm(ctx context.Context, filmId uint64) (*models.FilmResponse, bool, error)
 	AddFilm(ctx context.Context, film *models.FilmRequest, actors []uint64) (uint64, error)
-	SearchFilms(ctx context.Context, titleFilm string, nameActor string, page uint64, perPage uint64) ([]models.FilmItem, error)
-	UpdateFilm(ctx context.Context, film *models.FilmRequest) error
+	SearchFilms(ctx context.Context, request *models.FindFilmRequest) (*models.FilmsResponse, error)
+	UpdateFilm(ctx context.Context, film *models.FilmRequest) (bool, error)
 	DeleteFilm(ctx context.Context, filmId uint64) (bool, error)
 }
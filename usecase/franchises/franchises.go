@@ -0,0 +1,201 @@
+package core
+
+import (
+	"context"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/models"
+	"filmoteka/repository/psx"
+	core_audit "filmoteka/usecase/audit"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"slices"
+)
+
+type Franchises struct {
+	log        *logrus.Logger
+	franchises psx.IFranchiseRepo
+	audit      core_audit.IAudit
+}
+
+func NewCoreFranchises(franchises psx.IFranchiseRepo, audit core_audit.IAudit, log *logrus.Logger) *Franchises {
+	return &Franchises{
+		log:        log,
+		franchises: franchises,
+		audit:      audit,
+	}
+}
+
+func (c *Franchises) AddFranchise(ctx context.Context, franchise *models.FranchiseRequest) (uint64, error) {
+	err := utils.ValidateStringSize(franchise.Name, utils.FranchiseNameBegin, utils.FranchiseNameEnd, utils.FranchiseNameSizeError, c.log)
+	if err != nil {
+		return 0, err
+	}
+
+	franchiseId, err := c.franchises.AddFranchise(ctx, franchise)
+	if err != nil {
+		c.log.Errorf("add franchise error: %s", err.Error())
+		return 0, fmt.Errorf("add franchise error: %s", err.Error())
+	}
+
+	err = c.audit.Record(ctx, utils.AuditActionAdd, utils.AuditEntityFranchise, franchiseId, nil, c.snapshot(ctx, franchiseId))
+	if err != nil {
+		return 0, err
+	}
+
+	return franchiseId, nil
+}
+
+// UpdateFranchise changes the franchise, its films are replaced only when
+// they are given. Found is false when there is no such franchise.
+func (c *Franchises) UpdateFranchise(ctx context.Context, franchise *models.FranchiseRequest) (bool, error) {
+	err := utils.ValidateStringSize(franchise.Name, utils.FranchiseNameBegin, utils.FranchiseNameEnd, utils.FranchiseNameSizeError, c.log)
+	if err != nil {
+		return false, err
+	}
+
+	before := c.snapshot(ctx, franchise.Id)
+
+	found, err := c.franchises.UpdateFranchise(ctx, franchise)
+	if err != nil {
+		c.log.Errorf("change franchise error: %s", err.Error())
+		return false, fmt.Errorf("change franchise error: %s", err.Error())
+	}
+
+	if found {
+		err = c.audit.Record(ctx, utils.AuditActionUpdate, utils.AuditEntityFranchise, franchise.Id, before, c.snapshot(ctx, franchise.Id))
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return found, nil
+}
+
+func (c *Franchises) DeleteFranchise(ctx context.Context, franchiseId uint64) (bool, error) {
+	before := c.snapshot(ctx, franchiseId)
+
+	deleted, err := c.franchises.DeleteFranchise(ctx, franchiseId)
+	if err != nil {
+		c.log.Errorf("delete franchise error: %s", err.Error())
+		return false, fmt.Errorf("delete franchise error: %s", err.Error())
+	}
+
+	if deleted {
+		err = c.audit.Record(ctx, utils.AuditActionDelete, utils.AuditEntityFranchise, franchiseId, before, nil)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return deleted, nil
+}
+
+func (c *Franchises) FindFranchiseByName(ctx context.Context, name string) (bool, error) {
+	found, err := c.franchises.FindFranchise(ctx, name)
+	if err != nil {
+		c.log.Errorf("find franchise by name error: %s", err.Error())
+		return false, fmt.Errorf("find franchise by name error: %s", err.Error())
+	}
+
+	return found, nil
+}
+
+func (c *Franchises) FindFranchises(ctx context.Context, page uint64, perPage uint64) (*models.FranchisesResponse, error) {
+	franchises, err := c.franchises.FindFranchises(ctx, page, perPage)
+	if err != nil {
+		c.log.Errorf("find franchises error: %s", err.Error())
+		return nil, fmt.Errorf("find franchises error: %s", err.Error())
+	}
+
+	return franchises, nil
+}
+
+// GetFranchise returns the franchise with its films in order, found is false
+// when there is no such franchise.
+func (c *Franchises) GetFranchise(ctx context.Context, franchiseId uint64, order string) (*models.FranchiseResponse, bool, error) {
+	if !slices.Contains(utils.FranchiseOrders, order) {
+		c.log.Error(utils.FranchiseOrderError)
+		return nil, false, fmt.Errorf(utils.FranchiseOrderError)
+	}
+
+	franchise, found, err := c.franchises.GetFranchise(ctx, franchiseId, order)
+	if err != nil {
+		c.log.Errorf("get franchise error: %s", err.Error())
+		return nil, false, fmt.Errorf("get franchise error: %s", err.Error())
+	}
+
+	return franchise, found, nil
+}
+
+// AddFilmRelation records that the related film is a sequel, prequel, remake
+// or spin-off of the film. Found is false when either film does not exist.
+func (c *Franchises) AddFilmRelation(ctx context.Context, relation *models.FilmRelationRequest) (bool, error) {
+	err := c.validateRelation(relation)
+	if err != nil {
+		return false, err
+	}
+
+	found, err := c.franchises.AddFilmRelation(ctx, relation)
+	if err != nil {
+		c.log.Errorf("add film relation error: %s", err.Error())
+		return false, fmt.Errorf("add film relation error: %s", err.Error())
+	}
+
+	if found {
+		err = c.audit.Record(ctx, utils.AuditActionAdd, utils.AuditEntityRelation, relation.FilmId, nil, relation)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return found, nil
+}
+
+// DeleteFilmRelation removes the relation, deleted is false when there is
+// no such relation.
+func (c *Franchises) DeleteFilmRelation(ctx context.Context, relation *models.FilmRelationRequest) (bool, error) {
+	err := c.validateRelation(relation)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := c.franchises.DeleteFilmRelation(ctx, relation)
+	if err != nil {
+		c.log.Errorf("delete film relation error: %s", err.Error())
+		return false, fmt.Errorf("delete film relation error: %s", err.Error())
+	}
+
+	if deleted {
+		err = c.audit.Record(ctx, utils.AuditActionDelete, utils.AuditEntityRelation, relation.FilmId, relation, nil)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return deleted, nil
+}
+
+func (c *Franchises) validateRelation(relation *models.FilmRelationRequest) error {
+	if !slices.Contains(utils.FilmRelationKinds, relation.Kind) {
+		c.log.Error(utils.FilmRelationKindError)
+		return fmt.Errorf(utils.FilmRelationKindError)
+	}
+
+	if relation.FilmId == relation.RelatedId {
+		c.log.Error(utils.FilmRelationSelfError)
+		return fmt.Errorf(utils.FilmRelationSelfError)
+	}
+
+	return nil
+}
+
+// snapshot returns the franchise with its films as it is stored in the
+// audit log, or nil when it cannot be read.
+func (c *Franchises) snapshot(ctx context.Context, franchiseId uint64) any {
+	franchise, found, err := c.franchises.GetFranchise(ctx, franchiseId, utils.FranchiseOrderChronology)
+	if err != nil || !found {
+		return nil
+	}
+
+	return franchise
+}
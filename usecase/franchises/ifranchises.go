@@ -0,0 +1,17 @@
+package core
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type IFranchises interface {
+	AddFranchise(ctx context.Context, franchise *models.FranchiseRequest) (uint64, error)
+	UpdateFranchise(ctx context.Context, franchise *models.FranchiseRequest) (bool, error)
+	DeleteFranchise(ctx context.Context, franchiseId uint64) (bool, error)
+	FindFranchiseByName(ctx context.Context, name string) (bool, error)
+	FindFranchises(ctx context.Context, page uint64, perPage uint64) (*models.FranchisesResponse, error)
+	GetFranchise(ctx context.Context, franchiseId uint64, order string) (*models.FranchiseResponse, bool, error)
+	AddFilmRelation(ctx context.Context, relation *models.FilmRelationRequest) (bool, error)
+	DeleteFilmRelation(ctx context.Context, relation *models.FilmRelationRequest) (bool, error)
+}
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"context"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/models"
+	"filmoteka/repository/psx"
+	core_audit "filmoteka/usecase/audit"
+	core_versions "filmoteka/usecase/versions"
+	"fmt"
+	"github.com/sirupsen/logrus"
+)
+
+type Genres struct {
+	log      *logrus.Logger
+	genres   psx.IGenreRepo
+	audit    core_audit.IAudit
+	versions core_versions.IVersions
+}
+
+func NewCoreGenres(genres psx.IGenreRepo, audit core_audit.IAudit, versions core_versions.IVersions, log *logrus.Logger) *Genres {
+	return &Genres{
+		log:      log,
+		genres:   genres,
+		audit:    audit,
+		versions: versions,
+	}
+}
+
+func (c *Genres) AddGenre(ctx context.Context, genre *models.GenreItem) (uint64, error) {
+	err := utils.ValidateStringSize(genre.Name, utils.GenreNameBegin, utils.GenreNameEnd, utils.GenreNameSizeError, c.log)
+	if err != nil {
+		return 0, err
+	}
+
+	genreId, err := c.genres.AddGenre(ctx, genre)
+	if err != nil {
+		c.log.Errorf("add genre error: %s", err.Error())
+		return 0, fmt.Errorf("add genre error: %w", err)
+	}
+
+	err = c.audit.Record(ctx, utils.AuditActionAdd, utils.AuditEntityGenre, genreId, nil, genre)
+	if err != nil {
+		return 0, err
+	}
+
+	return genreId, nil
+}
+
+func (c *Genres) FindGenres(ctx context.Context) ([]models.GenreItem, error) {
+	genres, err := c.genres.FindGenres(ctx)
+	if err != nil {
+		c.log.Errorf("find genres error: %s", err.Error())
+		return nil, fmt.Errorf("find genres error: %s", err.Error())
+	}
+
+	return genres, nil
+}
+
+func (c *Genres) FindGenreByName(ctx context.Context, name string) (bool, error) {
+	found, err := c.genres.FindGenre(ctx, name)
+	if err != nil {
+		c.log.Errorf("find genre by name error: %s", err.Error())
+		return false, fmt.Errorf("find genre by name error: %s", err.Error())
+	}
+
+	return found, nil
+}
+
+// UpdateGenre renames the genre, found is false when there is no such genre.
+// Renaming it to the name of another genre fails with utils.ErrGenreExists.
+func (c *Genres) UpdateGenre(ctx context.Context, genre *models.GenreItem) (bool, error) {
+	err := utils.ValidateStringSize(genre.Name, utils.GenreNameBegin, utils.GenreNameEnd, utils.GenreNameSizeError, c.log)
+	if err != nil {
+		return false, err
+	}
+
+	before := c.snapshot(ctx, genre.Id)
+
+	found, err := c.genres.UpdateGenre(ctx, genre)
+	if err != nil {
+		c.log.Errorf("change genre error: %s", err.Error())
+		return false, fmt.Errorf("change genre error: %w", err)
+	}
+
+	if found {
+		err = c.audit.Record(ctx, utils.AuditActionUpdate, utils.AuditEntityGenre, genre.Id, before, genre)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return found, nil
+}
+
+// DeleteGenre removes the genre, deleted is false when there is no such genre.
+func (c *Genres) DeleteGenre(ctx context.Context, genreId uint64) (bool, error) {
+	before := c.snapshot(ctx, genreId)
+
+	deleted, err := c.genres.DeleteGenre(ctx, genreId)
+	if err != nil {
+		c.log.Errorf("delete genre error: %s", err.Error())
+		return false, fmt.Errorf("delete genre error: %s", err.Error())
+	}
+
+	if deleted {
+		// the genre is taken off its films along with it
+		c.versions.Bump(ctx, utils.CatalogVersionSimilarFilms)
+
+		err = c.audit.Record(ctx, utils.AuditActionDelete, utils.AuditEntityGenre, genreId, before, nil)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return deleted, nil
+}
+
+// snapshot returns the genre as it is stored in the audit log, or nil when it
+// cannot be read.
+func (c *Genres) snapshot(ctx context.Context, genreId uint64) any {
+	genre, found, err := c.genres.GetGenre(ctx, genreId)
+	if err != nil || !found {
+		return nil
+	}
+
+	return genre
+}
@@ -0,0 +1,14 @@
+package core
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type IGenres interface {
+	AddGenre(ctx context.Context, genre *models.GenreItem) (uint64, error)
+	FindGenres(ctx context.Context) ([]models.GenreItem, error)
+	FindGenreByName(ctx context.Context, name string) (bool, error)
+	UpdateGenre(ctx context.Context, genre *models.GenreItem) (bool, error)
+	DeleteGenre(ctx context.Context, genreId uint64) (bool, error)
+}
@@ -0,0 +1,208 @@
+package core
+
+import (
+	"context"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/castgraph"
+	"filmoteka/pkg/models"
+	"filmoteka/repository/psx"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"sync"
+)
+
+type Graph struct {
+	log   *logrus.Logger
+	graph psx.IGraphRepo
+
+	mu       sync.Mutex
+	cached   *castgraph.Graph
+	version  uint64
+	building *graphBuild
+}
+
+// graphBuild is a build of the graph shared by the requests waiting for it,
+// done is closed once it is over.
+type graphBuild struct {
+	done chan struct{}
+	err  error
+}
+
+func NewCoreGraph(graph psx.IGraphRepo, log *logrus.Logger) *Graph {
+	return &Graph{
+		log:   log,
+		graph: graph,
+	}
+}
+
+// current returns the collaboration graph, which is kept in memory and built
+// again once the cast_graph catalog version moves on. Requests that find it
+// stale wait for one build, which runs without the lock held and outlives
+// them, and a build only replaces a graph of an older version, so a slow
+// build never brings back an outdated graph.
+func (c *Graph) current(ctx context.Context) (*castgraph.Graph, error) {
+	version, err := c.graph.GetCatalogVersion(ctx, utils.CatalogVersionCastGraph)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		c.mu.Lock()
+		if c.cached != nil && c.version >= version {
+			graph := c.cached
+			c.mu.Unlock()
+			return graph, nil
+		}
+
+		// a build already running may have read an older version, then the
+		// loop waits for it and starts the next one
+		build := c.building
+		if build == nil {
+			build = &graphBuild{done: make(chan struct{})}
+			c.building = build
+			go c.build(context.WithoutCancel(ctx), build)
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-build.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		if build.err != nil {
+			return nil, build.err
+		}
+	}
+}
+
+// build reads the links and builds the graph from them. The version is read
+// before the links, so a graph is never taken for newer than it is.
+func (c *Graph) build(ctx context.Context, build *graphBuild) {
+	var graph *castgraph.Graph
+
+	version, err := c.graph.GetCatalogVersion(ctx, utils.CatalogVersionCastGraph)
+	if err == nil {
+		var links []models.CastLink
+
+		links, err = c.graph.FindCastLinks(ctx)
+		if err == nil {
+			graph = castgraph.New(links)
+			c.log.Infof("collaboration graph built: %d links, version %d", len(links), version)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil && (c.cached == nil || version > c.version) {
+		c.cached, c.version = graph, version
+	}
+	c.building = nil
+
+	build.err = err
+	close(build.done)
+}
+
+// FindActorPath returns a shortest chain of co-stars between the actors,
+// found is false when either of them does not exist.
+func (c *Graph) FindActorPath(ctx context.Context, fromId uint64, toId uint64) (*models.ActorPathResponse, bool, error) {
+	names, err := c.graph.FindActorNames(ctx, []uint64{fromId, toId})
+	if err != nil {
+		c.log.Errorf("find actor path error: %s", err.Error())
+		return nil, false, fmt.Errorf("find actor path error: %s", err.Error())
+	}
+
+	_, fromFound := names[fromId]
+	_, toFound := names[toId]
+	if !fromFound || !toFound {
+		return nil, false, nil
+	}
+
+	graph, err := c.current(ctx)
+	if err != nil {
+		c.log.Errorf("get collaboration graph error: %s", err.Error())
+		return nil, false, fmt.Errorf("get collaboration graph error: %s", err.Error())
+	}
+
+	response := &models.ActorPathResponse{Path: make([]models.ActorPathStep, 0)}
+
+	links, connected := graph.Path(fromId, toId)
+	if !connected {
+		return response, true, nil
+	}
+
+	actorIds := make([]uint64, 0, len(links))
+	filmIds := make([]uint64, 0, len(links))
+	for _, link := range links {
+		actorIds = append(actorIds, link.ActorId)
+		if link.FilmId != 0 {
+			filmIds = append(filmIds, link.FilmId)
+		}
+	}
+
+	names, err = c.graph.FindActorNames(ctx, actorIds)
+	if err != nil {
+		c.log.Errorf("find actor path error: %s", err.Error())
+		return nil, false, fmt.Errorf("find actor path error: %s", err.Error())
+	}
+
+	titles, err := c.graph.FindFilmTitles(ctx, filmIds)
+	if err != nil {
+		c.log.Errorf("find actor path error: %s", err.Error())
+		return nil, false, fmt.Errorf("find actor path error: %s", err.Error())
+	}
+
+	for _, link := range links {
+		step := models.ActorPathStep{Actor: models.GraphActor{Id: link.ActorId, Name: names[link.ActorId]}}
+		if link.FilmId != 0 {
+			step.Film = &models.GraphFilm{Id: link.FilmId, Title: titles[link.FilmId]}
+		}
+		response.Path = append(response.Path, step)
+	}
+
+	response.Connected, response.Degrees = true, len(filmIds)
+
+	return response, true, nil
+}
+
+// FindCollaborators returns up to limit actors who played with the actor,
+// those sharing the most films first. Found is false when there is no such
+// actor.
+func (c *Graph) FindCollaborators(ctx context.Context, actorId uint64, limit uint64) ([]models.CollaboratorItem, bool, error) {
+	names, err := c.graph.FindActorNames(ctx, []uint64{actorId})
+	if err != nil {
+		c.log.Errorf("find collaborators error: %s", err.Error())
+		return nil, false, fmt.Errorf("find collaborators error: %s", err.Error())
+	}
+
+	if len(names) == 0 {
+		return nil, false, nil
+	}
+
+	graph, err := c.current(ctx)
+	if err != nil {
+		c.log.Errorf("get collaboration graph error: %s", err.Error())
+		return nil, false, fmt.Errorf("get collaboration graph error: %s", err.Error())
+	}
+
+	collaborators := graph.Collaborators(actorId, int(limit))
+
+	actorIds := make([]uint64, 0, len(collaborators))
+	for _, collaborator := range collaborators {
+		actorIds = append(actorIds, collaborator.ActorId)
+	}
+
+	names, err = c.graph.FindActorNames(ctx, actorIds)
+	if err != nil {
+		c.log.Errorf("find collaborators error: %s", err.Error())
+		return nil, false, fmt.Errorf("find collaborators error: %s", err.Error())
+	}
+
+	items := make([]models.CollaboratorItem, 0, len(collaborators))
+	for _, collaborator := range collaborators {
+		items = append(items, models.CollaboratorItem{Id: collaborator.ActorId, Name: names[collaborator.ActorId], Films: collaborator.Films})
+	}
+
+	return items, true, nil
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+	"filmoteka/repository/psx"
+	"github.com/sirupsen/logrus"
+	"io"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeGraphRepo serves the links of a single film cast by actors 1 and 2.
+// FindCastLinks waits on release when it is set.
+type fakeGraphRepo struct {
+	psx.IGraphRepo
+	version atomic.Uint64
+	builds  atomic.Int64
+	release chan struct{}
+}
+
+func (f *fakeGraphRepo) GetCatalogVersion(ctx context.Context, name string) (uint64, error) {
+	return f.version.Load(), nil
+}
+
+func (f *fakeGraphRepo) FindCastLinks(ctx context.Context) ([]models.CastLink, error) {
+	f.builds.Add(1)
+	if f.release != nil {
+		<-f.release
+	}
+
+	return []models.CastLink{{ActorId: 1, FilmId: 1}, {ActorId: 2, FilmId: 1}}, nil
+}
+
+func testGraph(repo *fakeGraphRepo) *Graph {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	return NewCoreGraph(repo, log)
+}
+
+func TestCurrentSharesBuild(t *testing.T) {
+	repo := &fakeGraphRepo{release: make(chan struct{})}
+	c := testGraph(repo)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.current(context.Background())
+			if err != nil {
+				t.Errorf("current error: %s", err.Error())
+			}
+		}()
+	}
+
+	for repo.builds.Load() == 0 {
+		runtime.Gosched()
+	}
+	close(repo.release)
+	wg.Wait()
+
+	if builds := repo.builds.Load(); builds != 1 {
+		t.Errorf("builds = %d, want 1", builds)
+	}
+}
+
+func TestCurrentRebuildsOnNewVersion(t *testing.T) {
+	repo := &fakeGraphRepo{}
+	c := testGraph(repo)
+
+	first, err := c.current(context.Background())
+	if err != nil {
+		t.Fatalf("current error: %s", err.Error())
+	}
+
+	same, _ := c.current(context.Background())
+	if same != first || repo.builds.Load() != 1 {
+		t.Errorf("graph built again without a new version")
+	}
+
+	repo.version.Store(1)
+	next, _ := c.current(context.Background())
+	if next == first || repo.builds.Load() != 2 {
+		t.Errorf("graph not built again for a new version")
+	}
+}
+
+func TestBuildKeepsNewerGraph(t *testing.T) {
+	repo := &fakeGraphRepo{}
+	c := testGraph(repo)
+
+	repo.version.Store(2)
+	newer, err := c.current(context.Background())
+	if err != nil {
+		t.Fatalf("current error: %s", err.Error())
+	}
+
+	// a build that read an older version finishes last
+	repo.version.Store(1)
+	build := &graphBuild{done: make(chan struct{})}
+	c.build(context.Background(), build)
+
+	if c.cached != newer || c.version != 2 {
+		t.Errorf("graph of version %d replaced by version %d", 2, c.version)
+	}
+}
+
+func TestCurrentCancelled(t *testing.T) {
+	repo := &fakeGraphRepo{release: make(chan struct{})}
+	defer close(repo.release)
+	c := testGraph(repo)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.current(ctx)
+	if err == nil {
+		t.Errorf("current of a cancelled request returned no error")
+	}
+}
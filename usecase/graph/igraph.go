@@ -0,0 +1,11 @@
+package core
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type IGraph interface {
+	FindActorPath(ctx context.Context, fromId uint64, toId uint64) (*models.ActorPathResponse, bool, error)
+	FindCollaborators(ctx context.Context, actorId uint64, limit uint64) ([]models.CollaboratorItem, bool, error)
+}
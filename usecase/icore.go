@@ -2,14 +2,44 @@ package usecase
 
 import (
 	core_actor "filmoteka/usecase/actors"
+	core_audit "filmoteka/usecase/audit"
+	core_catalog "filmoteka/usecase/catalog"
 	core_films "filmoteka/usecase/films"
+	core_franchises "filmoteka/usecase/franchises"
+	core_genres "filmoteka/usecase/genres"
+	core_graph "filmoteka/usecase/graph"
+	core_images "filmoteka/usecase/images"
+	core_lists "filmoteka/usecase/lists"
 	core_profiles "filmoteka/usecase/profiles"
+	core_ratings "filmoteka/usecase/ratings"
+	core_recommendations "filmoteka/usecase/recommendations"
+	core_reviews "filmoteka/usecase/reviews"
+	core_revisions "filmoteka/usecase/revisions"
 	core_sessions "filmoteka/usecase/sessions"
+	core_similar "filmoteka/usecase/similar"
+	core_stats "filmoteka/usecase/stats"
+	core_tokens "filmoteka/usecase/tokens"
+	core_trash "filmoteka/usecase/trash"
 )
 
 type ICore interface {
 	core_films.IFilms
+	core_franchises.IFranchises
+	core_genres.IGenres
+	core_graph.IGraph
+	core_images.IImages
+	core_lists.ILists
 	core_actor.IActors
+	core_audit.IAudit
+	core_catalog.ICatalog
 	core_profiles.IProfiles
+	core_ratings.IRatings
+	core_recommendations.IRecommendations
+	core_reviews.IReviews
+	core_revisions.IRevisions
 	core_sessions.ISessions
+	core_similar.ISimilar
+	core_stats.IStats
+	core_tokens.ITokens
+	core_trash.ITrash
 }
@@ -0,0 +1,14 @@
+package core
+
+import (
+	"context"
+	"filmoteka/pkg/images"
+	"filmoteka/pkg/models"
+)
+
+type IImages interface {
+	UploadFilmPoster(ctx context.Context, filmId uint64, upload *images.Upload) (*models.ImageUrls, bool, error)
+	UploadActorPhoto(ctx context.Context, actorId uint64, upload *images.Upload) (*models.ImageUrls, bool, error)
+	OpenImage(ctx context.Context, key string) (*models.StoredFile, bool, error)
+	RemoveImages(ctx context.Context, dir string, names []string)
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"context"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/images"
+	"filmoteka/pkg/models"
+	"filmoteka/repository/psx"
+	"filmoteka/repository/storage"
+	core_audit "filmoteka/usecase/audit"
+	core_versions "filmoteka/usecase/versions"
+	"fmt"
+	"github.com/sirupsen/logrus"
+)
+
+type Images struct {
+	log      *logrus.Logger
+	images   psx.IImageRepo
+	storage  storage.IStorage
+	audit    core_audit.IAudit
+	versions core_versions.IVersions
+}
+
+func NewCoreImages(images psx.IImageRepo, storage storage.IStorage, audit core_audit.IAudit, versions core_versions.IVersions,
+	log *logrus.Logger) *Images {
+	return &Images{
+		log:      log,
+		images:   images,
+		storage:  storage,
+		audit:    audit,
+		versions: versions,
+	}
+}
+
+// UploadFilmPoster stores upload with its thumbnails as the poster of the
+// film, replacing the previous one. Found is false when there is no such film.
+func (c *Images) UploadFilmPoster(ctx context.Context, filmId uint64, upload *images.Upload) (*models.ImageUrls, bool, error) {
+	previous, name, found, err := c.upload(ctx, images.DirFilms, filmId, upload, c.images.SetFilmPoster)
+	if err != nil {
+		c.log.Errorf("upload film poster error: %s", err.Error())
+		return nil, false, fmt.Errorf("upload film poster error: %s", err.Error())
+	}
+
+	if !found {
+		return nil, false, nil
+	}
+
+	c.versions.Bump(ctx, utils.CatalogVersionSimilarFilms)
+	urls := images.Urls(images.DirFilms, name)
+	err = c.audit.Record(ctx, utils.AuditActionUpdate, utils.AuditEntityFilm, filmId, imageState("poster", images.Urls(images.DirFilms, previous)), imageState("poster", urls))
+	if err != nil {
+		return nil, false, err
+	}
+
+	return urls, true, nil
+}
+
+// UploadActorPhoto stores upload as the photo of the actor, see UploadFilmPoster.
+func (c *Images) UploadActorPhoto(ctx context.Context, actorId uint64, upload *images.Upload) (*models.ImageUrls, bool, error) {
+	previous, name, found, err := c.upload(ctx, images.DirActors, actorId, upload, c.images.SetActorPhoto)
+	if err != nil {
+		c.log.Errorf("upload actor photo error: %s", err.Error())
+		return nil, false, fmt.Errorf("upload actor photo error: %s", err.Error())
+	}
+
+	if !found {
+		return nil, false, nil
+	}
+
+	urls := images.Urls(images.DirActors, name)
+	err = c.audit.Record(ctx, utils.AuditActionUpdate, utils.AuditEntityActor, actorId, imageState("photo", images.Urls(images.DirActors, previous)), imageState("photo", urls))
+	if err != nil {
+		return nil, false, err
+	}
+
+	return urls, true, nil
+}
+
+// upload stores the files of upload under a new name in dir and then links
+// the name to the record with set, so that the record never points to files
+// that are not there yet. It returns the name of the replaced image, empty
+// when there was none, and the new name.
+func (c *Images) upload(ctx context.Context, dir string, id uint64, upload *images.Upload,
+	set func(ctx context.Context, id uint64, name string) (string, bool, error)) (string, string, bool, error) {
+	name, err := images.NewName(upload.Ext)
+	if err != nil {
+		return "", "", false, err
+	}
+
+	files, err := images.Files(dir, name, upload)
+	if err != nil {
+		return "", "", false, err
+	}
+
+	for key, data := range files {
+		err = c.storage.Put(ctx, key, data)
+		if err != nil {
+			c.RemoveImages(ctx, dir, []string{name})
+			return "", "", false, err
+		}
+	}
+
+	previous, found, err := set(ctx, id, name)
+	if err != nil || !found {
+		c.RemoveImages(ctx, dir, []string{name})
+		return "", "", false, err
+	}
+
+	if previous != "" {
+		c.RemoveImages(ctx, dir, []string{previous})
+	}
+
+	return previous, name, true, nil
+}
+
+// OpenImage returns the stored image or thumbnail with the given key, found
+// is false for unknown keys.
+func (c *Images) OpenImage(ctx context.Context, key string) (*models.StoredFile, bool, error) {
+	if !images.ValidKey(key) {
+		return nil, false, nil
+	}
+
+	file, found, err := c.storage.Open(ctx, key)
+	if err != nil {
+		c.log.Errorf("open image error: %s", err.Error())
+		return nil, false, fmt.Errorf("open image error: %s", err.Error())
+	}
+
+	return file, found, nil
+}
+
+// RemoveImages deletes the files of the images with the given names in dir.
+// The images are no longer referenced at this point, so failures are logged
+// rather than returned.
+func (c *Images) RemoveImages(ctx context.Context, dir string, names []string) {
+	ctx = context.WithoutCancel(ctx)
+
+	for _, name := range names {
+		for _, key := range images.Keys(dir, name) {
+			err := c.storage.Delete(ctx, key)
+			if err != nil {
+				c.log.Errorf("remove image error: %s", err.Error())
+			}
+		}
+	}
+}
+
+// imageState is the image field of a record as it is stored in the audit log.
+func imageState(field string, urls *models.ImageUrls) any {
+	if urls == nil {
+		return nil
+	}
+
+	return map[string]*models.ImageUrls{field: urls}
+}
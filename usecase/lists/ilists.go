@@ -0,0 +1,13 @@
+package core
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type ILists interface {
+	AddToList(ctx context.Context, userId uint64, filmId uint64, list string) (bool, error)
+	DeleteFromList(ctx context.Context, userId uint64, filmId uint64, list string) (bool, error)
+	FindList(ctx context.Context, userId uint64, list string, page uint64, perPage uint64) (*models.FilmsResponse, error)
+	MarkFilms(ctx context.Context, userId uint64, films []models.FilmItem) error
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"context"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/models"
+	"filmoteka/repository/psx"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"slices"
+)
+
+type Lists struct {
+	log   *logrus.Logger
+	lists psx.IFilmListRepo
+}
+
+func NewCoreLists(lists psx.IFilmListRepo, log *logrus.Logger) *Lists {
+	return &Lists{
+		log:   log,
+		lists: lists,
+	}
+}
+
+func (c *Lists) AddToList(ctx context.Context, userId uint64, filmId uint64, list string) (bool, error) {
+	err := c.validateList(list)
+	if err != nil {
+		return false, err
+	}
+
+	found, err := c.lists.AddToList(ctx, userId, filmId, list)
+	if err != nil {
+		c.log.Errorf("add film to list error: %s", err.Error())
+		return false, fmt.Errorf("add film to list error: %s", err.Error())
+	}
+
+	return found, nil
+}
+
+func (c *Lists) DeleteFromList(ctx context.Context, userId uint64, filmId uint64, list string) (bool, error) {
+	err := c.validateList(list)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := c.lists.DeleteFromList(ctx, userId, filmId, list)
+	if err != nil {
+		c.log.Errorf("delete film from list error: %s", err.Error())
+		return false, fmt.Errorf("delete film from list error: %s", err.Error())
+	}
+
+	return deleted, nil
+}
+
+func (c *Lists) FindList(ctx context.Context, userId uint64, list string, page uint64, perPage uint64) (*models.FilmsResponse, error) {
+	err := c.validateList(list)
+	if err != nil {
+		return nil, err
+	}
+
+	films, err := c.lists.FindList(ctx, userId, list, page, perPage)
+	if err != nil {
+		c.log.Errorf("find list error: %s", err.Error())
+		return nil, fmt.Errorf("find list error: %s", err.Error())
+	}
+
+	err = c.MarkFilms(ctx, userId, *films.Films)
+	if err != nil {
+		return nil, err
+	}
+
+	return films, nil
+}
+
+// MarkFilms sets the favorite and watchlist flags of films for userId.
+func (c *Lists) MarkFilms(ctx context.Context, userId uint64, films []models.FilmItem) error {
+	filmIds := make([]uint64, 0, len(films))
+	for _, film := range films {
+		filmIds = append(filmIds, film.Id)
+	}
+
+	lists, err := c.lists.FindListsByFilms(ctx, userId, filmIds)
+	if err != nil {
+		c.log.Errorf("mark films error: %s", err.Error())
+		return fmt.Errorf("mark films error: %s", err.Error())
+	}
+
+	for i := range films {
+		inFavorites := slices.Contains(lists[films[i].Id], utils.FilmListFavorite)
+		inWatchlist := slices.Contains(lists[films[i].Id], utils.FilmListWatchlist)
+		films[i].InFavorites = &inFavorites
+		films[i].InWatchlist = &inWatchlist
+	}
+
+	return nil
+}
+
+func (c *Lists) validateList(list string) error {
+	if !slices.Contains(utils.FilmLists, list) {
+		c.log.Error(utils.FilmListError)
+		return fmt.Errorf(utils.FilmListError)
+	}
+
+	return nil
+}
@@ -10,4 +10,5 @@ type IProfiles interface {
 	FindUserAccount(ctx context.Context, login string, password string) (*models.UserItem, bool, error)
 	FindUserByLogin(ctx context.Context, login string) (bool, error)
 	GetRole(ctx context.Context, userId uint64) (string, error)
+	UpdateRole(ctx context.Context, userId uint64, role string) (bool, error)
 }
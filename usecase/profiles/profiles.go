@@ -2,10 +2,13 @@ package core
 
 import (
 	"context"
+	"errors"
 	utils "filmoteka/pkg"
 	"filmoteka/pkg/models"
+	"filmoteka/pkg/passwords"
 	"filmoteka/repository/psx"
 	"filmoteka/repository/session"
+	core_audit "filmoteka/usecase/audit"
 	"fmt"
 	"github.com/sirupsen/logrus"
 )
@@ -14,35 +17,95 @@ type Profiles struct {
 	log      *logrus.Logger
 	profiles psx.IProfileRepo
 	sessions session.ISessionRepo
+	audit    core_audit.IAudit
 }
 
-func NewCoreProfiles(profiles psx.IProfileRepo, sessions session.ISessionRepo, log *logrus.Logger) *Profiles {
+func NewCoreProfiles(profiles psx.IProfileRepo, sessions session.ISessionRepo, audit core_audit.IAudit, log *logrus.Logger) *Profiles {
 	return &Profiles{
 		log:      log,
 		profiles: profiles,
 		sessions: sessions,
+		audit:    audit,
 	}
 }
 
 func (c *Profiles) CreateUserAccount(ctx context.Context, login string, password string) error {
-	hashPassword := utils.HashPassword(password)
-	err := c.profiles.CreateUser(ctx, login, hashPassword)
+	hash, err := passwords.Hash(password)
 	if err != nil {
 		c.log.Errorf("create user account error: %s", err.Error())
 		return fmt.Errorf("create user account error: %s", err.Error())
 	}
 
-	return nil
+	err = c.profiles.CreateUser(ctx, login, hash, passwords.AlgoArgon2id)
+	if err != nil {
+		c.log.Errorf("create user account error: %s", err.Error())
+		return fmt.Errorf("create user account error: %s", err.Error())
+	}
+
+	return c.recordSignup(ctx, login)
+}
+
+// recordSignup logs the new account in the audit log as added by itself,
+// since nobody is signed in yet when it is created.
+func (c *Profiles) recordSignup(ctx context.Context, login string) error {
+	userId, err := c.profiles.GetUserId(ctx, login)
+	if err != nil {
+		c.log.Errorf("audit signup of %s error: %s", login, err.Error())
+		return fmt.Errorf("audit signup of %s error: %s", login, err.Error())
+	}
+
+	role, err := c.profiles.GetRole(ctx, userId)
+	if err != nil {
+		c.log.Errorf("audit signup of %s error: %s", login, err.Error())
+		return fmt.Errorf("audit signup of %s error: %s", login, err.Error())
+	}
+
+	ctx = context.WithValue(ctx, utils.UserIDKey, userId)
+	return c.audit.Record(ctx, utils.AuditActionAdd, utils.AuditEntityProfile, userId, nil,
+		&models.ProfileItem{Id: userId, Login: login, Role: role})
 }
 
 func (c *Profiles) FindUserAccount(ctx context.Context, login string, password string) (*models.UserItem, bool, error) {
-	hashPassword := utils.HashPassword(password)
-	user, found, err := c.profiles.GetUser(ctx, login, hashPassword)
+	credentials, found, err := c.profiles.GetUserCredentials(ctx, login)
 	if err != nil {
 		c.log.Errorf("find user error: %s", err.Error())
 		return nil, false, fmt.Errorf("find user account error: %s", err.Error())
 	}
-	return user, found, nil
+	if !found {
+		passwords.Waste(password)
+		return nil, false, nil
+	}
+
+	match, rehash, err := passwords.Verify(password, credentials.Hash, credentials.Algo)
+	if err != nil {
+		c.log.Errorf("find user error: %s", err.Error())
+		return nil, false, fmt.Errorf("find user account error: %s", err.Error())
+	}
+	if !match {
+		return nil, false, nil
+	}
+
+	if rehash {
+		c.upgradePassword(ctx, credentials, password)
+	}
+
+	return &credentials.User, true, nil
+}
+
+// upgradePassword replaces a legacy or outdated hash with a new one once the
+// password is known to be right. A failure keeps the old hash, which still
+// works, so it is only logged.
+func (c *Profiles) upgradePassword(ctx context.Context, credentials *models.UserCredentials, password string) {
+	hash, err := passwords.Hash(password)
+	if err != nil {
+		c.log.Errorf("upgrade password of user %d error: %s", credentials.User.Id, err.Error())
+		return
+	}
+
+	err = c.profiles.UpdatePassword(ctx, credentials.User.Id, hash, passwords.AlgoArgon2id, credentials.Hash)
+	if err != nil {
+		c.log.Errorf("upgrade password of user %d error: %s", credentials.User.Id, err.Error())
+	}
 }
 
 func (c *Profiles) FindUserByLogin(ctx context.Context, login string) (bool, error) {
@@ -64,3 +127,38 @@ func (c *Profiles) GetRole(ctx context.Context, userId uint64) (string, error) {
 
 	return role, nil
 }
+
+// UpdateRole changes the role of the user and voids their sessions, the role
+// is kept in the session so a new one is needed to carry it. Sessions are
+// voided even when the role is unchanged, so a retry after a failed attempt
+// still takes effect. Demoting the last admin fails with utils.ErrLastAdmin.
+func (c *Profiles) UpdateRole(ctx context.Context, userId uint64, role string) (bool, error) {
+	profile, previous, found, err := c.profiles.UpdateRole(ctx, userId, role)
+	if errors.Is(err, utils.ErrLastAdmin) {
+		return true, err
+	}
+	if err != nil {
+		c.log.Errorf("update role error: %s", err.Error())
+		return false, fmt.Errorf("update role error: %s", err.Error())
+	}
+	if !found {
+		return false, nil
+	}
+
+	err = c.sessions.DeleteUserSessions(ctx, userId, c.log)
+	if err != nil {
+		c.log.Errorf("update role error: %s", err.Error())
+		return true, fmt.Errorf("update role error: %s", err.Error())
+	}
+
+	if previous != role {
+		before := *profile
+		before.Role = previous
+		err = c.audit.Record(ctx, utils.AuditActionUpdate, utils.AuditEntityProfile, userId, &before, profile)
+		if err != nil {
+			return true, err
+		}
+	}
+
+	return true, nil
+}
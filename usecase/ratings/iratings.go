@@ -0,0 +1,12 @@
+package core
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type IRatings interface {
+	GetRating(ctx context.Context, filmId uint64, userId uint64) (*models.FilmRatingResponse, bool, error)
+	RateFilm(ctx context.Context, filmId uint64, userId uint64, rating int) (*models.FilmRatingResponse, bool, error)
+	DeleteRating(ctx context.Context, filmId uint64, userId uint64) (*models.FilmRatingResponse, bool, error)
+}
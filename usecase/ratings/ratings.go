@@ -0,0 +1,66 @@
+package core
+
+import (
+	"context"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/models"
+	"filmoteka/repository/psx"
+	"fmt"
+	"github.com/sirupsen/logrus"
+)
+
+type Ratings struct {
+	log     *logrus.Logger
+	ratings psx.IRatingRepo
+}
+
+func NewCoreRatings(ratings psx.IRatingRepo, log *logrus.Logger) *Ratings {
+	return &Ratings{
+		log:     log,
+		ratings: ratings,
+	}
+}
+
+func (c *Ratings) GetRating(ctx context.Context, filmId uint64, userId uint64) (*models.FilmRatingResponse, bool, error) {
+	rating, found, err := c.ratings.GetRating(ctx, filmId, userId)
+	if err != nil {
+		c.log.Errorf("get rating error: %s", err.Error())
+		return nil, false, fmt.Errorf("get rating error: %s", err.Error())
+	}
+
+	return rating, found, nil
+}
+
+func (c *Ratings) RateFilm(ctx context.Context, filmId uint64, userId uint64, rating int) (*models.FilmRatingResponse, bool, error) {
+	if rating < utils.VoteBegin || rating > utils.VoteEnd {
+		c.log.Error(utils.VoteSizeError)
+		return nil, false, fmt.Errorf(utils.VoteSizeError)
+	}
+
+	_, found, err := c.GetRating(ctx, filmId, userId)
+	if err != nil || !found {
+		return nil, found, err
+	}
+
+	err = c.ratings.RateFilm(ctx, filmId, userId, rating)
+	if err != nil {
+		c.log.Errorf("rate film error: %s", err.Error())
+		return nil, false, fmt.Errorf("rate film error: %s", err.Error())
+	}
+
+	return c.GetRating(ctx, filmId, userId)
+}
+
+func (c *Ratings) DeleteRating(ctx context.Context, filmId uint64, userId uint64) (*models.FilmRatingResponse, bool, error) {
+	deleted, err := c.ratings.DeleteRating(ctx, filmId, userId)
+	if err != nil {
+		c.log.Errorf("delete rating error: %s", err.Error())
+		return nil, false, fmt.Errorf("delete rating error: %s", err.Error())
+	}
+
+	if !deleted {
+		return nil, false, nil
+	}
+
+	return c.GetRating(ctx, filmId, userId)
+}
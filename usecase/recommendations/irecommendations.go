@@ -0,0 +1,11 @@
+package core
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type IRecommendations interface {
+	RefreshSimilarity(ctx context.Context) (int64, error)
+	FindRecommendations(ctx context.Context, userId uint64, limit uint64) ([]models.RecommendedFilmItem, error)
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+	"filmoteka/repository/psx"
+	"fmt"
+	"github.com/sirupsen/logrus"
+)
+
+type Recommendations struct {
+	log             *logrus.Logger
+	recommendations psx.IRecommendationRepo
+	neighbours      uint64
+	minRaters       uint64
+}
+
+func NewCoreRecommendations(recommendations psx.IRecommendationRepo, neighbours uint64, minRaters uint64, log *logrus.Logger) *Recommendations {
+	return &Recommendations{
+		log:             log,
+		recommendations: recommendations,
+		neighbours:      neighbours,
+		minRaters:       minRaters,
+	}
+}
+
+// RefreshSimilarity rebuilds the item-item model recommendations are made
+// from and returns the number of similar film pairs in it.
+func (c *Recommendations) RefreshSimilarity(ctx context.Context) (int64, error) {
+	pairs, err := c.recommendations.RefreshFilmSimilarity(ctx, c.neighbours, c.minRaters)
+	if err != nil {
+		c.log.Errorf("refresh film similarity error: %s", err.Error())
+		return 0, fmt.Errorf("refresh film similarity error: %s", err.Error())
+	}
+
+	c.log.Infof("film similarity refreshed: %d pairs", pairs)
+
+	return pairs, nil
+}
+
+// FindRecommendations returns up to limit films for the user, those predicted
+// from their ratings first. Users with too few ratings to predict from, and
+// users for whom there are fewer predictions than limit, get popular films
+// they have not rated after them.
+func (c *Recommendations) FindRecommendations(ctx context.Context, userId uint64, limit uint64) ([]models.RecommendedFilmItem, error) {
+	films, err := c.recommendations.FindRecommendedFilms(ctx, userId, limit)
+	if err != nil {
+		c.log.Errorf("find recommended films error: %s", err.Error())
+		return nil, fmt.Errorf("find recommended films error: %s", err.Error())
+	}
+
+	if uint64(len(films)) >= limit {
+		return films, nil
+	}
+
+	exclude := make([]uint64, 0, len(films))
+	for _, film := range films {
+		exclude = append(exclude, film.Id)
+	}
+
+	popular, err := c.recommendations.FindPopularFilms(ctx, userId, exclude, limit-uint64(len(films)))
+	if err != nil {
+		c.log.Errorf("find popular films error: %s", err.Error())
+		return nil, fmt.Errorf("find popular films error: %s", err.Error())
+	}
+
+	return append(films, popular...), nil
+}
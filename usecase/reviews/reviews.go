@@ -0,0 +1,103 @@
+package core
+
+import (
+	"context"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/models"
+	"filmoteka/repository/psx"
+	"fmt"
+	"github.com/sirupsen/logrus"
+)
+
+type Reviews struct {
+	log     *logrus.Logger
+	reviews psx.IReviewRepo
+	films   psx.IFilmRepo
+}
+
+func NewCoreReviews(reviews psx.IReviewRepo, films psx.IFilmRepo, log *logrus.Logger) *Reviews {
+	return &Reviews{
+		log:     log,
+		reviews: reviews,
+		films:   films,
+	}
+}
+
+func (c *Reviews) AddReview(ctx context.Context, filmId uint64, userId uint64, review *models.ReviewRequest) (uint64, bool, error) {
+	err := c.validateReview(review)
+	if err != nil {
+		return 0, false, err
+	}
+
+	reviewId, found, err := c.reviews.AddReview(ctx, filmId, userId, review)
+	if err != nil {
+		c.log.Errorf("add review error: %s", err.Error())
+		return 0, false, fmt.Errorf("add review error: %s", err.Error())
+	}
+
+	return reviewId, found, nil
+}
+
+func (c *Reviews) FindReviews(ctx context.Context, filmId uint64, page uint64, perPage uint64) (*models.ReviewsResponse, bool, error) {
+	_, found, err := c.films.GetFilm(ctx, filmId)
+	if err != nil {
+		c.log.Errorf("find reviews error: %s", err.Error())
+		return nil, false, fmt.Errorf("find reviews error: %s", err.Error())
+	}
+
+	if !found {
+		return nil, false, nil
+	}
+
+	reviews, err := c.reviews.FindReviews(ctx, filmId, page, perPage)
+	if err != nil {
+		c.log.Errorf("find reviews error: %s", err.Error())
+		return nil, false, fmt.Errorf("find reviews error: %s", err.Error())
+	}
+
+	return reviews, true, nil
+}
+
+func (c *Reviews) UpdateReview(ctx context.Context, userId uint64, review *models.ReviewRequest) (bool, error) {
+	err := c.validateReview(review)
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := c.reviews.UpdateReview(ctx, userId, review)
+	if err != nil {
+		c.log.Errorf("change review error: %s", err.Error())
+		return false, fmt.Errorf("change review error: %s", err.Error())
+	}
+
+	return updated, nil
+}
+
+func (c *Reviews) DeleteReview(ctx context.Context, userId uint64, reviewId uint64) (bool, error) {
+	deleted, err := c.reviews.DeleteReview(ctx, userId, reviewId)
+	if err != nil {
+		c.log.Errorf("delete review error: %s", err.Error())
+		return false, fmt.Errorf("delete review error: %s", err.Error())
+	}
+
+	return deleted, nil
+}
+
+func (c *Reviews) HideReview(ctx context.Context, reviewId uint64, hidden bool) (bool, error) {
+	updated, err := c.reviews.HideReview(ctx, reviewId, hidden)
+	if err != nil {
+		c.log.Errorf("hide review error: %s", err.Error())
+		return false, fmt.Errorf("hide review error: %s", err.Error())
+	}
+
+	return updated, nil
+}
+
+func (c *Reviews) validateReview(review *models.ReviewRequest) error {
+	err := utils.ValidateStringSize(review.Title, utils.ReviewTitleBegin, utils.ReviewTitleEnd, utils.ReviewTitleSizeError, c.log)
+	if err != nil {
+		return err
+	}
+
+	return utils.ValidateStringSize(review.Body, utils.ReviewBodyBegin, utils.ReviewBodyEnd, utils.ReviewBodySizeError, c.log)
+}
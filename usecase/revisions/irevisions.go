@@ -0,0 +1,15 @@
+package core
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type IRevisions interface {
+	RecordFilm(ctx context.Context, filmId uint64)
+	RecordActor(ctx context.Context, actorId uint64)
+	FindFilmRevisions(ctx context.Context, filmId uint64, page uint64, perPage uint64) (*models.RevisionsResponse, bool, error)
+	FindActorRevisions(ctx context.Context, actorId uint64, page uint64, perPage uint64) (*models.RevisionsResponse, bool, error)
+	RollbackFilm(ctx context.Context, filmId uint64, revision uint64) (bool, error)
+	RollbackActor(ctx context.Context, actorId uint64, revision uint64) (bool, error)
+}
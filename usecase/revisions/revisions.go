@@ -0,0 +1,304 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/models"
+	"filmoteka/repository/psx"
+	core_audit "filmoteka/usecase/audit"
+	core_versions "filmoteka/usecase/versions"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"slices"
+)
+
+type Revisions struct {
+	log       *logrus.Logger
+	revisions psx.IRevisionRepo
+	audit     core_audit.IAudit
+	versions  core_versions.IVersions
+}
+
+func NewCoreRevisions(revisions psx.IRevisionRepo, audit core_audit.IAudit, versions core_versions.IVersions, log *logrus.Logger) *Revisions {
+	return &Revisions{
+		log:       log,
+		revisions: revisions,
+		audit:     audit,
+		versions:  versions,
+	}
+}
+
+// RecordFilm saves the current state of the film as a new revision by the
+// user of ctx, if it changed since the latest one, and does the same for the
+// actors whose credits in the film changed. It is called once the change is
+// applied, so failures are logged rather than returned.
+func (c *Revisions) RecordFilm(ctx context.Context, filmId uint64) {
+	c.recordFilm(ctx, filmId)
+}
+
+// RecordActor saves the current state of the actor like RecordFilm, together
+// with the films whose cast changed with it.
+func (c *Revisions) RecordActor(ctx context.Context, actorId uint64) {
+	c.recordActor(ctx, actorId)
+}
+
+func (c *Revisions) recordFilm(ctx context.Context, filmId uint64) *models.Revision {
+	ctx = context.WithoutCancel(ctx)
+	userId, _ := ctx.Value(utils.UserIDKey).(uint64)
+
+	revision, added, err := c.revisions.AddFilmRevision(ctx, filmId, userId)
+	if err != nil {
+		c.log.Errorf("record film %d revision error: %s", filmId, err.Error())
+		return nil
+	}
+
+	if !added {
+		return revision
+	}
+
+	var before, after models.FilmRevisionData
+	err = unmarshalRevisions(revision, &before, &after)
+	if err != nil {
+		c.log.Errorf("record film %d revision error: %s", filmId, err.Error())
+		return revision
+	}
+
+	for _, actorId := range changedIds(before.Credits, after.Credits, func(credit models.CreditRequest) uint64 { return credit.ActorId }) {
+		_, _, err := c.revisions.AddActorRevision(ctx, actorId, userId)
+		if err != nil {
+			c.log.Errorf("record actor %d revision error: %s", actorId, err.Error())
+		}
+	}
+
+	return revision
+}
+
+func (c *Revisions) recordActor(ctx context.Context, actorId uint64) *models.Revision {
+	ctx = context.WithoutCancel(ctx)
+	userId, _ := ctx.Value(utils.UserIDKey).(uint64)
+
+	revision, added, err := c.revisions.AddActorRevision(ctx, actorId, userId)
+	if err != nil {
+		c.log.Errorf("record actor %d revision error: %s", actorId, err.Error())
+		return nil
+	}
+
+	if !added {
+		return revision
+	}
+
+	var before, after models.ActorRevisionData
+	err = unmarshalRevisions(revision, &before, &after)
+	if err != nil {
+		c.log.Errorf("record actor %d revision error: %s", actorId, err.Error())
+		return revision
+	}
+
+	for _, filmId := range changedIds(before.Credits, after.Credits, func(credit models.ActorRevisionCredit) uint64 { return credit.FilmId }) {
+		_, _, err := c.revisions.AddFilmRevision(ctx, filmId, userId)
+		if err != nil {
+			c.log.Errorf("record film %d revision error: %s", filmId, err.Error())
+		}
+	}
+
+	return revision
+}
+
+func (c *Revisions) FindFilmRevisions(ctx context.Context, filmId uint64, page uint64, perPage uint64) (*models.RevisionsResponse, bool, error) {
+	revisions, found, err := c.revisions.FindFilmRevisions(ctx, filmId, page, perPage)
+	if err != nil {
+		c.log.Errorf("find film revisions error: %s", err.Error())
+		return nil, false, fmt.Errorf("find film revisions error: %s", err.Error())
+	}
+
+	if !found {
+		return nil, false, nil
+	}
+
+	err = addChanges(revisions)
+	if err != nil {
+		c.log.Errorf("find film revisions error: %s", err.Error())
+		return nil, false, fmt.Errorf("find film revisions error: %s", err.Error())
+	}
+
+	return revisions, true, nil
+}
+
+func (c *Revisions) FindActorRevisions(ctx context.Context, actorId uint64, page uint64, perPage uint64) (*models.RevisionsResponse, bool, error) {
+	revisions, found, err := c.revisions.FindActorRevisions(ctx, actorId, page, perPage)
+	if err != nil {
+		c.log.Errorf("find actor revisions error: %s", err.Error())
+		return nil, false, fmt.Errorf("find actor revisions error: %s", err.Error())
+	}
+
+	if !found {
+		return nil, false, nil
+	}
+
+	err = addChanges(revisions)
+	if err != nil {
+		c.log.Errorf("find actor revisions error: %s", err.Error())
+		return nil, false, fmt.Errorf("find actor revisions error: %s", err.Error())
+	}
+
+	return revisions, true, nil
+}
+
+// RollbackFilm brings the film back to the given revision. The state being
+// replaced and the result are both kept as revisions, so a rollback can be
+// undone like any other change. Found is false when the film or the revision
+// does not exist.
+func (c *Revisions) RollbackFilm(ctx context.Context, filmId uint64, revision uint64) (bool, error) {
+	target, found, err := c.revisions.GetFilmRevision(ctx, filmId, revision)
+	if err != nil {
+		c.log.Errorf("rollback film error: %s", err.Error())
+		return false, fmt.Errorf("rollback film error: %s", err.Error())
+	}
+
+	if !found {
+		return false, nil
+	}
+
+	before := c.recordFilm(ctx, filmId)
+
+	found, err = c.revisions.RollbackFilm(ctx, filmId, target.Data)
+	if err != nil {
+		c.log.Errorf("rollback film error: %s", err.Error())
+		return false, fmt.Errorf("rollback film error: %s", err.Error())
+	}
+
+	if !found {
+		return false, nil
+	}
+
+	c.versions.Bump(ctx, utils.CatalogVersionSimilarFilms, utils.CatalogVersionCastGraph)
+	after := c.recordFilm(ctx, filmId)
+	err = c.audit.Record(ctx, utils.AuditActionRollback, utils.AuditEntityFilm, filmId, revisionData(before), revisionData(after))
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RollbackActor brings the actor back to the given revision, see RollbackFilm.
+func (c *Revisions) RollbackActor(ctx context.Context, actorId uint64, revision uint64) (bool, error) {
+	target, found, err := c.revisions.GetActorRevision(ctx, actorId, revision)
+	if err != nil {
+		c.log.Errorf("rollback actor error: %s", err.Error())
+		return false, fmt.Errorf("rollback actor error: %s", err.Error())
+	}
+
+	if !found {
+		return false, nil
+	}
+
+	before := c.recordActor(ctx, actorId)
+
+	found, err = c.revisions.RollbackActor(ctx, actorId, target.Data)
+	if err != nil {
+		c.log.Errorf("rollback actor error: %s", err.Error())
+		return false, fmt.Errorf("rollback actor error: %s", err.Error())
+	}
+
+	if !found {
+		return false, nil
+	}
+
+	c.versions.Bump(ctx, utils.CatalogVersionSimilarFilms, utils.CatalogVersionCastGraph)
+	after := c.recordActor(ctx, actorId)
+	err = c.audit.Record(ctx, utils.AuditActionRollback, utils.AuditEntityActor, actorId, revisionData(before), revisionData(after))
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// revisionData returns the document of revision for the audit log.
+func revisionData(revision *models.Revision) any {
+	if revision == nil {
+		return nil
+	}
+
+	return revision.Data
+}
+
+// unmarshalRevisions decodes the previous and the new document of a revision
+// that has just been added, the previous one is empty for the first revision.
+func unmarshalRevisions(revision *models.Revision, before any, after any) error {
+	if len(revision.Previous) > 0 {
+		err := json.Unmarshal(revision.Previous, before)
+		if err != nil {
+			return fmt.Errorf("unmarshal revision %d error: %s", revision.Revision-1, err.Error())
+		}
+	}
+
+	err := json.Unmarshal(revision.Data, after)
+	if err != nil {
+		return fmt.Errorf("unmarshal revision %d error: %s", revision.Revision, err.Error())
+	}
+
+	return nil
+}
+
+// changedIds returns the ids of the credits that are only in before or only
+// in after, once each.
+func changedIds[T comparable](before []T, after []T, id func(T) uint64) []uint64 {
+	var ids []uint64
+	for _, credit := range before {
+		if !slices.Contains(after, credit) && !slices.Contains(ids, id(credit)) {
+			ids = append(ids, id(credit))
+		}
+	}
+	for _, credit := range after {
+		if !slices.Contains(before, credit) && !slices.Contains(ids, id(credit)) {
+			ids = append(ids, id(credit))
+		}
+	}
+
+	return ids
+}
+
+// addChanges fills the field-level difference of every revision from the
+// revision before it.
+func addChanges(response *models.RevisionsResponse) error {
+	for i := range response.Revisions {
+		revision := &response.Revisions[i]
+
+		var before, after map[string]json.RawMessage
+		if len(revision.Previous) > 0 {
+			err := json.Unmarshal(revision.Previous, &before)
+			if err != nil {
+				return fmt.Errorf("unmarshal revision %d error: %s", revision.Revision-1, err.Error())
+			}
+		}
+
+		err := json.Unmarshal(revision.Data, &after)
+		if err != nil {
+			return fmt.Errorf("unmarshal revision %d error: %s", revision.Revision, err.Error())
+		}
+
+		fields := make([]string, 0, len(after))
+		for field := range after {
+			fields = append(fields, field)
+		}
+		for field := range before {
+			if _, found := after[field]; !found {
+				fields = append(fields, field)
+			}
+		}
+		slices.Sort(fields)
+
+		revision.Changes = make([]models.FieldChange, 0)
+		for _, field := range fields {
+			if !bytes.Equal(before[field], after[field]) {
+				revision.Changes = append(revision.Changes, models.FieldChange{Field: field, Before: before[field], After: after[field]})
+			}
+		}
+	}
+
+	return nil
+}
@@ -6,9 +6,12 @@ import (
 )
 
 type ISessions interface {
-	CreateSession(ctx context.Context, login string) (models.Session, error)
+	CreateSession(ctx context.Context, user *models.UserItem, ip string, userAgent string) (models.Session, error)
+	GetSession(ctx context.Context, sid string) (*models.Session, bool, error)
 	FindActiveSession(ctx context.Context, sid string) (bool, error)
 	KillSession(ctx context.Context, sid string) error
-	GetUserName(ctx context.Context, sid string) (string, error)
-	GetUserId(ctx context.Context, sid string) (uint64, error)
+	KillUserSessions(ctx context.Context, userId uint64) error
+	FindUserSessions(ctx context.Context, userId uint64, currentSid string) ([]models.SessionItem, error)
+	KillUserSession(ctx context.Context, userId uint64, id string) (bool, error)
+	KillOtherSessions(ctx context.Context, userId uint64, currentSid string) error
 }
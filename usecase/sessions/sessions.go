@@ -2,15 +2,27 @@ package core
 
 import (
 	"context"
-	utils "filmoteka/pkg"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"filmoteka/pkg/models"
 	"filmoteka/repository/psx"
 	"filmoteka/repository/session"
 	"fmt"
 	"github.com/sirupsen/logrus"
+	"slices"
 	"time"
 )
 
+const (
+	sessionTtl   = 24 * time.Hour
+	sessionIdLen = 32
+	// sessionTouchInterval bounds how often the last seen time of a session
+	// is written back, so most requests only read from Redis.
+	sessionTouchInterval = time.Minute
+)
+
 type Sessions struct {
 	log      *logrus.Logger
 	profiles psx.IProfileRepo
@@ -25,65 +37,88 @@ func NewCoreSessions(profiles psx.IProfileRepo, sessions session.ISessionRepo, l
 	}
 }
 
-func (c *Sessions) GetUserId(ctx context.Context, sid string) (uint64, error) {
-	login, err := c.sessions.GetUserLogin(ctx, sid, c.log)
-
-	if err != nil {
-		c.log.Errorf("get user login error: %s", err.Error())
-		return 0, fmt.Errorf("get user login error: %s", err.Error())
-	}
-
-	id, err := c.profiles.GetUserId(ctx, login)
+// newSessionId returns 256 random bits from the CSPRNG, URL safe for the cookie.
+func newSessionId() (string, error) {
+	id := make([]byte, sessionIdLen)
+	_, err := rand.Read(id)
 	if err != nil {
-		c.log.Errorf("get user id error: %s", err.Error())
-		return 0, fmt.Errorf("get user id error: %s", err.Error())
+		return "", err
 	}
 
-	return id, nil
+	return base64.RawURLEncoding.EncodeToString(id), nil
 }
 
-func (c *Sessions) GetUserName(ctx context.Context, sid string) (string, error) {
-	login, err := c.sessions.GetUserLogin(ctx, sid, c.log)
-
+func (c *Sessions) CreateSession(ctx context.Context, user *models.UserItem, ip string, userAgent string) (models.Session, error) {
+	sid, err := newSessionId()
 	if err != nil {
-		c.log.Errorf("get user name error: %s", err.Error())
-		return "", fmt.Errorf("get user name error: %s", err.Error())
+		c.log.Errorf("create session error: %s", err.Error())
+		return models.Session{}, fmt.Errorf("create session error: %s", err.Error())
 	}
 
-	return login, nil
-}
+	// The session version is read before the role, and a role change writes
+	// the role before it increments the version. A session made while the
+	// role changes is then either made with the new role or already void.
+	version, err := c.sessions.GetSessionsVersion(ctx, user.Id, c.log)
+	if err != nil {
+		c.log.Errorf("create session error: %s", err.Error())
+		return models.Session{}, fmt.Errorf("create session error: %s", err.Error())
+	}
 
-func (c *Sessions) CreateSession(ctx context.Context, login string) (models.Session, error) {
-	sid := utils.RandStringRunes(32)
+	role, err := c.profiles.GetRole(ctx, user.Id)
+	if err != nil {
+		c.log.Errorf("create session error: %s", err.Error())
+		return models.Session{}, fmt.Errorf("create session error: %s", err.Error())
+	}
 
+	now := time.Now()
 	newSession := models.Session{
-		Login:     login,
-		SID:       sid,
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		SID:        sid,
+		UserId:     user.Id,
+		Login:      user.Login,
+		Role:       role,
+		IP:         ip,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(sessionTtl),
+		Version:    version,
 	}
 
-	sessionAdded, err := c.sessions.AddSession(ctx, newSession, c.log)
+	err = c.sessions.AddSession(ctx, newSession, c.log)
+	if err != nil {
+		c.log.Errorf("create session error: %s", err.Error())
+		return models.Session{}, fmt.Errorf("create session error: %s", err.Error())
+	}
 
-	if !sessionAdded && err != nil {
-		return models.Session{}, err
+	return newSession, nil
+}
+
+func (c *Sessions) GetSession(ctx context.Context, sid string) (*models.Session, bool, error) {
+	active, found, err := c.sessions.GetSession(ctx, sid, c.log)
+	if err != nil {
+		c.log.Errorf("get session error: %s", err.Error())
+		return nil, false, fmt.Errorf("get session error: %s", err.Error())
 	}
 
-	if !sessionAdded {
-		return models.Session{}, nil
+	if found && time.Since(active.LastSeenAt) > sessionTouchInterval {
+		active.LastSeenAt = time.Now()
+		err = c.sessions.TouchSession(ctx, *active, c.log)
+		if err != nil {
+			c.log.Errorf("touch session error: %s", err.Error())
+		}
 	}
 
-	return newSession, nil
+	return active, found, nil
 }
 
 func (c *Sessions) FindActiveSession(ctx context.Context, sid string) (bool, error) {
-	login, err := c.sessions.CheckActiveSession(ctx, sid, c.log)
-
+	_, found, err := c.sessions.GetSession(ctx, sid, c.log)
 	if err != nil {
 		c.log.Errorf("find active session error: %s", err.Error())
 		return false, fmt.Errorf("find active session error: %s", err.Error())
 	}
 
-	return login, nil
+	return found, nil
 }
 
 func (c *Sessions) KillSession(ctx context.Context, sid string) error {
@@ -96,3 +131,98 @@ func (c *Sessions) KillSession(ctx context.Context, sid string) error {
 
 	return nil
 }
+
+func (c *Sessions) KillUserSessions(ctx context.Context, userId uint64) error {
+	err := c.sessions.DeleteUserSessions(ctx, userId, c.log)
+	if err != nil {
+		c.log.Errorf("delete user sessions error: %s", err.Error())
+		return fmt.Errorf("delete user sessions error: %s", err.Error())
+	}
+
+	return nil
+}
+
+// publicSessionId is the id a session is listed and revoked by. It is a hash
+// of the session id, so listing the sessions of a user never reveals what
+// could be used to sign in as them.
+func publicSessionId(sid string) string {
+	sum := sha256.Sum256([]byte(sid))
+
+	return hex.EncodeToString(sum[:16])
+}
+
+func (c *Sessions) FindUserSessions(ctx context.Context, userId uint64, currentSid string) ([]models.SessionItem, error) {
+	sessions, err := c.sessions.FindUserSessions(ctx, userId, c.log)
+	if err != nil {
+		c.log.Errorf("find user sessions error: %s", err.Error())
+		return nil, fmt.Errorf("find user sessions error: %s", err.Error())
+	}
+
+	items := make([]models.SessionItem, 0, len(sessions))
+	for _, active := range sessions {
+		items = append(items, models.SessionItem{
+			Id:         publicSessionId(active.SID),
+			UserAgent:  active.UserAgent,
+			IP:         active.IP,
+			CreatedAt:  active.CreatedAt,
+			LastSeenAt: active.LastSeenAt,
+			ExpiresAt:  active.ExpiresAt,
+			Current:    active.SID == currentSid,
+		})
+	}
+
+	slices.SortFunc(items, func(a, b models.SessionItem) int {
+		return b.LastSeenAt.Compare(a.LastSeenAt)
+	})
+
+	return items, nil
+}
+
+// KillUserSession ends the session of the user with the given public id.
+func (c *Sessions) KillUserSession(ctx context.Context, userId uint64, id string) (bool, error) {
+	sessions, err := c.sessions.FindUserSessions(ctx, userId, c.log)
+	if err != nil {
+		c.log.Errorf("delete user session error: %s", err.Error())
+		return false, fmt.Errorf("delete user session error: %s", err.Error())
+	}
+
+	for _, active := range sessions {
+		if publicSessionId(active.SID) != id {
+			continue
+		}
+
+		err = c.sessions.DeleteSessions(ctx, userId, []string{active.SID}, c.log)
+		if err != nil {
+			c.log.Errorf("delete user session error: %s", err.Error())
+			return false, fmt.Errorf("delete user session error: %s", err.Error())
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// KillOtherSessions ends every session of the user but the current one.
+func (c *Sessions) KillOtherSessions(ctx context.Context, userId uint64, currentSid string) error {
+	sessions, err := c.sessions.FindUserSessions(ctx, userId, c.log)
+	if err != nil {
+		c.log.Errorf("delete other sessions error: %s", err.Error())
+		return fmt.Errorf("delete other sessions error: %s", err.Error())
+	}
+
+	sids := make([]string, 0, len(sessions))
+	for _, active := range sessions {
+		if active.SID != currentSid {
+			sids = append(sids, active.SID)
+		}
+	}
+
+	err = c.sessions.DeleteSessions(ctx, userId, sids, c.log)
+	if err != nil {
+		c.log.Errorf("delete other sessions error: %s", err.Error())
+		return fmt.Errorf("delete other sessions error: %s", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,10 @@
+package core
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type ISimilar interface {
+	FindSimilarFilms(ctx context.Context, filmId uint64, limit uint64) ([]models.SimilarFilmItem, bool, error)
+}
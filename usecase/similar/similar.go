@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/models"
+	"filmoteka/repository/cache"
+	"filmoteka/repository/psx"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type Similar struct {
+	log     *logrus.Logger
+	similar psx.ISimilarRepo
+	cache   cache.ICacheRepo
+	ttl     time.Duration
+}
+
+func NewCoreSimilar(similar psx.ISimilarRepo, cache cache.ICacheRepo, ttl time.Duration, log *logrus.Logger) *Similar {
+	return &Similar{
+		log:     log,
+		similar: similar,
+		cache:   cache,
+		ttl:     ttl,
+	}
+}
+
+// FindSimilarFilms returns up to limit films ranked by what they have in
+// common with the film, found is false when there is no such film. Results
+// are cached under the current similar films version, so a change of any
+// cast or genres makes them miss, and expire after the ttl, which bounds how
+// stale ratings in them get. The cache is an optimization only, its errors
+// are logged and the films are ranked again.
+func (c *Similar) FindSimilarFilms(ctx context.Context, filmId uint64, limit uint64) ([]models.SimilarFilmItem, bool, error) {
+	version, err := c.similar.GetCatalogVersion(ctx, utils.CatalogVersionSimilarFilms)
+	if err != nil {
+		c.log.Errorf("get similar films version error: %s", err.Error())
+		return nil, false, fmt.Errorf("get similar films version error: %s", err.Error())
+	}
+
+	key := fmt.Sprintf("similar:%d:%d:%d", version, filmId, limit)
+
+	cached, found, err := c.cache.Get(ctx, key)
+	if err != nil {
+		c.log.Errorf("get cached similar films error: %s", err.Error())
+	}
+
+	if found {
+		var films []models.SimilarFilmItem
+
+		err = json.Unmarshal(cached, &films)
+		if err == nil {
+			return films, true, nil
+		}
+		c.log.Errorf("decode cached similar films error: %s", err.Error())
+	}
+
+	films, found, err := c.similar.FindSimilarFilms(ctx, filmId, limit)
+	if err != nil {
+		c.log.Errorf("find similar films error: %s", err.Error())
+		return nil, false, fmt.Errorf("find similar films error: %s", err.Error())
+	}
+
+	if !found {
+		return nil, false, nil
+	}
+
+	data, err := json.Marshal(films)
+	if err == nil {
+		err = c.cache.Set(ctx, key, data, c.ttl)
+	}
+	if err != nil {
+		c.log.Errorf("cache similar films error: %s", err.Error())
+	}
+
+	return films, true, nil
+}
@@ -0,0 +1,10 @@
+package core
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type IStats interface {
+	GetStats(ctx context.Context, request *models.StatsRequest) (*models.StatsResponse, error)
+}
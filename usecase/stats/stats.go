@@ -0,0 +1,38 @@
+package core
+
+import (
+	"context"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/models"
+	"filmoteka/repository/psx"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"slices"
+)
+
+type Stats struct {
+	log   *logrus.Logger
+	stats psx.IStatsRepo
+}
+
+func NewCoreStats(stats psx.IStatsRepo, log *logrus.Logger) *Stats {
+	return &Stats{
+		log:   log,
+		stats: stats,
+	}
+}
+
+func (c *Stats) GetStats(ctx context.Context, request *models.StatsRequest) (*models.StatsResponse, error) {
+	if !slices.Contains(utils.StatsIntervals, request.Interval) {
+		c.log.Error(utils.StatsIntervalError)
+		return nil, fmt.Errorf(utils.StatsIntervalError)
+	}
+
+	stats, err := c.stats.GetStats(ctx, request)
+	if err != nil {
+		c.log.Errorf("get stats error: %s", err.Error())
+		return nil, fmt.Errorf("get stats error: %s", err.Error())
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,13 @@
+package core
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type ITokens interface {
+	CreateToken(ctx context.Context, userId uint64, request *models.AccessTokenRequest) (*models.AccessTokenResponse, error)
+	FindTokens(ctx context.Context, userId uint64) ([]models.AccessTokenItem, error)
+	RevokeToken(ctx context.Context, userId uint64, tokenId uint64) (bool, error)
+	CheckToken(ctx context.Context, token string) (*models.AccessToken, bool, error)
+}
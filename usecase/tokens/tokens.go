@@ -0,0 +1,107 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/models"
+	"filmoteka/repository/psx"
+	core_audit "filmoteka/usecase/audit"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"strings"
+)
+
+const tokenLen = 32
+
+type Tokens struct {
+	log    *logrus.Logger
+	tokens psx.ITokenRepo
+	audit  core_audit.IAudit
+}
+
+func NewCoreTokens(tokens psx.ITokenRepo, audit core_audit.IAudit, log *logrus.Logger) *Tokens {
+	return &Tokens{
+		log:    log,
+		tokens: tokens,
+		audit:  audit,
+	}
+}
+
+// hashToken is what is stored for a token. Tokens are random, so a fast hash
+// without salt is enough to keep them unusable if the table leaks.
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+
+	return sum[:]
+}
+
+// CreateToken makes a token for the user, it is returned this once and only
+// its hash is kept.
+func (c *Tokens) CreateToken(ctx context.Context, userId uint64, request *models.AccessTokenRequest) (*models.AccessTokenResponse, error) {
+	secret := make([]byte, tokenLen)
+	_, err := rand.Read(secret)
+	if err != nil {
+		c.log.Errorf("create token error: %s", err.Error())
+		return nil, fmt.Errorf("create token error: %s", err.Error())
+	}
+	token := utils.TokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	item, err := c.tokens.AddToken(ctx, userId, request.Name, hashToken(token), request.Scopes)
+	if err != nil {
+		c.log.Errorf("create token error: %s", err.Error())
+		return nil, fmt.Errorf("create token error: %s", err.Error())
+	}
+
+	err = c.audit.Record(ctx, utils.AuditActionAdd, utils.AuditEntityToken, item.Id, nil, item)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AccessTokenResponse{AccessTokenItem: *item, Token: token}, nil
+}
+
+func (c *Tokens) FindTokens(ctx context.Context, userId uint64) ([]models.AccessTokenItem, error) {
+	tokens, err := c.tokens.FindTokens(ctx, userId)
+	if err != nil {
+		c.log.Errorf("find tokens error: %s", err.Error())
+		return nil, fmt.Errorf("find tokens error: %s", err.Error())
+	}
+
+	return tokens, nil
+}
+
+func (c *Tokens) RevokeToken(ctx context.Context, userId uint64, tokenId uint64) (bool, error) {
+	item, found, err := c.tokens.RevokeToken(ctx, userId, tokenId)
+	if err != nil {
+		c.log.Errorf("revoke token error: %s", err.Error())
+		return false, fmt.Errorf("revoke token error: %s", err.Error())
+	}
+
+	if found {
+		err = c.audit.Record(ctx, utils.AuditActionDelete, utils.AuditEntityToken, tokenId, item, nil)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return found, nil
+}
+
+// CheckToken returns the user and scopes of a valid token. Found is false for
+// a revoked, unknown or malformed token.
+func (c *Tokens) CheckToken(ctx context.Context, token string) (*models.AccessToken, bool, error) {
+	if !strings.HasPrefix(token, utils.TokenPrefix) {
+		return nil, false, nil
+	}
+
+	accessToken, found, err := c.tokens.FindTokenByHash(ctx, hashToken(token))
+	if err != nil {
+		c.log.Errorf("check token error: %s", err.Error())
+		return nil, false, fmt.Errorf("check token error: %s", err.Error())
+	}
+
+	return accessToken, found, nil
+}
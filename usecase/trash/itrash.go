@@ -0,0 +1,14 @@
+package core
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+)
+
+type ITrash interface {
+	FindDeletedFilms(ctx context.Context, page uint64, perPage uint64) (*models.FilmsResponse, error)
+	FindDeletedActors(ctx context.Context, page uint64, perPage uint64) (*models.ActorsResponse, error)
+	RestoreFilm(ctx context.Context, filmId uint64) (bool, error)
+	RestoreActor(ctx context.Context, actorId uint64) (bool, error)
+	Purge(ctx context.Context) (*models.PurgeReport, error)
+}
@@ -0,0 +1,165 @@
+package core
+
+import (
+	"context"
+	"errors"
+	utils "filmoteka/pkg"
+	"filmoteka/pkg/models"
+	"filmoteka/repository/psx"
+	core_actors "filmoteka/usecase/actors"
+	core_audit "filmoteka/usecase/audit"
+	core_films "filmoteka/usecase/films"
+	core_images "filmoteka/usecase/images"
+	core_versions "filmoteka/usecase/versions"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type Trash struct {
+	log       *logrus.Logger
+	trash     psx.ITrashRepo
+	films     core_films.IFilms
+	actors    core_actors.IActors
+	images    core_images.IImages
+	audit     core_audit.IAudit
+	versions  core_versions.IVersions
+	retention time.Duration
+}
+
+func NewCoreTrash(trash psx.ITrashRepo, films core_films.IFilms, actors core_actors.IActors, images core_images.IImages,
+	audit core_audit.IAudit, versions core_versions.IVersions, retention time.Duration, log *logrus.Logger) *Trash {
+	return &Trash{
+		log:       log,
+		trash:     trash,
+		films:     films,
+		actors:    actors,
+		images:    images,
+		audit:     audit,
+		versions:  versions,
+		retention: retention,
+	}
+}
+
+func (c *Trash) FindDeletedFilms(ctx context.Context, page uint64, perPage uint64) (*models.FilmsResponse, error) {
+	films, err := c.trash.FindDeletedFilms(ctx, page, perPage)
+	if err != nil {
+		c.log.Errorf("find deleted films error: %s", err.Error())
+		return nil, fmt.Errorf("find deleted films error: %s", err.Error())
+	}
+
+	return films, nil
+}
+
+func (c *Trash) FindDeletedActors(ctx context.Context, page uint64, perPage uint64) (*models.ActorsResponse, error) {
+	actors, err := c.trash.FindDeletedActors(ctx, page, perPage)
+	if err != nil {
+		c.log.Errorf("find deleted actors error: %s", err.Error())
+		return nil, fmt.Errorf("find deleted actors error: %s", err.Error())
+	}
+
+	return actors, nil
+}
+
+func (c *Trash) RestoreFilm(ctx context.Context, filmId uint64) (bool, error) {
+	deletedAt, restored, err := c.trash.RestoreFilm(ctx, filmId)
+	if err != nil {
+		c.log.Errorf("restore film error: %s", err.Error())
+		return false, fmt.Errorf("restore film error: %s", err.Error())
+	}
+
+	if restored {
+		c.versions.Bump(ctx, utils.CatalogVersionSimilarFilms, utils.CatalogVersionCastGraph)
+		before, after := c.filmStates(ctx, filmId, deletedAt)
+		err = c.audit.Record(ctx, utils.AuditActionRestore, utils.AuditEntityFilm, filmId, before, after)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return restored, nil
+}
+
+func (c *Trash) RestoreActor(ctx context.Context, actorId uint64) (bool, error) {
+	deletedAt, restored, err := c.trash.RestoreActor(ctx, actorId)
+	if err != nil {
+		c.log.Errorf("restore actor error: %s", err.Error())
+		return false, fmt.Errorf("restore actor error: %s", err.Error())
+	}
+
+	if restored {
+		c.versions.Bump(ctx, utils.CatalogVersionSimilarFilms, utils.CatalogVersionCastGraph)
+		before, after := c.actorStates(ctx, actorId, deletedAt)
+		err = c.audit.Record(ctx, utils.AuditActionRestore, utils.AuditEntityActor, actorId, before, after)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return restored, nil
+}
+
+// filmStates returns the restored film for the audit log, before as it was in
+// the trash and after as it is now: restoring changes nothing but deleted_at.
+func (c *Trash) filmStates(ctx context.Context, filmId uint64, deletedAt time.Time) (any, any) {
+	film, found, err := c.films.GetFilm(ctx, filmId)
+	if err != nil || !found {
+		return nil, nil
+	}
+
+	film.Franchises, film.Relations = nil, nil
+	trashed := *film
+	trashed.DeletedAt = &deletedAt
+
+	return &trashed, film
+}
+
+// actorStates returns the restored actor for the audit log, see filmStates.
+func (c *Trash) actorStates(ctx context.Context, actorId uint64, deletedAt time.Time) (any, any) {
+	actor, found, err := c.actors.GetActor(ctx, actorId)
+	if err != nil || !found {
+		return nil, nil
+	}
+
+	trashed := *actor
+	trashed.DeletedAt = &deletedAt
+
+	return &trashed, actor
+}
+
+// Purge permanently removes everything that has stayed in the trash longer
+// than the retention period, together with the posters and photos.
+func (c *Trash) Purge(ctx context.Context) (*models.PurgeReport, error) {
+	report, err := c.trash.PurgeDeleted(ctx, time.Now().Add(-c.retention))
+	if err != nil {
+		c.log.Errorf("purge trash error: %s", err.Error())
+		return nil, fmt.Errorf("purge trash error: %s", err.Error())
+	}
+
+	for dir, names := range report.Images {
+		c.images.RemoveImages(ctx, dir, names)
+	}
+
+	// every purged record is audited even after a failed entry, the purge
+	// itself cannot be undone
+	var errs []error
+	for i := range report.PurgedFilms {
+		film := &report.PurgedFilms[i]
+		errs = append(errs, c.audit.Record(ctx, utils.AuditActionPurge, utils.AuditEntityFilm, film.Id, film, nil))
+	}
+	for i := range report.PurgedActors {
+		actor := &report.PurgedActors[i]
+		errs = append(errs, c.audit.Record(ctx, utils.AuditActionPurge, utils.AuditEntityActor, actor.Id, actor, nil))
+	}
+
+	if report.Films > 0 || report.Actors > 0 {
+		c.log.Infof("purged %d films and %d actors from the trash", report.Films, report.Actors)
+	}
+
+	err = errors.Join(errs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,9 @@
+package core
+
+import (
+	"context"
+)
+
+type IVersions interface {
+	Bump(ctx context.Context, names ...string)
+}
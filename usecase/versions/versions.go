@@ -0,0 +1,31 @@
+package core
+
+import (
+	"context"
+	"filmoteka/repository/psx"
+	"github.com/sirupsen/logrus"
+)
+
+type Versions struct {
+	log      *logrus.Logger
+	versions psx.IVersionRepo
+}
+
+func NewCoreVersions(versions psx.IVersionRepo, log *logrus.Logger) *Versions {
+	return &Versions{
+		log:      log,
+		versions: versions,
+	}
+}
+
+// Bump moves on the catalog versions names, see utils.CatalogVersion, once a
+// change of the data they stand for is committed. A cache filled from the old
+// data in between is keyed by the old version, so it goes stale with the
+// bump. Like Audit.Record it is called after the change, so it outlives a
+// cancelled request, and a failure is logged rather than returned.
+func (c *Versions) Bump(ctx context.Context, names ...string) {
+	err := c.versions.BumpCatalogVersions(context.WithoutCancel(ctx), names)
+	if err != nil {
+		c.log.Errorf("bump catalog versions error: %s", err.Error())
+	}
+}